@@ -2,59 +2,64 @@ package client
 
 import (
 	"context"
-	"time"
 )
 
-func (cli *EtcdClient) getAuthStatusWithRetries(retries uint64) (bool, error) {
-	ctx, cancel := context.WithTimeout(cli.Context, cli.RequestTimeout)
-	defer cancel()
-
-	resp, err := cli.Client.AuthStatus(ctx)
-	if err != nil {
-		if !shouldRetry(err, retries) {
-			return false, err
-		}
-
-		time.Sleep(cli.RetryInterval)
-		return cli.getAuthStatusWithRetries(retries - 1)
-	}
-
-	return resp.Enabled, nil
-}
-
 /*
 Retrieves the authentication status (enabled or not) of the etcd cluster.
 */
 func (cli *EtcdClient) GetAuthStatus() (bool, error) {
-	return cli.getAuthStatusWithRetries(cli.Retries)
+	return cli.GetAuthStatusCtx(cli.Context)
 }
 
-func (cli *EtcdClient) setAuthStatusWithRetries(enabled bool, retries uint64) error {
-	ctx, cancel := context.WithTimeout(cli.Context, cli.RequestTimeout)
-	defer cancel()
+/*
+Variant of GetAuthStatus that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) GetAuthStatusCtx(ctx context.Context) (_ bool, err error) {
+	ctx, op := cli.startOperation(ctx, "GetAuthStatus", "")
+	defer func() { op.end(err) }()
 
-	var err error
-	if enabled {
-		_, err = cli.Client.AuthEnable(ctx)
-	} else {
-		_, err = cli.Client.AuthDisable(ctx)
-	}
+	enabled := false
+	err = cli.withRetries(ctx, func() error {
+		ctx, cancel := context.WithTimeout(ctx, cli.RequestTimeout)
+		defer cancel()
 
-	if err != nil {
-		if !shouldRetry(err, retries) {
+		resp, err := cli.Client.AuthStatus(ctx)
+		if err != nil {
 			return err
 		}
 
-		time.Sleep(cli.RetryInterval)
-		return cli.setAuthStatusWithRetries(enabled, retries - 1)
-	}
+		enabled = resp.Enabled
+		return nil
+	})
 
-	return nil
+	return enabled, err
 }
 
 /*
 Sets the authentication status (enabled or not) of the etcd cluster.
 */
 func (cli *EtcdClient) SetAuthStatus(enable bool) error {
-	return cli.setAuthStatusWithRetries(enable, cli.Retries)
+	return cli.SetAuthStatusCtx(cli.Context, enable)
+}
+
+/*
+Variant of SetAuthStatus that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) SetAuthStatusCtx(ctx context.Context, enable bool) (err error) {
+	ctx, op := cli.startOperation(ctx, "SetAuthStatus", "")
+	defer func() { op.end(err) }()
+
+	return cli.withRetries(ctx, func() error {
+		ctx, cancel := context.WithTimeout(ctx, cli.RequestTimeout)
+		defer cancel()
+
+		var err error
+		if enable {
+			_, err = cli.Client.AuthEnable(ctx)
+		} else {
+			_, err = cli.Client.AuthDisable(ctx)
+		}
+
+		return err
+	})
 }
@@ -0,0 +1,235 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+func loadClientCertificateKeyFile(path string) (tls.Certificate, error) {
+	fData, fileErr := os.ReadFile(path)
+	if fileErr != nil {
+		return tls.Certificate{}, errors.New(fmt.Sprintf("Failed to load file containing client cert and key: %s", fileErr.Error()))
+	}
+
+	certBlock, rest := pem.Decode(fData)
+	if certBlock == nil {
+		return tls.Certificate{}, errors.New("Failed to read certificate from file containing client cert and key")
+	}
+
+	keyBlock, _ := pem.Decode(rest)
+	if keyBlock == nil {
+		return tls.Certificate{}, errors.New("Failed to read key from file containing client cert and key")
+	}
+
+	certData, err := tls.X509KeyPair(pem.EncodeToMemory(certBlock), pem.EncodeToMemory(keyBlock))
+	if err != nil {
+		return tls.Certificate{}, errors.New(fmt.Sprintf("Failed to load user credentials: %s", err.Error()))
+	}
+
+	return certData, nil
+}
+
+func loadClientCertificateFiles(certPath string, keyPath string, certKeyPath string) (tls.Certificate, error) {
+	if certKeyPath != "" {
+		return loadClientCertificateKeyFile(certKeyPath)
+	}
+
+	certData, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		return tls.Certificate{}, errors.New(fmt.Sprintf("Failed to load user credentials: %s", err.Error()))
+	}
+
+	return certData, nil
+}
+
+func loadClientCertificate(opts EtcdClientOptions) (tls.Certificate, error) {
+	if len(opts.ClientCert) > 0 || len(opts.ClientKey) > 0 {
+		certData, err := tls.X509KeyPair(opts.ClientCert, opts.ClientKey)
+		if err != nil {
+			return tls.Certificate{}, errors.New(fmt.Sprintf("Failed to load user credentials: %s", err.Error()))
+		}
+
+		return certData, nil
+	}
+
+	return loadClientCertificateFiles(opts.ClientCertPath, opts.ClientKeyPath, opts.ClientCertKeyPath)
+}
+
+func getModTimes(paths ...string) ([]time.Time, error) {
+	modTimes := []time.Time{}
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+
+		stat, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		modTimes = append(modTimes, stat.ModTime())
+	}
+
+	return modTimes, nil
+}
+
+func modTimesEqual(first []time.Time, second []time.Time) bool {
+	if len(first) != len(second) {
+		return false
+	}
+
+	for idx, _ := range first {
+		if !first[idx].Equal(second[idx]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+/*
+Loads the client certificate from files and reloads it when the files are modified.
+*/
+type clientCertLoader struct {
+	certPath    string
+	keyPath     string
+	certKeyPath string
+	modTimes    []time.Time
+	cert        *tls.Certificate
+	lock        sync.Mutex
+}
+
+func newClientCertLoader(certPath string, keyPath string, certKeyPath string) *clientCertLoader {
+	return &clientCertLoader{
+		certPath:    certPath,
+		keyPath:     keyPath,
+		certKeyPath: certKeyPath,
+	}
+}
+
+/*
+Returns the client certificate, reading the files again first if they changed since the last call.
+If the files cannot be read back, the last valid certificate is returned as the files may be in the middle of a rotation.
+It has the signature expected by the GetClientCertificate callback of tls configurations.
+*/
+func (l *clientCertLoader) GetClientCertificate(_ *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	modTimes, statErr := getModTimes(l.certPath, l.keyPath, l.certKeyPath)
+	if statErr != nil {
+		if l.cert != nil {
+			return l.cert, nil
+		}
+
+		return nil, errors.New(fmt.Sprintf("Failed to load user credentials: %s", statErr.Error()))
+	}
+
+	if l.cert != nil && modTimesEqual(modTimes, l.modTimes) {
+		return l.cert, nil
+	}
+
+	cert, err := loadClientCertificateFiles(l.certPath, l.keyPath, l.certKeyPath)
+	if err != nil {
+		if l.cert != nil {
+			return l.cert, nil
+		}
+
+		return nil, err
+	}
+
+	l.cert = &cert
+	l.modTimes = modTimes
+	return l.cert, nil
+}
+
+/*
+Loads the CA certificate from a file and reloads it when the file is modified.
+*/
+type caCertLoader struct {
+	path    string
+	modTime time.Time
+	roots   *x509.CertPool
+	lock    sync.Mutex
+}
+
+func newCaCertLoader(path string) *caCertLoader {
+	return &caCertLoader{path: path}
+}
+
+/*
+Returns the pool of CA certificates, reading the file again first if it changed since the last call.
+If the file cannot be read back, the last valid pool is returned as the file may be in the middle of a rotation.
+*/
+func (l *caCertLoader) GetRoots() (*x509.CertPool, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	stat, statErr := os.Stat(l.path)
+	if statErr != nil {
+		if l.roots != nil {
+			return l.roots, nil
+		}
+
+		return nil, errors.New(fmt.Sprintf("Failed to read root certificate file: %s", statErr.Error()))
+	}
+
+	if l.roots != nil && stat.ModTime().Equal(l.modTime) {
+		return l.roots, nil
+	}
+
+	caCertContent, err := os.ReadFile(l.path)
+	if err != nil {
+		if l.roots != nil {
+			return l.roots, nil
+		}
+
+		return nil, errors.New(fmt.Sprintf("Failed to read root certificate file: %s", err.Error()))
+	}
+
+	roots := x509.NewCertPool()
+	ok := roots.AppendCertsFromPEM(caCertContent)
+	if !ok {
+		if l.roots != nil {
+			return l.roots, nil
+		}
+
+		return nil, errors.New("Failed to parse root certificate authority")
+	}
+
+	l.roots = roots
+	l.modTime = stat.ModTime()
+	return l.roots, nil
+}
+
+/*
+Verifies the certificate chain presented by the server against the latest CA certificate.
+It has the signature expected by the VerifyConnection callback of tls configurations.
+*/
+func (l *caCertLoader) VerifyConnection(state tls.ConnectionState) error {
+	if len(state.PeerCertificates) == 0 {
+		return errors.New("Server did not present a certificate")
+	}
+
+	roots, err := l.GetRoots()
+	if err != nil {
+		return err
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, verifyErr := state.PeerCertificates[0].Verify(x509.VerifyOptions{
+		DNSName:       state.ServerName,
+		Roots:         roots,
+		Intermediates: intermediates,
+	})
+	return verifyErr
+}
@@ -4,10 +4,8 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
-	"time"
 
 	clientv3 "go.etcd.io/etcd/client/v3"
 )
@@ -18,9 +16,12 @@ type ChunkedKeySnapshot struct {
 }
 
 type ChunkedKeyInfo struct {
-	Size    int64
-	Count   int64
-	Version int64
+	//Size of the value, before compression
+	Size        int64
+	Count       int64
+	Version     int64
+	//Algorithm the value was compressed with before being split in chunks. Empty if it is not compressed.
+	Compression CompressionAlgorithm `json:",omitempty"`
 }
 
 type ChunkedKeyPayload struct {
@@ -37,8 +38,8 @@ func (p *ChunkedKeyPayload) Read(r []byte) (n int, err error) {
 	return p.Value.Read(r)
 }
 
-func (cli *EtcdClient) getChunkedKeyInfo(key string) (*ChunkedKeyInfo, int64, error) {
-	info, err := cli.GetKey(fmt.Sprintf("%s/info", key), GetKeyOptions{})
+func (cli *EtcdClient) getChunkedKeyInfo(ctx context.Context, key string) (*ChunkedKeyInfo, int64, error) {
+	info, err := cli.GetKeyCtx(ctx, fmt.Sprintf("%s/info", key), GetKeyOptions{})
 	if err != nil || (!info.Found()) {
 		return nil, 0, err
 	}
@@ -52,31 +53,37 @@ func (cli *EtcdClient) getChunkedKeyInfo(key string) (*ChunkedKeyInfo, int64, er
 	return &cKeyInfo, info.ModRevision, nil
 }
 
-func (cli *EtcdClient) persistVersionChange(key string, info ChunkedKeyInfo, retries uint64) error {
-	ctx, cancel := context.WithTimeout(context.Background(), cli.RequestTimeout)
-	defer cancel()
-
+func (cli *EtcdClient) persistVersionChange(ctx context.Context, key string, info ChunkedKeyInfo) error {
 	output, _ := json.Marshal(info)
 	previousChunks := fmt.Sprintf("%s/chunks/v%d/", key, info.Version-1)
-	tx := cli.Client.Txn(ctx).Then(
-		clientv3.OpPut(fmt.Sprintf("%s/info", key), string(output)),
-		clientv3.OpDelete(previousChunks, clientv3.WithRange(clientv3.GetPrefixRangeEnd(previousChunks))),
-	)
-
-	_, err := tx.Commit()
-	if err != nil {
-		if shouldRetry(err, retries) {
-			time.Sleep(cli.RetryInterval)
-			return cli.persistVersionChange(key, info, retries-1)
-		}
-	}
 
-	return err
+	return cli.withRetries(ctx, func() error {
+		ctx, cancel := context.WithTimeout(ctx, cli.RequestTimeout)
+		defer cancel()
+
+		tx := cli.Client.Txn(ctx).Then(
+			clientv3.OpPut(fmt.Sprintf("%s/info", key), string(output)),
+			clientv3.OpDelete(previousChunks, clientv3.WithRange(clientv3.GetPrefixRangeEnd(previousChunks))),
+		)
+
+		_, err := tx.Commit()
+		return err
+	})
 }
 
 func (cli *EtcdClient) PutChunkedKey(key *ChunkedKeyPayload) error {
+	return cli.PutChunkedKeyCtx(cli.Context, key)
+}
+
+/*
+Variant of PutChunkedKey that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) PutChunkedKeyCtx(ctx context.Context, key *ChunkedKeyPayload) (err error) {
+	ctx, op := cli.startOperation(ctx, "PutChunkedKey", key.Key)
+	defer func() { op.end(err) }()
+
 	cMaxSize := int64(1024 * 1024)
-	keyInfo, _, infoErr := cli.getChunkedKeyInfo(key.Key)
+	keyInfo, _, infoErr := cli.getChunkedKeyInfo(ctx, key.Key)
 	if infoErr != nil {
 		return infoErr
 	}
@@ -89,68 +96,102 @@ func (cli *EtcdClient) PutChunkedKey(key *ChunkedKeyPayload) error {
 	}
 
 	//Cleanup before write in case a previous write attempt aborted in error
-	clearErr := cli.DeletePrefix(fmt.Sprintf("%s/chunks/v%d/", key.Key, version+1))
+	clearErr := cli.DeletePrefixCtx(ctx, fmt.Sprintf("%s/chunks/v%d/", key.Key, version+1))
 	if clearErr != nil {
 		return clearErr
 	}
 
-	//Write all the chunks
-	chunks := key.Size / cMaxSize
-	if (key.Size % cMaxSize) > 0 {
-		chunks += 1
+	//The value is compressed as a stream if it is above the threshold, so that the count of chunks reflects the compressed size
+	src := &io.LimitedReader{R: key.Value, N: key.Size}
+	reader := io.Reader(src)
+	compression := CompressionAlgorithm("")
+	if cli.compression != nil && key.Size >= int64(cli.compression.threshold) {
+		compressed := cli.compression.compressStream(src)
+		defer compressed.Close()
+		reader = compressed
+		compression = cli.compression.algorithm
 	}
 
+	//Write all the chunks
+	chunks := int64(0)
 	buf := make([]byte, cMaxSize)
-	for idx := int64(0); idx < chunks; idx++ {
-		cKey := fmt.Sprintf("%s/chunks/v%d/%d", key.Key, version+1, idx)
-
-		if idx < (chunks-1) || (key.Size%cMaxSize) == 0 {
-			_, readErr := io.ReadFull(key.Value, buf)
-			if readErr != nil {
-				return readErr
-			}
-
-			_, putErr := cli.PutKey(cKey, string(buf))
-			if putErr != nil {
-				return putErr
-			}
-		} else {
-			_, readErr := io.ReadAtLeast(key.Value, buf, int(key.Size%cMaxSize))
-			if readErr != nil {
-				return readErr
-			}
-
-			_, putErr := cli.PutKey(cKey, string(buf[:key.Size%cMaxSize]))
-			if putErr != nil {
-				return putErr
-			}
+	for {
+		read, readErr := io.ReadFull(reader, buf)
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil && readErr != io.ErrUnexpectedEOF {
+			return readErr
 		}
+
+		cKey := fmt.Sprintf("%s/chunks/v%d/%d", key.Key, version+1, chunks)
+		putErr := cli.putChunk(ctx, cKey, buf[:read])
+		if putErr != nil {
+			return putErr
+		}
+		chunks += 1
+
+		if readErr == io.ErrUnexpectedEOF {
+			break
+		}
+	}
+
+	if src.N > 0 {
+		return io.ErrUnexpectedEOF
 	}
 
 	//update chunk info and delete previous version chunks as single transaction
-	return cli.persistVersionChange(key.Key, ChunkedKeyInfo{
-		Size:    key.Size,
-		Count:   chunks,
-		Version: version + 1,
-	}, cli.Retries)
+	return cli.persistVersionChange(ctx, key.Key, ChunkedKeyInfo{
+		Size:        key.Size,
+		Count:       chunks,
+		Version:     version + 1,
+		Compression: compression,
+	})
 }
 
 type ChunksReader struct {
-	Client   *EtcdClient
-	Key      string
-	Index    int64
-	Buffer   *bytes.Buffer
-	Snapshot ChunkedKeySnapshot
+	Client       *EtcdClient
+	Context      context.Context
+	Key          string
+	Index        int64
+	Buffer       *bytes.Buffer
+	Snapshot     ChunkedKeySnapshot
+	decompressed io.ReadCloser
 }
 
 func (r *ChunksReader) Close() error {
+	if r.decompressed != nil {
+		r.decompressed.Close()
+		r.decompressed = nil
+	}
 	r.Client = nil
+	r.Context = nil
 	r.Buffer = nil
 	r.Snapshot = ChunkedKeySnapshot{}
 	return nil
 }
 
+/*
+Reads the chunked key's value, fetching the chunks one at a time and decompressing them if the value was compressed.
+Returns a KeyError matching ErrChunkMissing if a chunk is not found, which happens if the key was modified during the read.
+*/
 func (r *ChunksReader) Read(p []byte) (n int, err error) {
+	if r.Snapshot.Info.Compression == "" {
+		return r.readChunks(p)
+	}
+
+	if r.decompressed == nil {
+		decompressed, decErr := newDecompressReader(r.Snapshot.Info.Compression, readerFunc(r.readChunks))
+		if decErr != nil {
+			return 0, &KeyError{Key: r.Key, Kind: ErrDecompressionFailed, Cause: decErr}
+		}
+		r.decompressed = decompressed
+	}
+
+	return r.decompressed.Read(p)
+}
+
+func (r *ChunksReader) readChunks(p []byte) (n int, err error) {
 	unread := r.Buffer.Len()
 	if unread > 0 {
 		return r.Buffer.Read(p)
@@ -161,12 +202,17 @@ func (r *ChunksReader) Read(p []byte) (n int, err error) {
 	}
 
 	chunkKey := fmt.Sprintf("%s/chunks/v%d/%d", r.Key, r.Snapshot.Info.Version, r.Index)
-	kInfo, kErr := r.Client.GetKey(chunkKey, GetKeyOptions{})
+	ctx := r.Context
+	if ctx == nil {
+		ctx = r.Client.Context
+	}
+
+	kInfo, kErr := r.Client.GetKeyCtx(ctx, chunkKey, GetKeyOptions{})
 	if kErr != nil {
 		return 0, kErr
 	}
 	if !kInfo.Found() {
-		return 0, errors.New(fmt.Sprintf("%s chunk key not found", chunkKey))
+		return 0, &KeyError{Key: chunkKey, Kind: ErrChunkMissing}
 	}
 
 	r.Index += 1
@@ -179,22 +225,23 @@ func (r *ChunksReader) Read(p []byte) (n int, err error) {
 	return r.Buffer.Read(p)
 }
 
-func (cli *EtcdClient) newChunksReader(key string) (*ChunksReader, error) {
-	cKeyInfo, revision, infoErr := cli.getChunkedKeyInfo(key)
+func (cli *EtcdClient) newChunksReader(ctx context.Context, key string) (*ChunksReader, error) {
+	cKeyInfo, revision, infoErr := cli.getChunkedKeyInfo(ctx, key)
 	if infoErr != nil {
 		return nil, infoErr
 	}
 	if cKeyInfo == nil {
-		return nil, errors.New(fmt.Sprintf("%s key doesn't have chunked key info", key))
+		return nil, &KeyError{Key: key, Kind: ErrNotFound}
 	}
 
 	var buffer bytes.Buffer
 	buffer.Grow(1024 * 1024)
 	reader := ChunksReader{
-		Client: cli,
-		Key:    key,
-		Index:  0,
-		Buffer: &buffer,
+		Client:  cli,
+		Context: ctx,
+		Key:     key,
+		Index:   0,
+		Buffer:  &buffer,
 		Snapshot: ChunkedKeySnapshot{
 			Info:     *cKeyInfo,
 			Revision: revision,
@@ -204,16 +251,66 @@ func (cli *EtcdClient) newChunksReader(key string) (*ChunksReader, error) {
 	return &reader, nil
 }
 
+/*
+Writes a chunk of a chunked key.
+Chunks bypass the compression of values, as chunked values are compressed as a stream before being split.
+*/
+func (cli *EtcdClient) putChunk(ctx context.Context, key string, val []byte) (err error) {
+	ctx, op := cli.startOperation(ctx, "PutKey", key)
+	defer func() { op.end(err) }()
+
+	kv := cli.uncompressedKV
+	if kv == nil {
+		kv = cli.Client.KV
+	}
+
+	var revision int64
+	err = cli.withRetries(ctx, func() error {
+		ctx, cancel := context.WithTimeout(ctx, cli.RequestTimeout)
+		defer cancel()
+
+		resp, err := kv.Put(ctx, key, string(val))
+		if err != nil {
+			return err
+		}
+
+		revision = resp.Header.Revision
+		return nil
+	})
+	if err == nil {
+		op.addBytes(0, int64(len(key) + len(val)))
+		op.setRevision(revision)
+	}
+
+	return err
+}
+
+/*
+Returns a payload to read the value of a chunked key. The payload will be nil if the key does not exist.
+*/
 func (cli *EtcdClient) GetChunkedKey(key string) (*ChunkedKeyPayload, error) {
-	keyInfo, _, infoErr := cli.getChunkedKeyInfo(key)
+	return cli.GetChunkedKeyCtx(cli.Context, key)
+}
+
+/*
+Variant of GetChunkedKey that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) GetChunkedKeyCtx(ctx context.Context, key string) (_ *ChunkedKeyPayload, err error) {
+	//The chunks are read after the method returned, so the reads are reported as operations of their own
+	readCtx := ctx
+	ctx, op := cli.startOperation(ctx, "GetChunkedKey", key)
+	defer func() { op.end(err) }()
+
+	keyInfo, _, infoErr := cli.getChunkedKeyInfo(ctx, key)
 	if infoErr != nil || keyInfo == nil {
 		return nil, infoErr
 	}
 
-	reader, rErr := cli.newChunksReader(key)
+	reader, rErr := cli.newChunksReader(ctx, key)
 	if rErr != nil {
 		return nil, rErr
 	}
+	reader.Context = readCtx
 
 	payload := ChunkedKeyPayload{
 		Key:   key,
@@ -224,28 +321,30 @@ func (cli *EtcdClient) GetChunkedKey(key string) (*ChunkedKeyPayload, error) {
 	return &payload, nil
 }
 
-func (cli *EtcdClient) deleteChunkedKeyWithRetries(key string, retries uint64) error {
-	ctx, cancel := context.WithTimeout(cli.Context, cli.RequestTimeout)
-	defer cancel()
+func (cli *EtcdClient) DeleteChunkedKey(key string) error {
+	return cli.DeleteChunkedKeyCtx(cli.Context, key)
+}
+
+/*
+Variant of DeleteChunkedKey that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) DeleteChunkedKeyCtx(ctx context.Context, key string) (err error) {
+	ctx, op := cli.startOperation(ctx, "DeleteChunkedKey", key)
+	defer func() { op.end(err) }()
 
 	chunksPrefix := fmt.Sprintf("%s/chunks/", key)
 	infoKey := fmt.Sprintf("%s/info", key)
-	tx := cli.Client.Txn(ctx).Then(
-		clientv3.OpDelete(infoKey),
-		clientv3.OpDelete(chunksPrefix, clientv3.WithRange(clientv3.GetPrefixRangeEnd(chunksPrefix))),
-	)
-
-	_, err := tx.Commit()
-	if err != nil {
-		if shouldRetry(err, retries) {
-			time.Sleep(cli.RetryInterval)
-			return cli.deleteChunkedKeyWithRetries(key, retries-1)
-		}
-	}
 
-	return err
-}
+	return cli.withRetries(ctx, func() error {
+		ctx, cancel := context.WithTimeout(ctx, cli.RequestTimeout)
+		defer cancel()
 
-func (cli *EtcdClient) DeleteChunkedKey(key string) error {
-	return cli.deleteChunkedKeyWithRetries(key, cli.Retries)
+		tx := cli.Client.Txn(ctx).Then(
+			clientv3.OpDelete(infoKey),
+			clientv3.OpDelete(chunksPrefix, clientv3.WithRange(clientv3.GetPrefixRangeEnd(chunksPrefix))),
+		)
+
+		_, err := tx.Commit()
+		return err
+	})
 }
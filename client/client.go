@@ -2,11 +2,16 @@ package client
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"sync"
 	"time"
 
 	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	clientv3 "go.etcd.io/etcd/client/v3"
 	raftv3 "go.etcd.io/raft/v3"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -14,14 +19,38 @@ import (
 /*
 Etcd client with simple interface for retries and timeouts.
 It should be instanciated with the Connect function.
+Methods use the client's context, but most of them have a variant suffixed with Ctx that takes a context as first argument instead.
 */
 type EtcdClient struct {
-	Client         *clientv3.Client
-	Retries        uint64
-	RetryInterval  time.Duration
-	RequestTimeout time.Duration
-	Context        context.Context
-	connOpts       EtcdClientOptions
+	Client            *clientv3.Client
+	Retries           uint64
+	RetryInterval     time.Duration
+	RequestTimeout    time.Duration
+	//Maximum number of keys retrieved per request when getting or diffing ranges and prefixes. If 0, they are retrieved in a single request.
+	PageSize          int64
+	//If true, all the reads of keys are serializable: they are served by the member the request is sent to without going through the leader, which can return stale data.
+	SerializableReads bool
+	//Policy determining how failed requests are retried. If nil, the Retries and RetryInterval values are used with a fixed interval.
+	RetryPolicy       RetryPolicy
+	//Logger for the requests made by the client. If nil, the client does not log.
+	Logger            *zap.Logger
+	//Hooks called during each request the client makes to the etcd cluster. Optional.
+	Hooks             OperationHooks
+	//Collector of metrics on the operations of the client. Optional.
+	Metrics           MetricsCollector
+	//Provider of the tracer used to create spans for the operations of the client. If nil, no spans are created.
+	TracerProvider    trace.TracerProvider
+	Context           context.Context
+	connOpts          EtcdClientOptions
+	tlsConf           *tls.Config
+	//Set if values are encrypted or compressed, in which case the KV interface of the etcd client is replaced with one that transforms them
+	encryption        *valueEncryption
+	compression       *valueCompression
+	rawKV             clientv3.KV
+	//Key-value API with all the value transforms but compression, for values that are compressed beforehand
+	uncompressedKV    clientv3.KV
+	//Locks acquired by the client and not yet released, by lease, so that releasing them cancels their context
+	heldLocks         *sync.Map
 }
 
 /*
@@ -30,13 +59,20 @@ Note that the underlying client connection to the etcd cluster is reused though.
 Thus, a call to the Close method would impact both the original client and its copy.
 */
 func (cli *EtcdClient) SetContext(ctx context.Context) *EtcdClient {
-	return &EtcdClient{
-		Client: cli.Client,
-		Retries: cli.Retries,
-		RequestTimeout: cli.RequestTimeout,
-		Context: ctx,
-		connOpts: cli.connOpts,
-	}
+	copy := *cli
+	copy.Context = ctx
+	return &copy
+}
+
+/*
+Returns a copy of the EtcdClient instance with serializable reads enabled or disabled.
+Useful for read-heavy callers that can accept slightly stale data in exchange for a lower latency and load on the leader.
+Note that the underlying client connection to the etcd cluster is reused.
+*/
+func (cli *EtcdClient) SetSerializableReads(serializable bool) *EtcdClient {
+	copy := *cli
+	copy.SerializableReads = serializable
+	return &copy
 }
 
 /*
@@ -45,9 +81,23 @@ The endpoints of the new connection can be set in the argument.
 Note however that the context is reused. To change it as well, a call to the SetContext can be made.
 */
 func (cli *EtcdClient) SetEndpoints(endpoints []string) (*EtcdClient, error) {
+	return Connect(cli.Context, cli.getEndpointsOpts(endpoints))
+}
+
+/*
+Returns the options to connect a copy of the client to other endpoints.
+*/
+func (cli *EtcdClient) getEndpointsOpts(endpoints []string) EtcdClientOptions {
 	opts := cli.connOpts
 	opts.EtcdEndpoints = endpoints
-	return Connect(cli.Context, opts)
+	opts.PageSize = cli.PageSize
+	opts.SerializableReads = cli.SerializableReads
+	opts.RetryPolicy = cli.RetryPolicy
+	opts.Logger = cli.Logger
+	opts.Hooks = cli.Hooks
+	opts.Metrics = cli.Metrics
+	opts.TracerProvider = cli.TracerProvider
+	return opts
 }
 
 /*
@@ -61,8 +111,8 @@ func (cli *EtcdClient) Close() error {
 Returns whether an error returned by etcd is probably transient and the operation should be retried
 */
 func ErrorIsRetryable(err error) bool {
-	etcdErr, ok := err.(rpctypes.EtcdError)
-	if ok {
+	var etcdErr rpctypes.EtcdError
+	if errors.As(err, &etcdErr) {
 		if etcdErr.Code() != codes.Unavailable {
 			return false
 		}
@@ -79,11 +129,3 @@ func ErrorIsRetryable(err error) bool {
 
 	return true
 }
-
-func shouldRetry(err error, retries uint64) bool {
-	if retries == 0 || (!ErrorIsRetryable(err)) {
-		return false
-	}
-
-	return true
-}
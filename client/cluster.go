@@ -2,15 +2,14 @@ package client
 
 import (
 	"context"
-	"errors"
-	"fmt"
-	"time"
 
 	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type EtcdMemberStatus struct {
 	IsLeader         bool
+	IsLearner        bool
 	IsResponsive     bool
 	ResponseError    error
 	ProtocolVersion  string
@@ -38,18 +37,18 @@ type EtcdMembers struct {
 	Members []EtcdMember
 }
 
-func (cli *EtcdClient) getMembersWithRetries(retries uint64) (EtcdMembers, error) {
-	ctx, cancel := context.WithTimeout(cli.Context, cli.RequestTimeout)
-	defer cancel()
+func (cli *EtcdClient) getMembers(ctx context.Context) (EtcdMembers, error) {
+	var listResp *clientv3.MemberListResponse
+	listErr := cli.withRetries(ctx, func() error {
+		ctx, cancel := context.WithTimeout(ctx, cli.RequestTimeout)
+		defer cancel()
 
-	listResp, listErr := cli.Client.MemberList(ctx)
+		var err error
+		listResp, err = cli.Client.MemberList(ctx)
+		return err
+	})
 	if listErr != nil {
-		if !shouldRetry(listErr, retries) {
-			return EtcdMembers{}, listErr
-		}
-
-		time.Sleep(cli.RetryInterval)
-		return cli.getMembersWithRetries(retries - 1)
+		return EtcdMembers{}, listErr
 	}
 
 	members := EtcdMembers{
@@ -73,20 +72,20 @@ func (cli *EtcdClient) getMembersWithRetries(retries uint64) (EtcdMembers, error
 	return members, nil
 }
 
-func (cli *EtcdClient) getEndpointStatusWithRetries(endpoint string, retries uint64) (*clientv3.StatusResponse, error) {
-	ctx, cancel := context.WithTimeout(cli.Context, cli.RequestTimeout)
-	defer cancel()
+func (cli *EtcdClient) getEndpointStatus(ctx context.Context, endpoint string) (*clientv3.StatusResponse, error) {
+	ctx, span := getOperation(ctx).startStep(ctx, "etcd.Status", attribute.String("etcd.endpoint", endpoint))
 
-	status, statusErr := cli.Client.Status(ctx, endpoint)
-	if statusErr != nil {
-		if !shouldRetry(statusErr, retries) {
-			return nil, statusErr
-		}
+	var status *clientv3.StatusResponse
+	statusErr := cli.withRetries(ctx, func() error {
+		ctx, cancel := context.WithTimeout(ctx, cli.RequestTimeout)
+		defer cancel()
 
-		time.Sleep(cli.RetryInterval)
-		return cli.getEndpointStatusWithRetries(endpoint, retries - 1)
-	}
+		var err error
+		status, err = cli.Client.Status(ctx, endpoint)
+		return err
+	})
 
+	endSpan(span, statusErr)
 	return status, statusErr
 }
 
@@ -95,7 +94,17 @@ Get members info in the cluster.
 If statusInfo is true, additional status info will be fetched from each node in the cluster
 */
 func (cli *EtcdClient) GetMembers(statusInfo bool) (EtcdMembers, error) {
-	members, membersErr := cli.getMembersWithRetries(cli.Retries)
+	return cli.GetMembersCtx(cli.Context, statusInfo)
+}
+
+/*
+Variant of GetMembers that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) GetMembersCtx(ctx context.Context, statusInfo bool) (_ EtcdMembers, err error) {
+	ctx, op := cli.startOperation(ctx, "GetMembers", "")
+	defer func() { op.end(err) }()
+
+	members, membersErr := cli.getMembers(ctx)
 	if (!statusInfo) || (membersErr != nil) {
 		return members, membersErr
 	}
@@ -103,7 +112,7 @@ func (cli *EtcdClient) GetMembers(statusInfo bool) (EtcdMembers, error) {
 	leaderId := uint64(0)
 	raftTerm := uint64(0)
 	for idx, member := range members.Members {
-		status, statusErr := cli.getEndpointStatusWithRetries(member.ClientUrls[0], cli.Retries)
+		status, statusErr := cli.getEndpointStatus(ctx, member.ClientUrls[0])
 		if statusErr != nil {
 			member.Status = &EtcdMemberStatus{
 				IsResponsive: false,
@@ -114,18 +123,8 @@ func (cli *EtcdClient) GetMembers(statusInfo bool) (EtcdMembers, error) {
 			continue
 		}
 
-		member.Status = &EtcdMemberStatus{
-			IsLeader: false,
-			IsResponsive: true,
-			ResponseError: nil,
-			ProtocolVersion: status.Version,
-			DbSize: status.DbSize,
-			DbSizeInUse: status.DbSizeInUse,
-			RaftIndex: status.RaftIndex,
-			RaftTerm: status.RaftTerm,
-			RaftAppliedIndex: status.RaftAppliedIndex,
-		}
 		member.IsLearner = status.IsLearner
+		member.Status = newMemberStatus(status, member.IsLearner)
 		members.Members[idx] = member
 		
 		if status.RaftTerm >= raftTerm {
@@ -144,30 +143,119 @@ func (cli *EtcdClient) GetMembers(statusInfo bool) (EtcdMembers, error) {
 	return members, membersErr
 }
 
-func (cli *EtcdClient) moveLeaderWithRetries(transfereeID uint64, retries uint64) error {
-	ctx, cancel := context.WithTimeout(cli.Context, cli.RequestTimeout)
-	defer cancel()
+func newMemberStatus(status *clientv3.StatusResponse, isLearner bool) *EtcdMemberStatus {
+	return &EtcdMemberStatus{
+		IsLeader: false,
+		IsLearner: isLearner,
+		IsResponsive: true,
+		ResponseError: nil,
+		ProtocolVersion: status.Version,
+		DbSize: status.DbSize,
+		DbSizeInUse: status.DbSizeInUse,
+		RaftIndex: status.RaftIndex,
+		RaftTerm: status.RaftTerm,
+		RaftAppliedIndex: status.RaftAppliedIndex,
+	}
+}
 
-	_, err := cli.Client.MoveLeader(ctx, transfereeID)
+/*
+Returns a copy of the EtcdClient instance with a different underlying connection, whose requests are all sent to a given member of the etcd cluster.
+The member is identified by its name, as returned by GetMembers. Returns an error matching ErrMemberNotFound if there is no such member.
+Useful to make serializable reads or get the status of a specific follower or learner.
+Note that learners only serve serializable reads and status calls, so serializable reads are enabled on the returned client if the member is a learner.
+The returned client has its own connection, which should be closed with its Close method. Its endpoints are neither discovered nor synced with the cluster.
+*/
+func (cli *EtcdClient) SetMember(name string) (*EtcdClient, error) {
+	return cli.SetMemberCtx(cli.Context, name)
+}
+
+/*
+Variant of SetMember that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) SetMemberCtx(ctx context.Context, name string) (_ *EtcdClient, err error) {
+	ctx, op := cli.startOperation(ctx, "SetMember", "")
+	defer func() { op.end(err) }()
+
+	members, err := cli.getMembers(ctx)
 	if err != nil {
-		if !shouldRetry(err, retries) {
-			return err
+		return nil, err
+	}
+
+	for _, member := range members.Members {
+		if member.Name == name && len(member.ClientUrls) > 0 {
+			//Discovery and auto sync would replace the member's endpoints with those of the whole cluster
+			opts := cli.getEndpointsOpts(member.ClientUrls)
+			opts.DiscoverySrv = ""
+			opts.AutoSyncInterval = 0
+			opts.OnEndpointsChange = nil
+			opts.SerializableReads = opts.SerializableReads || member.IsLearner
+			return Connect(cli.Context, opts)
 		}
+	}
 
-		time.Sleep(cli.RetryInterval)
-		return cli.moveLeaderWithRetries(transfereeID, retries - 1)
+	return nil, &MemberError{Member: name, Kind: ErrMemberNotFound}
+}
+
+/*
+Returns the status of the member the client's requests are sent to.
+If the client has several endpoints, the status of the first one is returned. It is mostly useful with clients returned by SetMember.
+*/
+func (cli *EtcdClient) GetStatus() (EtcdMemberStatus, error) {
+	return cli.GetStatusCtx(cli.Context)
+}
+
+/*
+Variant of GetStatus that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) GetStatusCtx(ctx context.Context) (_ EtcdMemberStatus, err error) {
+	ctx, op := cli.startOperation(ctx, "GetStatus", "")
+	defer func() { op.end(err) }()
+
+	endpoints := cli.Client.Endpoints()
+	if len(endpoints) == 0 {
+		return EtcdMemberStatus{}, ErrMemberNotFound
+	}
+
+	status, err := cli.getEndpointStatus(ctx, endpoints[0])
+	if err != nil {
+		return EtcdMemberStatus{}, err
 	}
 
-	return nil
+	//Learners do not serve member lists, so the learner status is taken from the status of the member itself
+	memberStatus := newMemberStatus(status, status.IsLearner)
+	memberStatus.IsLeader = status.Leader == status.Header.MemberId
+	return *memberStatus, nil
+}
+
+func (cli *EtcdClient) moveLeader(ctx context.Context, transfereeID uint64) error {
+	return cli.withRetries(ctx, func() error {
+		ctx, cancel := context.WithTimeout(ctx, cli.RequestTimeout)
+		defer cancel()
+
+		_, err := cli.Client.MoveLeader(ctx, transfereeID)
+		return err
+	})
 }
 
 /*
 Sets the leader status on the node with the given name.
 If isLeader is true, the node will be elected leader if it isn't.
 If isLeader is false, the leadership will be transfered to another node.
+Returns a MemberError matching ErrMemberIsLearner or ErrMemberNotResponsive if the node cannot have its status changed,
+ErrNoLeader if no leader can be reached and ErrNoTransferCandidate if no node can take over the leadership.
 */
 func (cli *EtcdClient) SetLeaderStatus(name string, isLeader bool) error {
-	members, membersErr := cli.GetMembers(true)
+	return cli.SetLeaderStatusCtx(cli.Context, name, isLeader)
+}
+
+/*
+Variant of SetLeaderStatus that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) SetLeaderStatusCtx(ctx context.Context, name string, isLeader bool) (err error) {
+	ctx, op := cli.startOperation(ctx, "SetLeaderStatus", "")
+	defer func() { op.end(err) }()
+
+	members, membersErr := cli.GetMembersCtx(ctx, true)
 	if membersErr != nil {
 		return membersErr
 	}
@@ -178,11 +266,11 @@ func (cli *EtcdClient) SetLeaderStatus(name string, isLeader bool) error {
 	for _, member := range members.Members {
 		if member.Name == name {
 			if member.IsLearner {
-				return errors.New(fmt.Sprintf("Cannot change leader status of node %s as it is a learner", name))
+				return &MemberError{Member: name, Kind: ErrMemberIsLearner}
 			}
 
 			if !member.Status.IsResponsive {
-				return errors.New(fmt.Sprintf("Cannot change leader status of node %s as it is not responsive", name))
+				return &MemberError{Member: name, Kind: ErrMemberNotResponsive, Cause: member.Status.ResponseError}
 			}
 
 			if (isLeader && member.Status.IsLeader) || ((!isLeader) && (!member.Status.IsLeader)) {
@@ -206,11 +294,11 @@ func (cli *EtcdClient) SetLeaderStatus(name string, isLeader bool) error {
 	}
 
 	if !leaderFound {
-		return errors.New("Cannot change leader status as it requires a pre-existing leader and no such node can be reached")
+		return ErrNoLeader
 	}
 
 	if len(eligibleIds) == 0 {
-		return errors.New("Cannot change leader status as no transfer candidate exists")
+		return ErrNoTransferCandidate
 	}
 
 	newCli, newCliErr := cli.SetEndpoints([]string{leaderEndpoint})
@@ -219,14 +307,25 @@ func (cli *EtcdClient) SetLeaderStatus(name string, isLeader bool) error {
 	}
 	defer newCli.Close()
 
-	return newCli.moveLeaderWithRetries(eligibleIds[0], cli.Retries)
+	return newCli.moveLeader(ctx, eligibleIds[0])
 }
 
 /*
 Forces a change of leader in the etcd cluster.
+Returns ErrNoLeader if no leader can be reached and ErrNoTransferCandidate if no node can take over the leadership.
 */
 func (cli *EtcdClient) ChangeLeader() error {
-	members, membersErr := cli.GetMembers(true)
+	return cli.ChangeLeaderCtx(cli.Context)
+}
+
+/*
+Variant of ChangeLeader that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) ChangeLeaderCtx(ctx context.Context) (err error) {
+	ctx, op := cli.startOperation(ctx, "ChangeLeader", "")
+	defer func() { op.end(err) }()
+
+	members, membersErr := cli.GetMembersCtx(ctx, true)
 	if membersErr != nil {
 		return membersErr
 	}
@@ -246,11 +345,11 @@ func (cli *EtcdClient) ChangeLeader() error {
 	}
 
 	if !leaderFound {
-		return errors.New("Cannot change leader status as it requires a pre-existing leader and no such node can be reached")
+		return ErrNoLeader
 	}
 
 	if len(eligibleIds) == 0 {
-		return errors.New("Cannot change leader status as no transfer candidate exists")
+		return ErrNoTransferCandidate
 	}
 
 	newCli, newCliErr := cli.SetEndpoints([]string{leaderEndpoint})
@@ -259,5 +358,5 @@ func (cli *EtcdClient) ChangeLeader() error {
 	}
 	defer newCli.Close()
 
-	return newCli.moveLeaderWithRetries(eligibleIds[0], cli.Retries)
+	return newCli.moveLeader(ctx, eligibleIds[0])
 }
\ No newline at end of file
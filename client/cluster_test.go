@@ -1,10 +1,14 @@
 package client
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/Ferlab-Ste-Justine/etcd-sdk/testutils"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
 )
 
 func TestGetMembers(t *testing.T) {
@@ -246,4 +250,118 @@ func TestChangeLeader(t *testing.T) {
 	for i:=0; i < 20; i++ {
 		testChangeLeader()
 	}
-}
\ No newline at end of file
+}
+/*
+Cluster API that rejects member lists like a learner does.
+*/
+type learnerCluster struct {
+	clientv3.Cluster
+}
+
+func (c learnerCluster) MemberList(ctx context.Context) (*clientv3.MemberListResponse, error) {
+	return nil, rpctypes.ErrGPRCNotSupportedForLearner
+}
+
+func TestSetMember(t *testing.T) {
+	tearDown, launchErr := testutils.LaunchTestEtcdCluster("../test", testutils.EtcdTestClusterOpts{})
+	if launchErr != nil {
+		t.Errorf("Error occured launching test etcd cluster: %s", launchErr.Error())
+		return
+	}
+
+	defer func() {
+		errs := tearDown()
+		if len(errs) > 0 {
+			t.Errorf("Errors occured tearing down etcd cluster: %s", errs[0].Error())
+		}
+	}()
+
+	retryInterval, _ := time.ParseDuration("1s")
+	timeouts, _ := time.ParseDuration("10s")
+	retries := uint64(10)
+	cli := setupTestEnv(t, timeouts, retryInterval, retries)
+
+	members, membersErr := cli.GetMembers(true)
+	if membersErr != nil {
+		t.Errorf("Getting members failed: %s", membersErr.Error())
+		return
+	}
+
+	rev, _ := cli.PutKey("member", "value")
+
+	for _, member := range members.Members {
+		memberCli, err := cli.SetMember(member.Name)
+		if err != nil {
+			t.Errorf("Error occured getting client for member %s: %s", member.Name, err.Error())
+			continue
+		}
+
+		if len(memberCli.Client.Endpoints()) != 1 || memberCli.Client.Endpoints()[0] != member.ClientUrls[0] {
+			t.Errorf("Expected client for member %s to only have its endpoint and got: %v", member.Name, memberCli.Client.Endpoints())
+		}
+
+		if memberCli.SerializableReads != member.IsLearner {
+			t.Errorf("Expected client for member %s to only have serializable reads enabled if it is a learner and it didn't", member.Name)
+		}
+
+		//Learners reject member lists, which the status of a member should therefore not need
+		memberCli.Client.Cluster = learnerCluster{memberCli.Client.Cluster}
+		status, err := memberCli.GetStatus()
+		if err != nil {
+			t.Errorf("Error occured getting status of member %s: %s", member.Name, err.Error())
+		} else if status.IsLeader != member.Status.IsLeader || status.IsLearner != member.IsLearner {
+			t.Errorf("Expected status of member %s to have the same leadership and learner status as in the members list and it didn't", member.Name)
+		}
+
+		//Serializable reads can be stale, so the member is given time to catch up
+		found := false
+		for i := 0; i < 10 && (!found); i++ {
+			info, err := memberCli.GetKey("member", GetKeyOptions{Serializable: true})
+			if err != nil {
+				t.Errorf("Error occured making serializable read on member %s: %s", member.Name, err.Error())
+				break
+			}
+			found = info.ModRevision == rev
+			if !found {
+				time.Sleep(100 * time.Millisecond)
+			}
+		}
+		if !found {
+			t.Errorf("Expected serializable read on member %s to eventually return the key and it didn't", member.Name)
+		}
+
+		prefix, err := memberCli.SetSerializableReads(true).GetPrefix("mem")
+		if err != nil || len(prefix.Keys) != 1 {
+			t.Errorf("Expected serializable prefix read on member %s to return the key", member.Name)
+		}
+
+		memberCli.Close()
+	}
+
+	_, err := cli.SetMember("unknown")
+	if !errors.Is(err, ErrMemberNotFound) {
+		t.Errorf("Expected getting a client for an unknown member to return a member not found error and got: %v", err)
+	}
+
+	opts := cli.connOpts
+	opts.AutoSyncInterval = 100 * time.Millisecond
+	syncCli, err := Connect(context.Background(), opts)
+	if err != nil {
+		t.Errorf("Error occured connecting client with auto sync: %s", err.Error())
+		return
+	}
+	defer syncCli.Close()
+
+	member := members.Members[0]
+	memberCli, err := syncCli.SetMember(member.Name)
+	if err != nil {
+		t.Errorf("Error occured getting client for member %s with auto sync: %s", member.Name, err.Error())
+		return
+	}
+	defer memberCli.Close()
+
+	time.Sleep(500 * time.Millisecond)
+	if len(memberCli.Client.Endpoints()) != 1 || memberCli.Client.Endpoints()[0] != member.ClientUrls[0] {
+		t.Errorf("Expected client for member %s to keep only its endpoint when auto sync is enabled and got: %v", member.Name, memberCli.Client.Endpoints())
+	}
+}
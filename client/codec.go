@@ -0,0 +1,85 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"google.golang.org/protobuf/proto"
+	yaml "gopkg.in/yaml.v3"
+)
+
+/*
+Encoding of the values stored in etcd, as used by the TypedStore structure.
+*/
+type Codec interface {
+	//Encodes a value to the bytes stored in etcd
+	Marshal(val any) ([]byte, error)
+	//Decodes bytes stored in etcd into the value pointed to by the dest argument
+	Unmarshal(data []byte, dest any) error
+}
+
+/*
+Codec that encodes values in json.
+*/
+type JsonCodec struct{}
+
+func (c JsonCodec) Marshal(val any) ([]byte, error) {
+	return json.Marshal(val)
+}
+
+func (c JsonCodec) Unmarshal(data []byte, dest any) error {
+	return json.Unmarshal(data, dest)
+}
+
+/*
+Codec that encodes values in yaml.
+*/
+type YamlCodec struct{}
+
+func (c YamlCodec) Marshal(val any) ([]byte, error) {
+	return yaml.Marshal(val)
+}
+
+func (c YamlCodec) Unmarshal(data []byte, dest any) error {
+	return yaml.Unmarshal(data, dest)
+}
+
+/*
+Codec that encodes values in the protobuf binary format.
+Values must be protobuf messages. When decoding, the dest argument can either be a message or a pointer to a message pointer, in which case a new message is allocated.
+*/
+type ProtoCodec struct{}
+
+func (c ProtoCodec) Marshal(val any) ([]byte, error) {
+	msg, ok := val.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("Value of type %T is not a protobuf message", val)
+	}
+
+	return proto.Marshal(msg)
+}
+
+func (c ProtoCodec) Unmarshal(data []byte, dest any) error {
+	if msg, ok := dest.(proto.Message); ok {
+		return proto.Unmarshal(data, msg)
+	}
+
+	ptr := reflect.ValueOf(dest)
+	if ptr.Kind() != reflect.Pointer || ptr.IsNil() || ptr.Elem().Kind() != reflect.Pointer {
+		return fmt.Errorf("Destination of type %T is not a protobuf message", dest)
+	}
+
+	msg, ok := reflect.New(ptr.Elem().Type().Elem()).Interface().(proto.Message)
+	if !ok {
+		return fmt.Errorf("Destination of type %T is not a protobuf message", dest)
+	}
+
+	err := proto.Unmarshal(data, msg)
+	if err != nil {
+		return err
+	}
+
+	ptr.Elem().Set(reflect.ValueOf(msg))
+	return nil
+}
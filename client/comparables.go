@@ -2,6 +2,13 @@ package client
 
 import clientv3 "go.etcd.io/etcd/client/v3"
 
+/*
+Returns a comparison on the presence of a key.
+Note that its result is inverted relative to its name: it is true if the key does not exist when presence is true, and if the key exists when presence is false.
+The behavior is kept for compatibility with existing callers.
+
+Deprecated: Use KeyExists or KeyMissing instead.
+*/
 func KeyIsPresent(key string, presence bool) clientv3.Cmp {
 	if presence {
 		return clientv3.Compare(clientv3.Version(key), "=", 0)
@@ -10,10 +17,45 @@ func KeyIsPresent(key string, presence bool) clientv3.Cmp {
 	return clientv3.Compare(clientv3.Version(key), ">", 0)
 }
 
+/*
+Returns a comparison that is true if the key exists.
+*/
+func KeyExists(key string) clientv3.Cmp {
+	return clientv3.Compare(clientv3.Version(key), ">", 0)
+}
+
+/*
+Returns a comparison that is true if the key does not exist.
+*/
+func KeyMissing(key string) clientv3.Cmp {
+	return clientv3.Compare(clientv3.Version(key), "=", 0)
+}
+
 func KeyValueIsCmp(key string, cmp string, value string) clientv3.Cmp {
 	return clientv3.Compare(clientv3.Value(key), cmp, value)
 }
 
 func KeyVersionIsCmp(key string, cmp string, value int64) clientv3.Cmp {
 	return clientv3.Compare(clientv3.Version(key), cmp, value)
-}
\ No newline at end of file
+}
+
+/*
+Returns a comparison on the revision of the etcd store when the key was last modified. It is 0 for keys that do not exist.
+*/
+func KeyModRevisionIsCmp(key string, cmp string, value int64) clientv3.Cmp {
+	return clientv3.Compare(clientv3.ModRevision(key), cmp, value)
+}
+
+/*
+Returns a comparison on the revision of the etcd store when the key was created. It is 0 for keys that do not exist.
+*/
+func KeyCreateRevisionIsCmp(key string, cmp string, value int64) clientv3.Cmp {
+	return clientv3.Compare(clientv3.CreateRevision(key), cmp, value)
+}
+
+/*
+Returns a comparison on the id of the lease the key is attached to. It is 0 for keys without a lease.
+*/
+func KeyLeaseIsCmp(key string, cmp string, value clientv3.LeaseID) clientv3.Cmp {
+	return clientv3.Compare(clientv3.LeaseValue(key), cmp, int64(value))
+}
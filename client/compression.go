@@ -0,0 +1,220 @@
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+type CompressionAlgorithm string
+
+const (
+	CompressionGzip CompressionAlgorithm = "gzip"
+	CompressionZstd CompressionAlgorithm = "zstd"
+)
+
+const DefaultCompressionThreshold = 1024
+
+/*
+Options to compress values on the client side before they are stored in etcd.
+Compressed values are prefixed with a header identifying the algorithm, so values written with any algorithm or without compression can be read.
+Note that only clients with compression enabled decompress values, except for chunked keys whose compression is recorded in their info.
+*/
+type CompressionOptions struct {
+	//Algorithm new values are compressed with
+	Algorithm CompressionAlgorithm
+	//Size in bytes from which values are compressed. Defaults to DefaultCompressionThreshold if zero.
+	Threshold int
+}
+
+/*
+Header of the values compressed by the client, followed by the id of the algorithm.
+*/
+var compressionHeader = []byte{0x00, 'C', 'Z', 0x01}
+
+var compressionAlgorithmIds = map[CompressionAlgorithm]byte{
+	CompressionGzip: 1,
+	CompressionZstd: 2,
+}
+
+/*
+Compresses the values that are above a size threshold and decompresses the values that have a compression header.
+*/
+type valueCompression struct {
+	algorithm CompressionAlgorithm
+	threshold int
+	zstdEnc   *zstd.Encoder
+	zstdDec   *zstd.Decoder
+}
+
+func newValueCompression(opts *CompressionOptions) (*valueCompression, error) {
+	if _, ok := compressionAlgorithmIds[opts.Algorithm]; !ok {
+		return nil, fmt.Errorf("%w: Unsupported compression algorithm %s", ErrInvalidOptions, opts.Algorithm)
+	}
+
+	if opts.Threshold < 0 {
+		return nil, fmt.Errorf("%w: The compression threshold cannot be negative", ErrInvalidOptions)
+	}
+
+	comp := &valueCompression{
+		algorithm: opts.Algorithm,
+		threshold: opts.Threshold,
+	}
+	if comp.threshold == 0 {
+		comp.threshold = DefaultCompressionThreshold
+	}
+
+	var err error
+	comp.zstdEnc, err = zstd.NewWriter(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	comp.zstdDec, err = zstd.NewReader(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return comp, nil
+}
+
+func compressionAlgorithmFromId(id byte) (CompressionAlgorithm, bool) {
+	for algorithm, algorithmId := range compressionAlgorithmIds {
+		if algorithmId == id {
+			return algorithm, true
+		}
+	}
+
+	return "", false
+}
+
+/*
+Returns a writer compressing what is written to it with the given algorithm into the underlying writer.
+*/
+func newCompressWriter(algorithm CompressionAlgorithm, w io.Writer) (io.WriteCloser, error) {
+	switch algorithm {
+	case CompressionGzip:
+		return gzip.NewWriter(w), nil
+	case CompressionZstd:
+		return zstd.NewWriter(w)
+	}
+
+	return nil, fmt.Errorf("%w: Unsupported compression algorithm %s", ErrInvalidOptions, algorithm)
+}
+
+/*
+Returns a reader decompressing the content of the underlying reader with the given algorithm.
+*/
+func newDecompressReader(algorithm CompressionAlgorithm, r io.Reader) (io.ReadCloser, error) {
+	switch algorithm {
+	case CompressionGzip:
+		return gzip.NewReader(r)
+	case CompressionZstd:
+		dec, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return dec.IOReadCloser(), nil
+	}
+
+	return nil, fmt.Errorf("%w: Unsupported compression algorithm %s", ErrDecompressionFailed, algorithm)
+}
+
+/*
+Compresses a value if it is above the threshold. The value is kept as is if compression does not make it smaller.
+*/
+func (comp *valueCompression) encode(key []byte, val []byte) ([]byte, error) {
+	if len(val) < comp.threshold {
+		return val, nil
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(val) / 2))
+	out.Write(compressionHeader)
+	out.WriteByte(compressionAlgorithmIds[comp.algorithm])
+
+	if comp.algorithm == CompressionZstd {
+		out.Write(comp.zstdEnc.EncodeAll(val, nil))
+	} else {
+		w, err := newCompressWriter(comp.algorithm, out)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = w.Write(val)
+		if err != nil {
+			return nil, err
+		}
+
+		err = w.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if out.Len() >= len(val) {
+		return val, nil
+	}
+
+	return out.Bytes(), nil
+}
+
+/*
+Decompresses a value if it has a compression header and returns it as is otherwise.
+*/
+func (comp *valueCompression) decode(key []byte, val []byte) ([]byte, error) {
+	if !bytes.HasPrefix(val, compressionHeader) || len(val) < len(compressionHeader) + 1 {
+		return val, nil
+	}
+
+	algorithm, ok := compressionAlgorithmFromId(val[len(compressionHeader)])
+	if !ok {
+		return nil, &KeyError{Key: string(key), Kind: ErrDecompressionFailed, Cause: fmt.Errorf("Unknown compression algorithm id %d", val[len(compressionHeader)])}
+	}
+
+	compressed := val[len(compressionHeader) + 1:]
+	if algorithm == CompressionZstd {
+		decompressed, err := comp.zstdDec.DecodeAll(compressed, nil)
+		if err != nil {
+			return nil, &KeyError{Key: string(key), Kind: ErrDecompressionFailed, Cause: err}
+		}
+
+		return decompressed, nil
+	}
+
+	r, err := newDecompressReader(algorithm, bytes.NewReader(compressed))
+	if err != nil {
+		return nil, &KeyError{Key: string(key), Kind: ErrDecompressionFailed, Cause: err}
+	}
+	defer r.Close()
+
+	decompressed, err := io.ReadAll(r)
+	if err != nil {
+		return nil, &KeyError{Key: string(key), Kind: ErrDecompressionFailed, Cause: err}
+	}
+
+	return decompressed, nil
+}
+
+/*
+Returns a reader of the compressed content of the source reader.
+It must be closed to stop the compression if it is not read to the end.
+*/
+func (comp *valueCompression) compressStream(src io.Reader) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		w, err := newCompressWriter(comp.algorithm, pw)
+		if err == nil {
+			_, err = io.Copy(w, src)
+			closeErr := w.Close()
+			if err == nil {
+				err = closeErr
+			}
+		}
+		pw.CloseWithError(err)
+	}()
+
+	return pr
+}
@@ -0,0 +1,193 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Ferlab-Ste-Justine/etcd-sdk/testutils"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+/*
+KV that rejects the writes of chunks, which should bypass it.
+*/
+type chunkRejectingKV struct {
+	clientv3.KV
+}
+
+func (kv chunkRejectingKV) Put(ctx context.Context, key string, val string, opts ...clientv3.OpOption) (*clientv3.PutResponse, error) {
+	if strings.Contains(key, "/chunks/") {
+		return nil, errors.New("Chunk written with the compression of values")
+	}
+
+	return kv.KV.Put(ctx, key, val, opts...)
+}
+
+func TestValueCompression(t *testing.T) {
+	value := []byte(strings.Repeat(`{"name": "test", "count": 1}`, 100))
+	for _, algorithm := range []CompressionAlgorithm{CompressionGzip, CompressionZstd} {
+		comp, err := newValueCompression(&CompressionOptions{Algorithm: algorithm})
+		if err != nil {
+			t.Errorf("Error occured creating %s compression: %s", algorithm, err.Error())
+			continue
+		}
+
+		compressed, _ := comp.encode([]byte("key"), value)
+		if !bytes.HasPrefix(compressed, compressionHeader) || len(compressed) >= len(value) {
+			t.Errorf("Expected value to be compressed with %s and got %d bytes out of %d", algorithm, len(compressed), len(value))
+		}
+
+		decompressed, err := comp.decode([]byte("key"), compressed)
+		if err != nil || !bytes.Equal(decompressed, value) {
+			t.Errorf("Expected value to be decompressed identically with %s and got: %v", algorithm, err)
+		}
+
+		small, _ := comp.encode([]byte("key"), []byte("small"))
+		if string(small) != "small" {
+			t.Errorf("Expected value below the threshold not to be compressed with %s and got: %v", algorithm, small)
+		}
+
+		plain, _ := comp.decode([]byte("key"), value)
+		if !bytes.Equal(plain, value) {
+			t.Errorf("Expected value without a compression header to be returned as is with %s and it wasn't", algorithm)
+		}
+	}
+
+	gzipComp, _ := newValueCompression(&CompressionOptions{Algorithm: CompressionGzip})
+	zstdComp, _ := newValueCompression(&CompressionOptions{Algorithm: CompressionZstd})
+	compressed, _ := zstdComp.encode([]byte("key"), value)
+	decompressed, err := gzipComp.decode([]byte("key"), compressed)
+	if err != nil || !bytes.Equal(decompressed, value) {
+		t.Errorf("Expected value compressed with zstd to be decompressed by a client compressing with gzip and got: %v", err)
+	}
+
+	_, err = gzipComp.decode([]byte("key"), append(append([]byte{}, compressionHeader...), 1, 'x'))
+	if !errors.Is(err, ErrDecompressionFailed) {
+		t.Errorf("Expected corrupted compressed value to return a decompression error and got: %v", err)
+	}
+
+	_, err = newValueCompression(&CompressionOptions{Algorithm: "lz4"})
+	if !errors.Is(err, ErrInvalidOptions) {
+		t.Errorf("Expected an unsupported compression algorithm to be rejected and got: %v", err)
+	}
+}
+
+func TestCompressedClient(t *testing.T) {
+	tearDown, launchErr := testutils.LaunchTestEtcdCluster("../test", testutils.EtcdTestClusterOpts{})
+	if launchErr != nil {
+		t.Errorf("Error occured launching test etcd cluster: %s", launchErr.Error())
+		return
+	}
+
+	defer func() {
+		errs := tearDown()
+		if len(errs) > 0 {
+			t.Errorf("Errors occured tearing down etcd cluster: %s", errs[0].Error())
+		}
+	}()
+
+	retryInterval, _ := time.ParseDuration("1s")
+	timeouts, _ := time.ParseDuration("10s")
+	retries := uint64(10)
+	rawCli := setupTestEnv(t, timeouts, retryInterval, retries)
+
+	opts := rawCli.connOpts
+	opts.Compression = &CompressionOptions{Algorithm: CompressionZstd}
+	opts.Encryption = &EncryptionOptions{
+		Keys:      map[string][]byte{"k1": bytes.Repeat([]byte("a"), 32)},
+		ActiveKey: "k1",
+		Prefixes:  []string{"/secret/"},
+	}
+	cli, err := Connect(context.Background(), opts)
+	if err != nil {
+		t.Errorf("Error occured connecting with compression: %s", err.Error())
+		return
+	}
+	defer cli.Close()
+
+	value := strings.Repeat(`{"name": "test", "count": 1}`, 1000)
+	rev, err := cli.PutKey("/blobs/a", value)
+	if err != nil {
+		t.Errorf("Error occured putting compressed key: %s", err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watch := cli.WatchCtx(ctx, "/blobs/", WatchOptions{Revision: rev, IsPrefix: true, TrimPrefix: true})
+	cli.PutKey("/blobs/small", "small")
+
+	raw, _ := rawCli.GetKey("/blobs/a", GetKeyOptions{})
+	if !strings.HasPrefix(raw.Value, string(compressionHeader)) || len(raw.Value) >= len(value) {
+		t.Errorf("Expected the value to be stored compressed and got %d bytes out of %d", len(raw.Value), len(value))
+	}
+
+	raw, _ = rawCli.GetKey("/blobs/small", GetKeyOptions{})
+	if raw.Value != "small" {
+		t.Errorf("Expected the value below the threshold to be stored as is and got: %v", []byte(raw.Value))
+	}
+
+	info, err := cli.GetKey("/blobs/a", GetKeyOptions{})
+	if err != nil || info.Value != value {
+		t.Errorf("Expected compressed key to be read back identically and it wasn't")
+	}
+
+	prefix, err := cli.GetPrefix("/blobs/")
+	if err != nil || prefix.Keys.ToValueMap("/blobs/")["a"] != value || prefix.Keys.ToValueMap("/blobs/")["small"] != "small" {
+		t.Errorf("Expected prefix to be read decompressed and it wasn't")
+	}
+
+	res := <-watch
+	if res.Error != nil || res.Changes.Upserts["a"].Value != value {
+		t.Errorf("Expected watch to report decompressed values and got: %v", res.Error)
+	}
+
+	cli.PutKey("/secret/a", value)
+	raw, _ = rawCli.GetKey("/secret/a", GetKeyOptions{})
+	if _, ok := encryptionKeyId([]byte(raw.Value)); !ok || len(raw.Value) >= len(value) {
+		t.Errorf("Expected the value to be compressed and then encrypted and got %d bytes out of %d", len(raw.Value), len(value))
+	}
+
+	info, _ = cli.GetKey("/secret/a", GetKeyOptions{})
+	if info.Value != value {
+		t.Errorf("Expected the compressed and encrypted value to be read back identically and it wasn't")
+	}
+
+	//Chunks are compressed as a stream beforehand, so they should not go through the compression of values
+	chunked := []byte(strings.Repeat(value, 100))
+	kv := cli.Client.KV
+	cli.Client.KV = chunkRejectingKV{kv}
+	err = cli.PutChunkedKey(&ChunkedKeyPayload{Key: "/chunked", Value: io.NopCloser(bytes.NewReader(chunked)), Size: int64(len(chunked))})
+	cli.Client.KV = kv
+	if err != nil {
+		t.Errorf("Error occured putting compressed chunked key: %s", err.Error())
+		return
+	}
+
+	chunkedInfo, _, _ := rawCli.getChunkedKeyInfo(context.Background(), "/chunked")
+	if chunkedInfo == nil || chunkedInfo.Count != 1 || chunkedInfo.Size != int64(len(chunked)) || chunkedInfo.Compression != CompressionZstd {
+		t.Errorf("Expected chunk count to reflect the compressed size and got: %v", chunkedInfo)
+	}
+
+	//Chunked keys record their compression, so clients without compression can read them
+	payload, err := rawCli.GetChunkedKey("/chunked")
+	if err != nil || payload == nil {
+		t.Errorf("Error occured getting compressed chunked key: %v", err)
+		return
+	}
+	read, _ := io.ReadAll(payload)
+	payload.Close()
+	if !bytes.Equal(read, chunked) {
+		t.Errorf("Expected compressed chunked key to be read back identically and got %d bytes out of %d", len(read), len(chunked))
+	}
+
+	err = cli.PutChunkedKey(&ChunkedKeyPayload{Key: "/chunked", Value: io.NopCloser(bytes.NewReader(chunked[:10])), Size: int64(len(chunked))})
+	if err == nil {
+		t.Errorf("Expected putting a chunked key with a payload shorter than its size to fail and it didn't")
+	}
+}
@@ -0,0 +1,301 @@
+package client
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+const (
+	DefaultConnectionTimeout = 5 * time.Second
+	DefaultRequestTimeout    = 5 * time.Second
+	DefaultRetryInterval     = 500 * time.Millisecond
+	DefaultRetries           = uint64(10)
+)
+
+/*
+Serializable subset of the client options, as loaded from configuration files and environment variables.
+It can be embedded in the configuration of dependent projects.
+*/
+type EtcdClientConfig struct {
+	//Endpoints of the etcd cluster. Each entry should be of the format 'address:port'
+	Endpoints            []string      `yaml:"endpoints"`
+	//Path to the CA certificate used to sign etcd's server certificates
+	CaCert               string        `yaml:"ca_cert"`
+	//Path to the client certificate file
+	ClientCert           string        `yaml:"client_cert"`
+	//Path to the client private key file
+	ClientKey            string        `yaml:"client_key"`
+	//Path to a file containing the client cert and the client key concatenated
+	ClientCertKey        string        `yaml:"client_cert_key"`
+	Username             string        `yaml:"username"`
+	Password             string        `yaml:"password"`
+	ConnectionTimeout    time.Duration `yaml:"connection_timeout"`
+	RequestTimeout       time.Duration `yaml:"request_timeout"`
+	RetryInterval        time.Duration `yaml:"retry_interval"`
+	Retries              uint64        `yaml:"retries"`
+	PageSize             int64         `yaml:"page_size"`
+	SkipTls              bool          `yaml:"skip_tls"`
+	ReloadCerts          bool          `yaml:"reload_certs"`
+	//Domain whose SRV records are used to discover the endpoints if none are specified
+	DiscoverySrv         string        `yaml:"discovery_srv"`
+	DiscoverySrvName     string        `yaml:"discovery_srv_name"`
+	AutoSyncInterval     time.Duration `yaml:"auto_sync_interval"`
+	//Algorithm values are compressed with, either "gzip" or "zstd". Values are not compressed if empty.
+	Compression          string        `yaml:"compression"`
+	CompressionThreshold int           `yaml:"compression_threshold"`
+}
+
+/*
+Returns a configuration with the default timeouts and retries.
+Files and environment variables are loaded on top of it, so that the values they do not specify keep their defaults.
+*/
+func DefaultEtcdClientConfig() EtcdClientConfig {
+	return EtcdClientConfig{
+		ConnectionTimeout: DefaultConnectionTimeout,
+		RequestTimeout:    DefaultRequestTimeout,
+		RetryInterval:     DefaultRetryInterval,
+		Retries:           DefaultRetries,
+	}
+}
+
+/*
+Converts the configuration to client options that can be passed to the Connect function.
+*/
+func (config *EtcdClientConfig) ToOptions() EtcdClientOptions {
+	var compression *CompressionOptions
+	if config.Compression != "" {
+		compression = &CompressionOptions{
+			Algorithm: CompressionAlgorithm(config.Compression),
+			Threshold: config.CompressionThreshold,
+		}
+	}
+
+	return EtcdClientOptions{
+		ClientCertPath:    config.ClientCert,
+		ClientKeyPath:     config.ClientKey,
+		ClientCertKeyPath: config.ClientCertKey,
+		CaCertPath:        config.CaCert,
+		Username:          config.Username,
+		Password:          config.Password,
+		EtcdEndpoints:     config.Endpoints,
+		ConnectionTimeout: config.ConnectionTimeout,
+		RequestTimeout:    config.RequestTimeout,
+		RetryInterval:     config.RetryInterval,
+		Retries:           config.Retries,
+		PageSize:          config.PageSize,
+		SkipTLS:           config.SkipTls,
+		ReloadCerts:       config.ReloadCerts,
+		DiscoverySrv:      config.DiscoverySrv,
+		DiscoverySrvName:  config.DiscoverySrvName,
+		AutoSyncInterval:  config.AutoSyncInterval,
+		Compression:       compression,
+	}
+}
+
+/*
+Overrides the configuration with the etcdctl environment variables that are set.
+The supported variables are:
+  - ETCDCTL_ENDPOINTS: Comma-separated list of endpoints
+  - ETCDCTL_CACERT, ETCDCTL_CERT and ETCDCTL_KEY: Paths to the CA certificate, client certificate and client key
+  - ETCDCTL_USER: Username, optionally followed by a colon and the password
+  - ETCDCTL_PASSWORD: Password
+  - ETCDCTL_DIAL_TIMEOUT and ETCDCTL_COMMAND_TIMEOUT: Connection and request timeouts, in the duration format of Golang (ie: "5s")
+  - ETCDCTL_INSECURE_TRANSPORT: Whether to connect in plaintext without tls
+  - ETCDCTL_DISCOVERY_SRV and ETCDCTL_DISCOVERY_SRV_NAME: Domain and service name of the SRV records used to discover the endpoints
+Additionally, the retries can be configured with ETCD_SDK_RETRIES and ETCD_SDK_RETRY_INTERVAL and the endpoints sync with ETCD_SDK_AUTO_SYNC_INTERVAL, which etcdctl does not have.
+*/
+func (config *EtcdClientConfig) LoadEnv() error {
+	if endpoints, ok := os.LookupEnv("ETCDCTL_ENDPOINTS"); ok {
+		config.Endpoints = []string{}
+		for _, endpoint := range strings.Split(endpoints, ",") {
+			endpoint = strings.TrimSpace(endpoint)
+			if endpoint != "" {
+				config.Endpoints = append(config.Endpoints, endpoint)
+			}
+		}
+	}
+
+	for envVar, field := range map[string]*string{
+		"ETCDCTL_CACERT":             &config.CaCert,
+		"ETCDCTL_CERT":               &config.ClientCert,
+		"ETCDCTL_KEY":                &config.ClientKey,
+		"ETCDCTL_PASSWORD":           &config.Password,
+		"ETCDCTL_DISCOVERY_SRV":      &config.DiscoverySrv,
+		"ETCDCTL_DISCOVERY_SRV_NAME": &config.DiscoverySrvName,
+	} {
+		if val, ok := os.LookupEnv(envVar); ok {
+			*field = val
+		}
+	}
+
+	if user, ok := os.LookupEnv("ETCDCTL_USER"); ok {
+		config.Username = user
+		if _, passwordSet := os.LookupEnv("ETCDCTL_PASSWORD"); !passwordSet {
+			if username, password, found := strings.Cut(user, ":"); found {
+				config.Username = username
+				config.Password = password
+			}
+		}
+	}
+
+	for envVar, field := range map[string]*time.Duration{
+		"ETCDCTL_DIAL_TIMEOUT":        &config.ConnectionTimeout,
+		"ETCDCTL_COMMAND_TIMEOUT":     &config.RequestTimeout,
+		"ETCD_SDK_RETRY_INTERVAL":     &config.RetryInterval,
+		"ETCD_SDK_AUTO_SYNC_INTERVAL": &config.AutoSyncInterval,
+	} {
+		if val, ok := os.LookupEnv(envVar); ok {
+			duration, err := time.ParseDuration(val)
+			if err != nil {
+				return fmt.Errorf("%w: Failed to parse %s: %s", ErrInvalidOptions, envVar, err.Error())
+			}
+			*field = duration
+		}
+	}
+
+	if val, ok := os.LookupEnv("ETCD_SDK_RETRIES"); ok {
+		retries, err := strconv.ParseUint(val, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: Failed to parse ETCD_SDK_RETRIES: %s", ErrInvalidOptions, err.Error())
+		}
+		config.Retries = retries
+	}
+
+	if val, ok := os.LookupEnv("ETCDCTL_INSECURE_TRANSPORT"); ok {
+		skipTls, err := strconv.ParseBool(val)
+		if err != nil {
+			return fmt.Errorf("%w: Failed to parse ETCDCTL_INSECURE_TRANSPORT: %s", ErrInvalidOptions, err.Error())
+		}
+		config.SkipTls = skipTls
+	}
+
+	return nil
+}
+
+/*
+Overrides the configuration with the values specified in a yaml or json file.
+The keys of the file are the ones in the yaml tags of the EtcdClientConfig structure and timeouts are in the duration format of Golang (ie: "5s").
+*/
+func (config *EtcdClientConfig) LoadFile(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("Failed to read client configuration file: %w", err)
+	}
+
+	err = yaml.Unmarshal(content, config)
+	if err != nil {
+		return fmt.Errorf("%w: Failed to parse client configuration file: %s", ErrInvalidOptions, err.Error())
+	}
+
+	return nil
+}
+
+/*
+Loads client options from the etcdctl environment variables, on top of the default timeouts and retries.
+The options are validated and can be passed to the Connect function directly.
+*/
+func LoadOptionsFromEnv() (EtcdClientOptions, error) {
+	return LoadOptions("")
+}
+
+/*
+Loads client options from a yaml or json configuration file, on top of the default timeouts and retries.
+The options are validated and can be passed to the Connect function directly.
+*/
+func LoadOptionsFromFile(path string) (EtcdClientOptions, error) {
+	config := DefaultEtcdClientConfig()
+	err := config.LoadFile(path)
+	if err != nil {
+		return EtcdClientOptions{}, err
+	}
+
+	opts := config.ToOptions()
+	return opts, opts.Validate()
+}
+
+/*
+Loads client options from a yaml or json configuration file, overriden by the etcdctl environment variables that are set.
+The path can be empty to only use the environment variables. Values that are specified in neither have their defaults.
+The options are validated and can be passed to the Connect function directly.
+*/
+func LoadOptions(path string) (EtcdClientOptions, error) {
+	config := DefaultEtcdClientConfig()
+	if path != "" {
+		err := config.LoadFile(path)
+		if err != nil {
+			return EtcdClientOptions{}, err
+		}
+	}
+
+	err := config.LoadEnv()
+	if err != nil {
+		return EtcdClientOptions{}, err
+	}
+
+	opts := config.ToOptions()
+	return opts, opts.Validate()
+}
+
+/*
+Validates that the options are coherent and complete enough to connect to the etcd cluster.
+Returns an error matching ErrInvalidOptions if they are not.
+*/
+func (opts *EtcdClientOptions) Validate() error {
+	if len(opts.EtcdEndpoints) == 0 && opts.DiscoverySrv == "" {
+		return fmt.Errorf("%w: No etcd endpoints or discovery domain were specified", ErrInvalidOptions)
+	}
+
+	if opts.ConnectionTimeout <= 0 || opts.RequestTimeout <= 0 {
+		return fmt.Errorf("%w: The connection and request timeouts must be greater than zero", ErrInvalidOptions)
+	}
+
+	if opts.RetryInterval < 0 {
+		return fmt.Errorf("%w: The retry interval cannot be negative", ErrInvalidOptions)
+	}
+
+	hasClientCert := opts.ClientCertPath != "" || opts.ClientKeyPath != "" || opts.ClientCertKeyPath != "" || len(opts.ClientCert) > 0 || len(opts.ClientKey) > 0 || opts.ClientCertProvider != nil
+	hasCaCert := opts.CaCertPath != "" || len(opts.CaCert) > 0
+
+	if opts.SkipTLS {
+		if hasClientCert || hasCaCert || opts.TlsConfig != nil {
+			return fmt.Errorf("%w: Tls was disabled, but certificates were specified", ErrInvalidOptions)
+		}
+
+		return nil
+	}
+
+	if opts.Username != "" && hasClientCert {
+		return fmt.Errorf("%w: Both password and certificate authentication were specified", ErrInvalidOptions)
+	}
+
+	if opts.TlsConfig != nil {
+		return nil
+	}
+
+	if !hasCaCert {
+		return fmt.Errorf("%w: Tls is enabled, but no CA certificate was specified", ErrInvalidOptions)
+	}
+
+	if opts.Username != "" || opts.ClientCertProvider != nil {
+		return nil
+	}
+
+	if len(opts.ClientCert) > 0 || len(opts.ClientKey) > 0 {
+		if len(opts.ClientCert) == 0 || len(opts.ClientKey) == 0 {
+			return fmt.Errorf("%w: Both the client certificate and the client key must be specified", ErrInvalidOptions)
+		}
+
+		return nil
+	}
+
+	if opts.ClientCertKeyPath == "" && (opts.ClientCertPath == "" || opts.ClientKeyPath == "") {
+		return fmt.Errorf("%w: Either a username or both the client certificate and the client key must be specified", ErrInvalidOptions)
+	}
+
+	return nil
+}
@@ -0,0 +1,144 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/Ferlab-Ste-Justine/etcd-sdk/testutils"
+)
+
+func TestLoadOptions(t *testing.T) {
+	configPath := path.Join(t.TempDir(), "config.yml")
+	os.WriteFile(configPath, []byte(`
+endpoints:
+  - 127.0.0.1:3379
+ca_cert: ca.crt
+client_cert: client.crt
+client_key: client.key
+request_timeout: 10s
+retries: 0
+compression: gzip
+`), 0600)
+
+	opts, err := LoadOptionsFromFile(configPath)
+	if err != nil {
+		t.Errorf("Error occured loading options from file: %s", err.Error())
+		return
+	}
+	if opts.CaCertPath != "ca.crt" || opts.RequestTimeout != 10*time.Second || opts.Retries != 0 {
+		t.Errorf("Expected the values of the file to be loaded and got: %v", opts)
+	}
+	if opts.ConnectionTimeout != DefaultConnectionTimeout || opts.RetryInterval != DefaultRetryInterval {
+		t.Errorf("Expected values missing from the file to have their defaults and got: %v", opts)
+	}
+	if opts.Compression == nil || opts.Compression.Algorithm != CompressionGzip {
+		t.Errorf("Expected compression to be enabled by the file and got: %v", opts.Compression)
+	}
+
+	jsonPath := path.Join(t.TempDir(), "config.json")
+	os.WriteFile(jsonPath, []byte(`{"endpoints": ["127.0.0.1:3379"], "skip_tls": true, "connection_timeout": "1s"}`), 0600)
+	opts, err = LoadOptionsFromFile(jsonPath)
+	if err != nil || (!opts.SkipTLS) || opts.ConnectionTimeout != time.Second {
+		t.Errorf("Expected options to be loaded from a json file and got: %v, %v", opts, err)
+	}
+
+	t.Setenv("ETCDCTL_ENDPOINTS", "127.0.0.2:3379, 127.0.0.3:3379")
+	t.Setenv("ETCDCTL_USER", "user:password")
+	t.Setenv("ETCDCTL_CERT", "")
+	t.Setenv("ETCDCTL_KEY", "")
+	t.Setenv("ETCDCTL_DIAL_TIMEOUT", "2s")
+	t.Setenv("ETCD_SDK_RETRIES", "3")
+
+	opts, err = LoadOptions(configPath)
+	if err != nil {
+		t.Errorf("Error occured loading options from file and environment: %s", err.Error())
+		return
+	}
+	if len(opts.EtcdEndpoints) != 2 || opts.EtcdEndpoints[1] != "127.0.0.3:3379" {
+		t.Errorf("Expected endpoints to be overriden by the environment and got: %v", opts.EtcdEndpoints)
+	}
+	if opts.Username != "user" || opts.Password != "password" || opts.ClientCertPath != "" {
+		t.Errorf("Expected user and password to be taken from ETCDCTL_USER and got: %s, %s", opts.Username, opts.Password)
+	}
+	if opts.ConnectionTimeout != 2*time.Second || opts.RequestTimeout != 10*time.Second || opts.Retries != 3 {
+		t.Errorf("Expected environment variables to override the file and the file the defaults and got: %v", opts)
+	}
+
+	t.Setenv("ETCDCTL_COMMAND_TIMEOUT", "ten seconds")
+	_, err = LoadOptionsFromEnv()
+	if !errors.Is(err, ErrInvalidOptions) {
+		t.Errorf("Expected an invalid duration in the environment to return an invalid options error and got: %v", err)
+	}
+}
+
+func TestValidateOptions(t *testing.T) {
+	valid := EtcdClientOptions{
+		ClientCertPath:    "client.crt",
+		ClientKeyPath:     "client.key",
+		CaCertPath:        "ca.crt",
+		EtcdEndpoints:     []string{"127.0.0.1:3379"},
+		ConnectionTimeout: time.Second,
+		RequestTimeout:    time.Second,
+	}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Expected options to be valid and got: %s", err.Error())
+	}
+
+	invalid := map[string]func(opts *EtcdClientOptions){
+		"no endpoints":          func(opts *EtcdClientOptions) { opts.EtcdEndpoints = nil },
+		"no request timeout":    func(opts *EtcdClientOptions) { opts.RequestTimeout = 0 },
+		"tls skipped with certs": func(opts *EtcdClientOptions) { opts.SkipTLS = true },
+		"no ca certificate":     func(opts *EtcdClientOptions) { opts.CaCertPath = "" },
+		"no client key":         func(opts *EtcdClientOptions) { opts.ClientKeyPath = "" },
+		"user and certificates": func(opts *EtcdClientOptions) { opts.Username = "user" },
+	}
+	for name, change := range invalid {
+		opts := valid
+		change(&opts)
+		if err := opts.Validate(); !errors.Is(err, ErrInvalidOptions) {
+			t.Errorf("Expected options with %s to be invalid and got: %v", name, err)
+		}
+	}
+}
+
+func TestConnectWithLoadedOptions(t *testing.T) {
+	tearDown, launchErr := testutils.LaunchTestEtcdCluster("../test", testutils.EtcdTestClusterOpts{})
+	if launchErr != nil {
+		t.Errorf("Error occured launching test etcd cluster: %s", launchErr.Error())
+		return
+	}
+
+	defer func() {
+		errs := tearDown()
+		if len(errs) > 0 {
+			t.Errorf("Errors occured tearing down etcd cluster: %s", errs[0].Error())
+		}
+	}()
+
+	t.Setenv("ETCDCTL_ENDPOINTS", "127.0.0.1:3379,127.0.0.2:3379,127.0.0.3:3379")
+	t.Setenv("ETCDCTL_CACERT", "../test/certs/ca.crt")
+	t.Setenv("ETCDCTL_CERT", "../test/certs/root.pem")
+	t.Setenv("ETCDCTL_KEY", "../test/certs/root.key")
+
+	opts, err := LoadOptionsFromEnv()
+	if err != nil {
+		t.Errorf("Error occured loading options from environment: %s", err.Error())
+		return
+	}
+
+	cli, err := Connect(context.Background(), opts)
+	if err != nil {
+		t.Errorf("Error occured connecting with loaded options: %s", err.Error())
+		return
+	}
+	defer cli.Close()
+
+	_, err = cli.PutKey("test", "test")
+	if err != nil {
+		t.Errorf("Error occured putting key with loaded options: %s", err.Error())
+	}
+}
@@ -4,15 +4,17 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
-	"encoding/pem"
 	"errors"
 	"fmt"
-	"io/ioutil"
+	"net"
 	"os"
+	"sync"
 	"time"
 
 	"google.golang.org/grpc/connectivity"
 	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
 )
 
 /*
@@ -41,40 +43,76 @@ type EtcdClientOptions struct {
 	RetryInterval     time.Duration
 	//Number of retries to attempt before returning an error when requests to the etcd cluster fail
 	Retries           uint64
+	//Policy determining how failed requests are retried. If set, it takes precedence over RetryInterval and Retries.
+	RetryPolicy       RetryPolicy
+	//Maximum number of keys retrieved per request when getting or diffing ranges and prefixes. If 0, they are retrieved in a single request.
+	PageSize          int64
+	//If set to true, all the reads of keys are serializable, which lowers their latency and the load on the leader, but can return stale data
+	SerializableReads bool
 	//If set to true, connection to the etcd cluster will be attempted in plaintext without encryption
 	SkipTLS           bool
+	//If tls is enabled and certificate authentication is used, alternate argument to provide the PEM-encoded client certificate directly instead of a path
+	ClientCert         []byte
+	//If tls is enabled and certificate authentication is used, alternate argument to provide the PEM-encoded client private key directly instead of a path
+	ClientKey          []byte
+	//If tls is enabled, alternate argument to provide the PEM-encoded CA certificate directly instead of a path
+	CaCert             []byte
+	//If tls is enabled and certificate authentication is used, function called on each new tls handshake to get the client certificate.
+	//Useful when certificates are managed by a secret manager. Takes precedence over the other client certificate arguments.
+	ClientCertProvider func() (*tls.Certificate, error)
+	//If tls is enabled, complete tls configuration to use. Takes precedence over all the other tls arguments.
+	TlsConfig          *tls.Config
+	//If set to true, certificate files passed as paths will be read again on new tls handshakes if they were modified.
+	//Useful to pick up rotated certificates without reconnecting.
+	ReloadCerts        bool
+	//Logger for the client and its underlying etcd connection. If nil, the client does not log and the etcd connection uses its default logger.
+	Logger             *zap.Logger
+	//Hooks called during each request the client makes to the etcd cluster. Optional.
+	Hooks              OperationHooks
+	//Collector of metrics on the operations of the client. Optional.
+	Metrics            MetricsCollector
+	//Provider of the tracer used to create spans for the operations of the client. Optional.
+	TracerProvider     trace.TracerProvider
+	//If EtcdEndpoints is empty, domain whose SRV records are used to discover the endpoints of the etcd cluster
+	DiscoverySrv       string
+	//Optional service name suffix of the SRV records used for discovery, for clusters sharing a domain
+	DiscoverySrvName   string
+	//Resolver used to lookup the SRV records for discovery. If nil, the default resolver is used.
+	DiscoveryResolver  *net.Resolver
+	//If greater than zero, interval at which the etcd client updates the endpoints of the connection with the members of the etcd cluster
+	AutoSyncInterval   time.Duration
+	//Function called with the new endpoints when they are changed by an endpoints sync. Optional.
+	OnEndpointsChange  func(endpoints []string)
+	//If set, values of the keys under the configured prefixes are encrypted on the client side. Optional.
+	Encryption         *EncryptionOptions
+	//If set, values above a size threshold are compressed on the client side, before they are encrypted. Optional.
+	Compression        *CompressionOptions
 }
 
 func getTlsConfigs(opts EtcdClientOptions) (*tls.Config, error) {
+	if opts.TlsConfig != nil {
+		return opts.TlsConfig.Clone(), nil
+	}
+
 	tlsConf := &tls.Config{}
 
 	//User credentials
 	if opts.Username == "" {
-		if opts.ClientCertKeyPath != "" {
-			fData, fileErr := os.ReadFile(opts.ClientCertKeyPath)
-			if fileErr != nil {
-				return nil, errors.New(fmt.Sprintf("Failed to load file containing client cert and key: %s", fileErr.Error()))
-			}
-
-			certBlock, rest := pem.Decode(fData)
-			if certBlock == nil {
-				return nil, errors.New("Failed to read certificate from file containing client cert and key")
+		if opts.ClientCertProvider != nil {
+			(*tlsConf).GetClientCertificate = func(_ *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+				return opts.ClientCertProvider()
 			}
-
-			keyBlock, _ := pem.Decode(rest)
-			if keyBlock == nil {
-				return nil, errors.New("Failed to read key from file containing client cert and key")
-			}
-
-			certData, err := tls.X509KeyPair(pem.EncodeToMemory(certBlock), pem.EncodeToMemory(keyBlock))
+		} else if opts.ReloadCerts && len(opts.ClientCert) == 0 {
+			loader := newClientCertLoader(opts.ClientCertPath, opts.ClientKeyPath, opts.ClientCertKeyPath)
+			_, err := loader.GetClientCertificate(nil)
 			if err != nil {
-				return nil, errors.New(fmt.Sprintf("Failed to load user credentials: %s", err.Error()))
+				return nil, err
 			}
-			(*tlsConf).Certificates = []tls.Certificate{certData}
+			(*tlsConf).GetClientCertificate = loader.GetClientCertificate
 		} else {
-			certData, err := tls.LoadX509KeyPair(opts.ClientCertPath, opts.ClientKeyPath)
+			certData, err := loadClientCertificate(opts)
 			if err != nil {
-				return nil, errors.New(fmt.Sprintf("Failed to load user credentials: %s", err.Error()))
+				return nil, err
 			}
 			(*tlsConf).Certificates = []tls.Certificate{certData}
 		}
@@ -83,9 +121,26 @@ func getTlsConfigs(opts EtcdClientOptions) (*tls.Config, error) {
 	(*tlsConf).InsecureSkipVerify = false
 
 	//CA cert
-	caCertContent, err := ioutil.ReadFile(opts.CaCertPath)
-	if err != nil {
-		return nil, errors.New(fmt.Sprintf("Failed to read root certificate file: %s", err.Error()))
+	if opts.ReloadCerts && len(opts.CaCert) == 0 {
+		loader := newCaCertLoader(opts.CaCertPath)
+		_, err := loader.GetRoots()
+		if err != nil {
+			return nil, err
+		}
+		//Standard verification is done against a static pool which would not pick up a rotated CA.
+		//The verification is instead done in VerifyConnection against the latest CA certificate.
+		(*tlsConf).InsecureSkipVerify = true
+		(*tlsConf).VerifyConnection = loader.VerifyConnection
+		return tlsConf, nil
+	}
+
+	caCertContent := opts.CaCert
+	if len(caCertContent) == 0 {
+		var err error
+		caCertContent, err = os.ReadFile(opts.CaCertPath)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("Failed to read root certificate file: %s", err.Error()))
+		}
 	}
 	roots := x509.NewCertPool()
 	ok := roots.AppendCertsFromPEM(caCertContent)
@@ -99,8 +154,19 @@ func getTlsConfigs(opts EtcdClientOptions) (*tls.Config, error) {
 
 /*
 Entrypoint function to try connect to the etcd cluster and return a client.
+If no endpoints are specified in the options, they are discovered with the SRV records of the DiscoverySrv domain.
+If AutoSyncInterval is set, the endpoints are periodically synced with the members of the cluster until the client is closed.
+Returns ErrConnectionTimeout if the connection could not be established before the connection timeout.
 */
 func Connect(ctx context.Context, opts EtcdClientOptions) (*EtcdClient, error) {
+	if len(opts.EtcdEndpoints) == 0 && opts.DiscoverySrv != "" {
+		endpoints, discoveryErr := discoverEndpoints(ctx, opts)
+		if discoveryErr != nil {
+			return nil, discoveryErr
+		}
+		opts.EtcdEndpoints = endpoints
+	}
+
 	var tlsConf *tls.Config
 	var tlsConfErr error
 
@@ -116,24 +182,28 @@ func Connect(ctx context.Context, opts EtcdClientOptions) (*EtcdClient, error) {
 
 	if opts.Username == "" {
 		cli, connErr = clientv3.New(clientv3.Config{
-			Context:     ctx,
-			Endpoints:   opts.EtcdEndpoints,
-			TLS:         tlsConf,
-			DialTimeout: opts.ConnectionTimeout,
+			Context:          ctx,
+			Endpoints:        opts.EtcdEndpoints,
+			TLS:              tlsConf,
+			DialTimeout:      opts.ConnectionTimeout,
+			Logger:           opts.Logger,
+			AutoSyncInterval: opts.AutoSyncInterval,
 		})
 	} else {
 		cli, connErr = clientv3.New(clientv3.Config{
-			Context:     ctx,
-			Username:    opts.Username,
-			Password:    opts.Password,
-			Endpoints:   opts.EtcdEndpoints,
-			TLS:         tlsConf,
-			DialTimeout: opts.ConnectionTimeout,
+			Context:          ctx,
+			Username:         opts.Username,
+			Password:         opts.Password,
+			Endpoints:        opts.EtcdEndpoints,
+			TLS:              tlsConf,
+			DialTimeout:      opts.ConnectionTimeout,
+			Logger:           opts.Logger,
+			AutoSyncInterval: opts.AutoSyncInterval,
 		})
 	}
 
 	if connErr != nil {
-		return nil, errors.New(fmt.Sprintf("Failed to connect to etcd servers: %s", connErr.Error()))
+		return nil, fmt.Errorf("Failed to connect to etcd servers: %w", connErr)
 	}
 
 	connDeadline := time.NewTimer(opts.ConnectionTimeout)
@@ -143,18 +213,57 @@ func Connect(ctx context.Context, opts EtcdClientOptions) (*EtcdClient, error) {
 		select {
 		case <-connDeadline.C:
 			cli.Close()
-			return nil, errors.New("Failed to establish connection to etcd servers in time")
+			return nil, ErrConnectionTimeout
 		case <-time.After(10 * time.Nanosecond):
 		}
 		state = cli.ActiveConnection().GetState()
 	}
 
-	return &EtcdClient{
-		Client:         cli,
-		Retries:        opts.Retries,
-		RetryInterval:  opts.RetryInterval,
-		RequestTimeout: opts.RequestTimeout,
-		Context:        ctx,
-		connOpts:       opts,
-	}, nil
+	etcdCli := &EtcdClient{
+		Client:            cli,
+		Retries:           opts.Retries,
+		RetryInterval:     opts.RetryInterval,
+		RequestTimeout:    opts.RequestTimeout,
+		PageSize:          opts.PageSize,
+		SerializableReads: opts.SerializableReads,
+		RetryPolicy:       opts.RetryPolicy,
+		Logger:            opts.Logger,
+		Hooks:             opts.Hooks,
+		Metrics:           opts.Metrics,
+		TracerProvider:    opts.TracerProvider,
+		Context:           ctx,
+		connOpts:          opts,
+		tlsConf:           tlsConf,
+		rawKV:             cli.KV,
+		heldLocks:         &sync.Map{},
+	}
+
+	if opts.Encryption != nil {
+		enc, encErr := newValueEncryption(opts.Encryption)
+		if encErr != nil {
+			cli.Close()
+			return nil, encErr
+		}
+
+		etcdCli.encryption = enc
+		cli.KV = &transformedKV{KV: cli.KV, transform: enc}
+	}
+
+	etcdCli.uncompressedKV = cli.KV
+	if opts.Compression != nil {
+		comp, compErr := newValueCompression(opts.Compression)
+		if compErr != nil {
+			cli.Close()
+			return nil, compErr
+		}
+
+		etcdCli.compression = comp
+		cli.KV = &transformedKV{KV: cli.KV, transform: comp}
+	}
+
+	if opts.AutoSyncInterval > 0 && opts.OnEndpointsChange != nil {
+		go etcdCli.notifyEndpointsChanges(opts.AutoSyncInterval)
+	}
+
+	return etcdCli, nil
 }
\ No newline at end of file
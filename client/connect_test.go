@@ -1,7 +1,11 @@
 package client
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
+	"os"
+	"path"
 	"testing"
 	"time"
 
@@ -52,4 +56,148 @@ func TestConnect(t *testing.T) {
 	if connErr == nil {
 		t.Errorf("Connection test failed. Connection with wrong parameters should not have been successful. Connection status is: %v", cli.Client.ActiveConnection().GetState())
 	}
-}
\ No newline at end of file
+}
+func TestConnectWithTlsAlternatives(t *testing.T) {
+	tearDown, launchErr := testutils.LaunchTestEtcdCluster("../test", testutils.EtcdTestClusterOpts{})
+	if launchErr != nil {
+		t.Errorf("Error occured launching test etcd cluster: %s", launchErr.Error())
+		return
+	}
+
+	defer func() {
+		errs := tearDown()
+		if len(errs) > 0 {
+			t.Errorf("Errors occured tearing down etcd cluster: %s", errs[0].Error())
+		}
+	}()
+
+	certPem, _ := os.ReadFile("../test/certs/root.pem")
+	keyPem, _ := os.ReadFile("../test/certs/root.key")
+	caPem, _ := os.ReadFile("../test/certs/ca.crt")
+
+	duration, _ := time.ParseDuration("5s")
+	cli, err := Connect(context.Background(), EtcdClientOptions{
+		ClientCert:        certPem,
+		ClientKey:         keyPem,
+		CaCert:            caPem,
+		EtcdEndpoints:     []string{"127.0.0.1:3379", "127.0.0.2:3379", "127.0.0.3:3379"},
+		ConnectionTimeout: duration,
+		RequestTimeout:    duration,
+		RetryInterval:     duration,
+		Retries:           5,
+	})
+	if err != nil {
+		t.Errorf("Connection test failed. Connection with in-memory credentials should have been successful: %s", err.Error())
+	} else {
+		_, putErr := cli.PutKey("test", "test")
+		if putErr != nil {
+			t.Errorf("Connection test failed. Request with in-memory credentials should have been successful: %s", putErr.Error())
+		}
+		cli.Close()
+	}
+
+	cli, err = Connect(context.Background(), EtcdClientOptions{
+		ClientCertPath:    "../test/certs/root.pem",
+		ClientKeyPath:     "../test/certs/root.key",
+		CaCertPath:        "../test/certs/ca.crt",
+		ReloadCerts:       true,
+		EtcdEndpoints:     []string{"127.0.0.1:3379", "127.0.0.2:3379", "127.0.0.3:3379"},
+		ConnectionTimeout: duration,
+		RequestTimeout:    duration,
+		RetryInterval:     duration,
+		Retries:           5,
+	})
+	if err != nil {
+		t.Errorf("Connection test failed. Connection with reloadable credentials should have been successful: %s", err.Error())
+	} else {
+		_, putErr := cli.PutKey("test", "test")
+		if putErr != nil {
+			t.Errorf("Connection test failed. Request with reloadable credentials should have been successful: %s", putErr.Error())
+		}
+		cli.Close()
+	}
+
+	cli, err = Connect(context.Background(), EtcdClientOptions{
+		ClientCertProvider: func() (*tls.Certificate, error) {
+			cert, err := tls.X509KeyPair(certPem, keyPem)
+			return &cert, err
+		},
+		CaCert:            caPem,
+		EtcdEndpoints:     []string{"127.0.0.1:3379", "127.0.0.2:3379", "127.0.0.3:3379"},
+		ConnectionTimeout: duration,
+		RequestTimeout:    duration,
+		RetryInterval:     duration,
+		Retries:           5,
+	})
+	if err != nil {
+		t.Errorf("Connection test failed. Connection with a certificate provider should have been successful: %s", err.Error())
+	} else {
+		_, putErr := cli.PutKey("test", "test")
+		if putErr != nil {
+			t.Errorf("Connection test failed. Request with a certificate provider should have been successful: %s", putErr.Error())
+		}
+		cli.Close()
+	}
+}
+
+func TestClientCertLoaderReload(t *testing.T) {
+	dir := t.TempDir()
+	certPath := path.Join(dir, "client.pem")
+	keyPath := path.Join(dir, "client.key")
+
+	copyFile := func(src string, dst string, modTime time.Time) {
+		content, err := os.ReadFile(src)
+		if err != nil {
+			t.Errorf("Error occured reading %s: %s", src, err.Error())
+			return
+		}
+		err = os.WriteFile(dst, content, 0600)
+		if err != nil {
+			t.Errorf("Error occured writing %s: %s", dst, err.Error())
+			return
+		}
+		os.Chtimes(dst, modTime, modTime)
+	}
+
+	initialTime := time.Now().Add(-1 * time.Hour)
+	copyFile("../test/certs/root.pem", certPath, initialTime)
+	copyFile("../test/certs/root.key", keyPath, initialTime)
+
+	loader := newClientCertLoader(certPath, keyPath, "")
+	first, err := loader.GetClientCertificate(nil)
+	if err != nil {
+		t.Errorf("Error occured loading the initial certificate: %s", err.Error())
+		return
+	}
+
+	second, err := loader.GetClientCertificate(nil)
+	if err != nil {
+		t.Errorf("Error occured loading the certificate a second time: %s", err.Error())
+		return
+	}
+	if first != second {
+		t.Errorf("Expected the certificate not to be reloaded when the files did not change and it was")
+	}
+
+	copyFile("../test/certs/server.crt", certPath, time.Now())
+	copyFile("../test/certs/server.key", keyPath, time.Now())
+
+	rotated, err := loader.GetClientCertificate(nil)
+	if err != nil {
+		t.Errorf("Error occured loading the rotated certificate: %s", err.Error())
+		return
+	}
+	if bytes.Equal(rotated.Certificate[0], first.Certificate[0]) {
+		t.Errorf("Expected the certificate to be reloaded after the files were rotated and it wasn't")
+	}
+
+	os.Remove(certPath)
+	fallback, err := loader.GetClientCertificate(nil)
+	if err != nil {
+		t.Errorf("Expected the last valid certificate to be returned when files are missing and got error: %s", err.Error())
+		return
+	}
+	if fallback != rotated {
+		t.Errorf("Expected the last valid certificate to be returned when files are missing and it wasn't")
+	}
+}
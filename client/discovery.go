@@ -0,0 +1,141 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+/*
+Discovers the endpoints of the etcd cluster from the SRV records of a domain, the same way etcd does.
+The _etcd-client-ssl._tcp records are looked up if tls is enabled and the _etcd-client._tcp records otherwise.
+If a service name is specified, it is appended to the service, as in _etcd-client-ssl-<name>._tcp.
+*/
+func discoverEndpoints(ctx context.Context, opts EtcdClientOptions) ([]string, error) {
+	service := "etcd-client-ssl"
+	if opts.SkipTLS {
+		service = "etcd-client"
+	}
+	if opts.DiscoverySrvName != "" {
+		service = fmt.Sprintf("%s-%s", service, opts.DiscoverySrvName)
+	}
+
+	resolver := opts.DiscoveryResolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	_, records, err := resolver.LookupSRV(ctx, service, "tcp", opts.DiscoverySrv)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to discover etcd endpoints from the SRV records of %s: %w", opts.DiscoverySrv, err)
+	}
+
+	endpoints := []string{}
+	for _, record := range records {
+		host := strings.TrimSuffix(record.Target, ".")
+		endpoints = append(endpoints, net.JoinHostPort(host, strconv.FormatUint(uint64(record.Port), 10)))
+	}
+
+	return endpoints, nil
+}
+
+/*
+Converts client urls of etcd members to the 'address:port' format of the client's endpoints.
+*/
+func urlToEndpoint(url string) string {
+	url = strings.TrimPrefix(url, "https://")
+	return strings.TrimPrefix(url, "http://")
+}
+
+/*
+Converts endpoints to the 'address:port' format and sorts them, so that they can be compared.
+*/
+func normalizeEndpoints(endpoints []string) []string {
+	normalized := []string{}
+	for _, endpoint := range endpoints {
+		normalized = append(normalized, urlToEndpoint(endpoint))
+	}
+	slices.Sort(normalized)
+
+	return normalized
+}
+
+/*
+Updates the endpoints of the client's connection with the client urls of the current members of the etcd cluster, excluding learners.
+The update is done without reconnecting and if the endpoints changed, the OnEndpointsChange callback of the client's options is called.
+Returns the updated endpoints.
+*/
+func (cli *EtcdClient) SyncEndpoints() ([]string, error) {
+	return cli.SyncEndpointsCtx(cli.Context)
+}
+
+/*
+Variant of SyncEndpoints that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) SyncEndpointsCtx(ctx context.Context) (_ []string, err error) {
+	ctx, op := cli.startOperation(ctx, "SyncEndpoints", "")
+	defer func() { op.end(err) }()
+
+	members, err := cli.getMembers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints := []string{}
+	for _, member := range members.Members {
+		if member.IsLearner || len(member.ClientUrls) == 0 {
+			continue
+		}
+		endpoints = append(endpoints, urlToEndpoint(member.ClientUrls[0]))
+	}
+	slices.Sort(endpoints)
+
+	if len(endpoints) == 0 {
+		return cli.Client.Endpoints(), nil
+	}
+
+	if slices.Equal(normalizeEndpoints(cli.Client.Endpoints()), endpoints) {
+		return endpoints, nil
+	}
+
+	cli.Client.SetEndpoints(endpoints...)
+	cli.getLogger().Info("Etcd endpoints changed", zap.Strings("endpoints", endpoints))
+	if cli.connOpts.OnEndpointsChange != nil {
+		cli.connOpts.OnEndpointsChange(endpoints)
+	}
+
+	return endpoints, nil
+}
+
+/*
+Calls the OnEndpointsChange callback of the client's options when the auto sync of the etcd client changes the endpoints of the connection, until it is closed.
+The etcd client does not report the changes it makes, so the endpoints are compared at the auto sync interval.
+*/
+func (cli *EtcdClient) notifyEndpointsChanges(interval time.Duration) {
+	done := cli.Client.Ctx().Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	previous := normalizeEndpoints(cli.Client.Endpoints())
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			endpoints := normalizeEndpoints(cli.Client.Endpoints())
+			if slices.Equal(endpoints, previous) {
+				continue
+			}
+
+			previous = endpoints
+			cli.getLogger().Info("Etcd endpoints changed", zap.Strings("endpoints", endpoints))
+			cli.connOpts.OnEndpointsChange(endpoints)
+		}
+	}
+}
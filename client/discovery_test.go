@@ -0,0 +1,175 @@
+package client
+
+import (
+	"context"
+	"net"
+	"slices"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/Ferlab-Ste-Justine/etcd-sdk/testutils"
+)
+
+/*
+Launches a dns server answering SRV queries with the given records, keyed by fully qualified name.
+Returns a resolver that queries it.
+*/
+func launchDnsStub(t *testing.T, records map[string][]dnsmessage.SRVResource) *net.Resolver {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error occured launching dns stub: %s", err.Error())
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, readErr := conn.ReadFrom(buf)
+			if readErr != nil {
+				return
+			}
+
+			var parser dnsmessage.Parser
+			header, parseErr := parser.Start(buf[:n])
+			if parseErr != nil {
+				continue
+			}
+			question, questionErr := parser.Question()
+			if questionErr != nil {
+				continue
+			}
+
+			builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: header.ID, Response: true, Authoritative: true})
+			builder.StartQuestions()
+			builder.Question(question)
+			builder.StartAnswers()
+			if question.Type == dnsmessage.TypeSRV {
+				for _, record := range records[question.Name.String()] {
+					builder.SRVResource(dnsmessage.ResourceHeader{Name: question.Name, Class: dnsmessage.ClassINET, TTL: 60}, record)
+				}
+			}
+			msg, _ := builder.Finish()
+			conn.WriteTo(msg, addr)
+		}
+	}()
+
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network string, address string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "udp", conn.LocalAddr().String())
+		},
+	}
+}
+
+func TestDiscoverEndpoints(t *testing.T) {
+	resolver := launchDnsStub(t, map[string][]dnsmessage.SRVResource{
+		"_etcd-client-ssl._tcp.example.com.": []dnsmessage.SRVResource{
+			{Target: dnsmessage.MustNewName("etcd0.example.com."), Port: 2379},
+			{Target: dnsmessage.MustNewName("etcd1.example.com."), Port: 2379},
+		},
+		"_etcd-client-main._tcp.example.com.": []dnsmessage.SRVResource{
+			{Target: dnsmessage.MustNewName("etcd2.example.com."), Port: 2380},
+		},
+	})
+
+	endpoints, err := discoverEndpoints(context.Background(), EtcdClientOptions{DiscoverySrv: "example.com", DiscoveryResolver: resolver})
+	if err != nil {
+		t.Errorf("Error occured discovering endpoints: %s", err.Error())
+		return
+	}
+	slices.Sort(endpoints)
+	if !slices.Equal(endpoints, []string{"etcd0.example.com:2379", "etcd1.example.com:2379"}) {
+		t.Errorf("Expected tls endpoints to be discovered and got: %v", endpoints)
+	}
+
+	endpoints, err = discoverEndpoints(context.Background(), EtcdClientOptions{DiscoverySrv: "example.com", DiscoverySrvName: "main", SkipTLS: true, DiscoveryResolver: resolver})
+	if err != nil || !slices.Equal(endpoints, []string{"etcd2.example.com:2380"}) {
+		t.Errorf("Expected plaintext endpoints of the named service to be discovered and got: %v, %v", endpoints, err)
+	}
+
+	_, err = discoverEndpoints(context.Background(), EtcdClientOptions{DiscoverySrv: "other.com", DiscoveryResolver: resolver})
+	if err == nil {
+		t.Errorf("Expected discovery without records to fail and it didn't")
+	}
+}
+
+func TestEndpointsDiscoveryAndSync(t *testing.T) {
+	tearDown, launchErr := testutils.LaunchTestEtcdCluster("../test", testutils.EtcdTestClusterOpts{})
+	if launchErr != nil {
+		t.Errorf("Error occured launching test etcd cluster: %s", launchErr.Error())
+		return
+	}
+
+	defer func() {
+		errs := tearDown()
+		if len(errs) > 0 {
+			t.Errorf("Errors occured tearing down etcd cluster: %s", errs[0].Error())
+		}
+	}()
+
+	resolver := launchDnsStub(t, map[string][]dnsmessage.SRVResource{
+		"_etcd-client-ssl._tcp.etcd.test.": []dnsmessage.SRVResource{
+			{Target: dnsmessage.MustNewName("localhost."), Port: 3379},
+		},
+	})
+
+	changes := make(chan []string, 10)
+	cli, err := Connect(context.Background(), EtcdClientOptions{
+		ClientCertPath:    "../test/certs/root.pem",
+		ClientKeyPath:     "../test/certs/root.key",
+		CaCertPath:        "../test/certs/ca.crt",
+		DiscoverySrv:      "etcd.test",
+		DiscoveryResolver: resolver,
+		AutoSyncInterval:  100 * time.Millisecond,
+		OnEndpointsChange: func(endpoints []string) {
+			changes <- endpoints
+		},
+		ConnectionTimeout: 5 * time.Second,
+		RequestTimeout:    5 * time.Second,
+		RetryInterval:     time.Second,
+		Retries:           5,
+	})
+	if err != nil {
+		t.Errorf("Error occured connecting with discovered endpoints: %s", err.Error())
+		return
+	}
+	defer cli.Close()
+
+	if !slices.Equal(cli.connOpts.EtcdEndpoints, []string{"localhost:3379"}) {
+		t.Errorf("Expected endpoints to be discovered from the SRV records and got: %v", cli.connOpts.EtcdEndpoints)
+	}
+
+	_, err = cli.PutKey("test", "test")
+	if err != nil {
+		t.Errorf("Error occured putting key with discovered endpoints: %s", err.Error())
+	}
+
+	expected := []string{"127.0.0.1:3379", "127.0.0.2:3379", "127.0.0.3:3379"}
+	select {
+	case endpoints := <-changes:
+		if !slices.Equal(endpoints, expected) {
+			t.Errorf("Expected endpoints to be synced with the cluster members and got: %v", endpoints)
+		}
+	case <-time.After(10 * time.Second):
+		t.Errorf("Expected endpoints to be synced with the cluster members and they were not")
+		return
+	}
+
+	synced := normalizeEndpoints(cli.Client.Endpoints())
+	if !slices.Equal(synced, expected) {
+		t.Errorf("Expected the endpoints of the live connection to be updated and got: %v", synced)
+	}
+
+	_, err = cli.GetKey("test", GetKeyOptions{})
+	if err != nil {
+		t.Errorf("Error occured getting key after endpoints sync: %s", err.Error())
+	}
+
+	time.Sleep(300 * time.Millisecond)
+	if len(changes) != 0 {
+		t.Errorf("Expected the callback not to be called again when the endpoints did not change and it was")
+	}
+}
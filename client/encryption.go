@@ -0,0 +1,292 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+/*
+Options to encrypt the values of keys on the client side before they are stored in etcd.
+Values are encrypted with AES-GCM and prefixed with a header containing the id of the key they were encrypted with, so that keys can be rotated.
+The full key is authenticated along with the value, so an encrypted value cannot be moved to another key.
+Note that comparisons on the values of encrypted keys, like KeyValueIsCmp, are made against the encrypted values by etcd and will not work as expected.
+*/
+type EncryptionOptions struct {
+	//Encryption keys by id. They must be 16, 24 or 32 bytes long, for AES-128, AES-192 or AES-256.
+	//Values are decrypted with the key whose id is in their header, so keys should be kept as long as values encrypted with them remain.
+	Keys      map[string][]byte
+	//Id of the key new values are encrypted with
+	ActiveKey string
+	//Prefixes of the keys whose values are encrypted. Values of other keys are stored in plaintext.
+	Prefixes  []string
+}
+
+/*
+Header of the values encrypted by the client, followed by the length of the key id and the key id itself.
+*/
+var encryptionHeader = []byte{0x00, 'E', 'S', 0x01}
+
+const encryptionNonceSize = 12
+
+/*
+Limits on the transactions that encrypt values again, below the default maximum number of operations per transaction and request size of etcd.
+*/
+const (
+	reEncryptMaxOps   = int64(128)
+	reEncryptMaxBytes = 1024 * 1024
+)
+
+/*
+Encrypts and decrypts the values of the keys under the configured prefixes.
+*/
+type valueEncryption struct {
+	aeads     map[string]cipher.AEAD
+	activeKey string
+	prefixes  []string
+}
+
+func newValueEncryption(opts *EncryptionOptions) (*valueEncryption, error) {
+	if len(opts.Prefixes) == 0 {
+		return nil, fmt.Errorf("%w: No prefixes were specified for encryption", ErrInvalidOptions)
+	}
+
+	if _, ok := opts.Keys[opts.ActiveKey]; !ok {
+		return nil, fmt.Errorf("%w: The active encryption key %s is not among the encryption keys", ErrInvalidOptions, opts.ActiveKey)
+	}
+
+	enc := &valueEncryption{
+		aeads:     make(map[string]cipher.AEAD),
+		activeKey: opts.ActiveKey,
+		prefixes:  opts.Prefixes,
+	}
+
+	for id, key := range opts.Keys {
+		if len(id) == 0 || len(id) > 255 {
+			return nil, fmt.Errorf("%w: Encryption key ids must be between 1 and 255 bytes long", ErrInvalidOptions)
+		}
+
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("%w: Encryption key %s is invalid: %s", ErrInvalidOptions, id, err.Error())
+		}
+
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("%w: Encryption key %s is invalid: %s", ErrInvalidOptions, id, err.Error())
+		}
+
+		enc.aeads[id] = aead
+	}
+
+	return enc, nil
+}
+
+func (enc *valueEncryption) isEncrypted(key []byte) bool {
+	for _, prefix := range enc.prefixes {
+		if strings.HasPrefix(string(key), prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+Returns the id of the key a value was encrypted with, or false if the value is not encrypted.
+*/
+func encryptionKeyId(val []byte) (string, bool) {
+	if !bytes.HasPrefix(val, encryptionHeader) || len(val) < len(encryptionHeader) + 1 {
+		return "", false
+	}
+
+	idLen := int(val[len(encryptionHeader)])
+	if len(val) < len(encryptionHeader) + 1 + idLen {
+		return "", false
+	}
+
+	return string(val[len(encryptionHeader) + 1:len(encryptionHeader) + 1 + idLen]), true
+}
+
+func (enc *valueEncryption) encrypt(key []byte, val []byte) ([]byte, error) {
+	if !enc.isEncrypted(key) {
+		return val, nil
+	}
+
+	aead := enc.aeads[enc.activeKey]
+	out := make([]byte, 0, len(encryptionHeader) + 1 + len(enc.activeKey) + encryptionNonceSize + len(val) + aead.Overhead())
+	out = append(out, encryptionHeader...)
+	out = append(out, byte(len(enc.activeKey)))
+	out = append(out, enc.activeKey...)
+
+	nonce := make([]byte, encryptionNonceSize)
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to generate encryption nonce: %w", err)
+	}
+	out = append(out, nonce...)
+
+	return aead.Seal(out, nonce, val, key), nil
+}
+
+/*
+Decrypts a value. Values that are not under the encrypted prefixes or that were stored in plaintext, before encryption was enabled for example, are returned as is.
+*/
+func (enc *valueEncryption) decrypt(key []byte, val []byte) ([]byte, error) {
+	if !enc.isEncrypted(key) {
+		return val, nil
+	}
+
+	id, ok := encryptionKeyId(val)
+	if !ok {
+		return val, nil
+	}
+
+	aead, ok := enc.aeads[id]
+	if !ok {
+		return nil, &KeyError{Key: string(key), Kind: ErrDecryptionFailed, Cause: fmt.Errorf("Unknown encryption key %s", id)}
+	}
+
+	offset := len(encryptionHeader) + 1 + len(id)
+	if len(val) < offset + encryptionNonceSize {
+		return nil, &KeyError{Key: string(key), Kind: ErrDecryptionFailed, Cause: errors.New("Encrypted value is truncated")}
+	}
+
+	plaintext, err := aead.Open(nil, val[offset:offset + encryptionNonceSize], val[offset + encryptionNonceSize:], key)
+	if err != nil {
+		return nil, &KeyError{Key: string(key), Kind: ErrDecryptionFailed, Cause: err}
+	}
+
+	return plaintext, nil
+}
+
+func (enc *valueEncryption) encode(key []byte, val []byte) ([]byte, error) {
+	return enc.encrypt(key, val)
+}
+
+func (enc *valueEncryption) decode(key []byte, val []byte) ([]byte, error) {
+	return enc.decrypt(key, val)
+}
+
+
+/*
+Encrypts again all the values under a prefix that are not encrypted with the active encryption key, including values stored in plaintext.
+Values are rewritten by pages, each in a transaction that only succeeds if none of the page's keys were modified in the meantime, in which case the page is read again.
+The leases of the keys are preserved. Returns the number of values that were encrypted again.
+*/
+func (cli *EtcdClient) ReEncryptPrefix(prefix string) (int64, error) {
+	return cli.ReEncryptPrefixCtx(cli.Context, prefix)
+}
+
+/*
+Variant of ReEncryptPrefix that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) ReEncryptPrefixCtx(ctx context.Context, prefix string) (_ int64, err error) {
+	ctx, op := cli.startOperation(ctx, "ReEncryptPrefix", prefix)
+	defer func() { op.end(err) }()
+
+	if cli.encryption == nil {
+		return 0, fmt.Errorf("%w: Encryption is not enabled on the client", ErrInvalidOptions)
+	}
+
+	if !cli.encryption.isEncrypted([]byte(prefix)) {
+		return 0, fmt.Errorf("%w: Prefix %s is not under an encrypted prefix", ErrInvalidOptions, prefix)
+	}
+
+	pageSize := cli.PageSize
+	if pageSize <= 0 || pageSize > reEncryptMaxOps {
+		pageSize = reEncryptMaxOps
+	}
+
+	count := int64(0)
+	start := prefix
+	rangeEnd := clientv3.GetPrefixRangeEnd(prefix)
+	for attempt := uint64(1); ; {
+		//Values are read without decryption to know which key they were encrypted with
+		var res *clientv3.GetResponse
+		err = cli.withRetries(ctx, func() error {
+			ctx, cancel := context.WithTimeout(ctx, cli.RequestTimeout)
+			defer cancel()
+
+			var err error
+			res, err = cli.rawKV.Get(ctx, start, clientv3.WithRange(rangeEnd), clientv3.WithLimit(pageSize))
+			return err
+		})
+		if err != nil {
+			return count, err
+		}
+
+		//The transaction is cut short if the values are large, to stay under the request size limit of etcd
+		guards := []clientv3.Cmp{}
+		puts := []clientv3.Op{}
+		size := 0
+		processed := 0
+		for _, kv := range res.Kvs {
+			if len(puts) > 0 && size + len(kv.Key) + len(kv.Value) > reEncryptMaxBytes {
+				break
+			}
+			processed++
+
+			op.addBytes(int64(len(kv.Key) + len(kv.Value)), 0)
+			if id, ok := encryptionKeyId(kv.Value); ok && id == cli.encryption.activeKey {
+				continue
+			}
+
+			plaintext, decErr := cli.encryption.decrypt(kv.Key, kv.Value)
+			if decErr != nil {
+				return count, decErr
+			}
+
+			ciphertext, encErr := cli.encryption.encrypt(kv.Key, plaintext)
+			if encErr != nil {
+				return count, encErr
+			}
+
+			size += len(kv.Key) + len(ciphertext)
+			guards = append(guards, clientv3.Compare(clientv3.ModRevision(string(kv.Key)), "=", kv.ModRevision))
+			puts = append(puts, clientv3.OpPut(string(kv.Key), string(ciphertext), clientv3.WithLease(clientv3.LeaseID(kv.Lease))))
+		}
+
+		if len(puts) > 0 {
+			var txnRes *clientv3.TxnResponse
+			err = cli.withRetries(ctx, func() error {
+				ctx, cancel := context.WithTimeout(ctx, cli.RequestTimeout)
+				defer cancel()
+
+				var err error
+				txnRes, err = cli.rawKV.Txn(ctx).If(guards...).Then(puts...).Commit()
+				return err
+			})
+			if err != nil {
+				return count, err
+			}
+
+			op.setTxnSucceeded(txnRes.Succeeded)
+			if !txnRes.Succeeded {
+				if attempt >= DefaultUpdateAttempts {
+					return count, &KeyError{Key: start, Kind: ErrUpdateConflict}
+				}
+
+				attempt++
+				continue
+			}
+
+			op.addBytes(0, int64(size))
+			count += int64(len(puts))
+		}
+
+		if processed == 0 || (!res.More && processed == len(res.Kvs)) {
+			return count, nil
+		}
+
+		start = string(res.Kvs[processed - 1].Key) + "\x00"
+		attempt = 1
+	}
+}
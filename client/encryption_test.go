@@ -0,0 +1,216 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Ferlab-Ste-Justine/etcd-sdk/testutils"
+)
+
+func TestValueEncryption(t *testing.T) {
+	opts := EncryptionOptions{
+		Keys:      map[string][]byte{"k1": bytes.Repeat([]byte("a"), 32), "k2": bytes.Repeat([]byte("b"), 16)},
+		ActiveKey: "k1",
+		Prefixes:  []string{"/secret/"},
+	}
+	enc, err := newValueEncryption(&opts)
+	if err != nil {
+		t.Errorf("Error occured creating value encryption: %s", err.Error())
+		return
+	}
+
+	ciphertext, _ := enc.encrypt([]byte("/secret/a"), []byte("value"))
+	if id, ok := encryptionKeyId(ciphertext); !ok || id != "k1" || bytes.Contains(ciphertext, []byte("value")) {
+		t.Errorf("Expected value to be encrypted with the active key and got: %v", ciphertext)
+	}
+
+	plaintext, err := enc.decrypt([]byte("/secret/a"), ciphertext)
+	if err != nil || string(plaintext) != "value" {
+		t.Errorf("Expected value to be decrypted identically and got: %s", string(plaintext))
+	}
+
+	_, err = enc.decrypt([]byte("/secret/b"), ciphertext)
+	if !errors.Is(err, ErrDecryptionFailed) {
+		t.Errorf("Expected decrypting a value moved to another key to fail and got: %v", err)
+	}
+
+	plaintext, _ = enc.decrypt([]byte("/secret/a"), []byte("plain"))
+	if string(plaintext) != "plain" {
+		t.Errorf("Expected values stored in plaintext to be returned as is and got: %s", string(plaintext))
+	}
+
+	ciphertext, _ = enc.encrypt([]byte("/other"), []byte("value"))
+	if string(ciphertext) != "value" {
+		t.Errorf("Expected values outside of the encrypted prefixes not to be encrypted and got: %v", ciphertext)
+	}
+
+	opts.ActiveKey = "k2"
+	rotated, _ := newValueEncryption(&opts)
+	ciphertext, _ = rotated.encrypt([]byte("/secret/a"), []byte("value"))
+	plaintext, err = enc.decrypt([]byte("/secret/a"), ciphertext)
+	if err != nil || string(plaintext) != "value" {
+		t.Errorf("Expected value encrypted with a rotated key to be decrypted with the old key set and got: %v", err)
+	}
+
+	delete(opts.Keys, "k1")
+	withoutK1, _ := newValueEncryption(&opts)
+	ciphertext, _ = enc.encrypt([]byte("/secret/a"), []byte("value"))
+	_, err = withoutK1.decrypt([]byte("/secret/a"), ciphertext)
+	if !errors.Is(err, ErrDecryptionFailed) {
+		t.Errorf("Expected decrypting a value with an unknown key to fail and got: %v", err)
+	}
+
+	_, err = newValueEncryption(&EncryptionOptions{Keys: map[string][]byte{"k1": []byte("short")}, ActiveKey: "k1", Prefixes: []string{"/"}})
+	if !errors.Is(err, ErrInvalidOptions) {
+		t.Errorf("Expected an encryption key of invalid size to be rejected and got: %v", err)
+	}
+}
+
+func TestEncryptedClient(t *testing.T) {
+	tearDown, launchErr := testutils.LaunchTestEtcdCluster("../test", testutils.EtcdTestClusterOpts{})
+	if launchErr != nil {
+		t.Errorf("Error occured launching test etcd cluster: %s", launchErr.Error())
+		return
+	}
+
+	defer func() {
+		errs := tearDown()
+		if len(errs) > 0 {
+			t.Errorf("Errors occured tearing down etcd cluster: %s", errs[0].Error())
+		}
+	}()
+
+	retryInterval, _ := time.ParseDuration("1s")
+	timeouts, _ := time.ParseDuration("10s")
+	retries := uint64(10)
+	rawCli := setupTestEnv(t, timeouts, retryInterval, retries)
+
+	encOpts := &EncryptionOptions{
+		Keys:      map[string][]byte{"k1": bytes.Repeat([]byte("a"), 32)},
+		ActiveKey: "k1",
+		Prefixes:  []string{"/secret/"},
+	}
+	opts := rawCli.connOpts
+	opts.Encryption = encOpts
+	cli, err := Connect(context.Background(), opts)
+	if err != nil {
+		t.Errorf("Error occured connecting with encryption: %s", err.Error())
+		return
+	}
+	defer cli.Close()
+
+	rev, err := cli.PutKey("/secret/a", "a")
+	if err != nil {
+		t.Errorf("Error occured putting encrypted key: %s", err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watch := cli.WatchCtx(ctx, "/secret/", WatchOptions{Revision: rev, IsPrefix: true, TrimPrefix: true})
+	cli.PutKey("/plain/a", "a")
+
+	info, err := cli.GetKey("/secret/a", GetKeyOptions{})
+	if err != nil || info.Value != "a" {
+		t.Errorf("Expected encrypted key to be read back identically and got: %s", info.Value)
+	}
+
+	raw, _ := rawCli.GetKey("/secret/a", GetKeyOptions{})
+	if id, ok := encryptionKeyId([]byte(raw.Value)); !ok || id != "k1" || strings.HasSuffix(raw.Value, "a") {
+		t.Errorf("Expected the value to be stored encrypted and got: %v", []byte(raw.Value))
+	}
+
+	raw, _ = rawCli.GetKey("/plain/a", GetKeyOptions{})
+	if raw.Value != "a" {
+		t.Errorf("Expected the value outside of the encrypted prefixes to be stored in plaintext and got: %v", []byte(raw.Value))
+	}
+
+	res := <-watch
+	if res.Error != nil || res.Changes.Upserts["a"].Value != "a" {
+		t.Errorf("Expected watch to report decrypted values and got: %v", res)
+	}
+
+	cli.PutKey("/secret/b", "b")
+	cli.PutKey("/secret/dst/a", "a")
+	diff, err := cli.DiffBetweenPrefixes("/secret/", "/secret/dst/")
+	if err != nil {
+		t.Errorf("Error occured diffing encrypted prefixes: %s", err.Error())
+		return
+	}
+	if len(diff.Inserts) != 2 || len(diff.Updates) != 0 {
+		t.Errorf("Expected diff to compare the decrypted values and got: %v", diff)
+	}
+
+	err = cli.ApplyDiffToPrefix("/secret/copy/", KeyDiff{Inserts: map[string]string{"c": "c"}})
+	if err != nil {
+		t.Errorf("Error occured applying diff to encrypted prefix: %s", err.Error())
+	}
+
+	prefix, err := cli.GetPrefix("/secret/copy/")
+	if err != nil || prefix.Keys.ToValueMap("/secret/copy/")["c"] != "c" {
+		t.Errorf("Expected prefix to be read decrypted and got: %v", prefix)
+	}
+
+	raw, _ = rawCli.GetKey("/secret/copy/c", GetKeyOptions{})
+	if _, ok := encryptionKeyId([]byte(raw.Value)); !ok {
+		t.Errorf("Expected the value inserted by the diff to be stored encrypted and it wasn't")
+	}
+
+	value := bytes.Repeat([]byte("v"), 1024 * 1024 + 10)
+	err = cli.PutChunkedKey(&ChunkedKeyPayload{Key: "/secret/chunked", Value: io.NopCloser(bytes.NewReader(value)), Size: int64(len(value))})
+	if err != nil {
+		t.Errorf("Error occured putting encrypted chunked key: %s", err.Error())
+		return
+	}
+
+	payload, err := cli.GetChunkedKey("/secret/chunked")
+	if err != nil || payload == nil {
+		t.Errorf("Error occured getting encrypted chunked key: %v", err)
+		return
+	}
+	read, _ := io.ReadAll(payload)
+	if !bytes.Equal(read, value) {
+		t.Errorf("Expected encrypted chunked key to be read back identically and it wasn't")
+	}
+
+	rawCli.PutKey("/secret/legacy", "legacy")
+	encOpts.Keys["k2"] = bytes.Repeat([]byte("b"), 32)
+	encOpts.ActiveKey = "k2"
+	rotated, err := Connect(context.Background(), opts)
+	if err != nil {
+		t.Errorf("Error occured connecting with a rotated encryption key: %s", err.Error())
+		return
+	}
+	defer rotated.Close()
+
+	count, err := rotated.ReEncryptPrefix("/secret/")
+	if err != nil {
+		t.Errorf("Error occured encrypting prefix again: %s", err.Error())
+		return
+	}
+
+	rawPrefix, _ := rawCli.GetPrefix("/secret/")
+	if count != int64(len(rawPrefix.Keys)) {
+		t.Errorf("Expected all the %d keys of the prefix to be encrypted again and got %d", len(rawPrefix.Keys), count)
+	}
+	for key, info := range rawPrefix.Keys {
+		if id, ok := encryptionKeyId([]byte(info.Value)); !ok || id != "k2" {
+			t.Errorf("Expected key %s to be encrypted with the rotated key and it wasn't", key)
+		}
+	}
+
+	info, _ = rotated.GetKey("/secret/legacy", GetKeyOptions{})
+	if info.Value != "legacy" {
+		t.Errorf("Expected plaintext value to be readable after being encrypted and got: %s", info.Value)
+	}
+
+	count, _ = rotated.ReEncryptPrefix("/secret/")
+	if count != 0 {
+		t.Errorf("Expected no key to be encrypted again when all are encrypted with the active key and got %d", count)
+	}
+}
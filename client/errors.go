@@ -0,0 +1,121 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+/*
+Sentinel errors returned by the client. They can be matched with errors.Is, including on the KeyError, MemberError and LeaseError structures that wrap them.
+*/
+var (
+	ErrNotFound            = errors.New("Not found")
+	ErrLockTimeout         = errors.New("Could not acquire lock before deadline")
+	ErrTransactionFailed   = errors.New("Transaction conditions failed")
+	ErrChunkMissing        = errors.New("Chunk key not found")
+	ErrConnectionTimeout   = errors.New("Failed to establish connection to etcd servers in time")
+	ErrWatchFailed         = errors.New("Failed to watch changes")
+	ErrNoLeader            = errors.New("No leader could be reached")
+	ErrNoTransferCandidate = errors.New("No leadership transfer candidate exists")
+	ErrMemberNotFound      = errors.New("Member not found")
+	ErrMemberNotResponsive = errors.New("Member is not responsive")
+	ErrMemberIsLearner     = errors.New("Member is a learner")
+	ErrInvalidOptions      = errors.New("Invalid client options")
+	ErrEncodeFailed        = errors.New("Failed to encode value")
+	ErrDecodeFailed        = errors.New("Failed to decode value")
+	ErrUpdateConflict      = errors.New("Keys were modified concurrently during update")
+	ErrRevisionCompacted   = errors.New("Revision was compacted")
+	ErrDecryptionFailed    = errors.New("Failed to decrypt value")
+	ErrDecompressionFailed = errors.New("Failed to decompress value")
+	ErrLeaseLost           = errors.New("Lease was lost")
+	ErrLockNotHeld         = errors.New("Lock is not held by its releaser")
+)
+
+/*
+Error pertaining to a specific key.
+It matches its Kind sentinel error with errors.Is and wraps the underlying Cause, if any, for errors.Is and errors.As.
+*/
+type KeyError struct {
+	//Key the error pertains to
+	Key   string
+	//Sentinel error categorizing the error
+	Kind  error
+	//Underlying error that caused this one, usually a grpc or etcd error. Can be nil.
+	Cause error
+}
+
+func (e *KeyError) Error() string {
+	if e.Cause == nil {
+		return fmt.Sprintf("%s for key %s", e.Kind.Error(), e.Key)
+	}
+
+	return fmt.Sprintf("%s for key %s: %s", e.Kind.Error(), e.Key, e.Cause.Error())
+}
+
+func (e *KeyError) Unwrap() []error {
+	if e.Cause == nil {
+		return []error{e.Kind}
+	}
+
+	return []error{e.Kind, e.Cause}
+}
+
+/*
+Error pertaining to a specific member of the etcd cluster.
+It matches its Kind sentinel error with errors.Is and wraps the underlying Cause, if any, for errors.Is and errors.As.
+*/
+type MemberError struct {
+	//Name of the member the error pertains to
+	Member string
+	//Sentinel error categorizing the error
+	Kind   error
+	//Underlying error that caused this one, usually a grpc or etcd error. Can be nil.
+	Cause  error
+}
+
+func (e *MemberError) Error() string {
+	if e.Cause == nil {
+		return fmt.Sprintf("%s: %s", e.Kind.Error(), e.Member)
+	}
+
+	return fmt.Sprintf("%s: %s: %s", e.Kind.Error(), e.Member, e.Cause.Error())
+}
+
+func (e *MemberError) Unwrap() []error {
+	if e.Cause == nil {
+		return []error{e.Kind}
+	}
+
+	return []error{e.Kind, e.Cause}
+}
+
+/*
+Error pertaining to a specific lease.
+It matches its Kind sentinel error with errors.Is and wraps the underlying Cause, if any, for errors.Is and errors.As.
+*/
+type LeaseError struct {
+	//Id of the lease the error pertains to
+	Lease clientv3.LeaseID
+	//Sentinel error categorizing the error
+	Kind  error
+	//Underlying error that caused this one, usually a grpc or etcd error. Can be nil.
+	Cause error
+}
+
+func (e *LeaseError) Error() string {
+	if e.Cause == nil {
+		return fmt.Sprintf("%s for lease %x", e.Kind.Error(), int64(e.Lease))
+	}
+
+	return fmt.Sprintf("%s for lease %x: %s", e.Kind.Error(), int64(e.Lease), e.Cause.Error())
+}
+
+func (e *LeaseError) Unwrap() []error {
+	if e.Cause == nil {
+		return []error{e.Kind}
+	}
+
+	return []error{e.Kind, e.Cause}
+}
@@ -0,0 +1,54 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestKeyError(t *testing.T) {
+	var err error = &KeyError{Key: "test", Kind: ErrLockTimeout}
+	if !errors.Is(err, ErrLockTimeout) {
+		t.Errorf("Expected key error to match its kind and it didn't")
+	}
+	if errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected key error not to match another kind and it did")
+	}
+
+	var keyErr *KeyError
+	if !errors.As(fmt.Errorf("Wrapped: %w", err), &keyErr) || keyErr.Key != "test" {
+		t.Errorf("Expected wrapped key error to be extractable with errors.As and it wasn't")
+	}
+
+	err = &KeyError{Key: "test", Kind: ErrChunkMissing, Cause: rpctypes.ErrGRPCNoLeader}
+	if !errors.Is(err, ErrChunkMissing) || !errors.Is(err, rpctypes.ErrGRPCNoLeader) {
+		t.Errorf("Expected key error to match both its kind and its cause and it didn't")
+	}
+}
+
+func TestMemberError(t *testing.T) {
+	cause := status.Error(codes.Unavailable, "unavailable")
+	var err error = &MemberError{Member: "etcd0", Kind: ErrMemberNotResponsive, Cause: cause}
+	if !errors.Is(err, ErrMemberNotResponsive) {
+		t.Errorf("Expected member error to match its kind and it didn't")
+	}
+
+	stat, ok := status.FromError(err)
+	if !ok || stat.Code() != codes.Unavailable {
+		t.Errorf("Expected grpc status of the cause to be extractable from the member error and it wasn't")
+	}
+}
+
+func TestErrorIsRetryableWrapped(t *testing.T) {
+	if !ErrorIsRetryable(fmt.Errorf("Wrapped: %w", status.Error(codes.Unavailable, "unavailable"))) {
+		t.Errorf("Expected wrapped unavailable grpc error to be retryable and it wasn't")
+	}
+
+	if ErrorIsRetryable(fmt.Errorf("Wrapped: %w", ErrLockTimeout)) {
+		t.Errorf("Expected wrapped sdk error not to be retryable and it was")
+	}
+}
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"errors"
 	"fmt"
 )
@@ -9,7 +10,17 @@ import (
 Join a group as represented by groupPrefix. A member with id memberId and content memberContent will be added.
 */
 func (cli *EtcdClient) JoinGroup(groupPrefix string, memberId string, memberContent string) (error) {
-	_, err := cli.PutKey(fmt.Sprintf("%s%s", groupPrefix, memberId), memberContent)
+	return cli.JoinGroupCtx(cli.Context, groupPrefix, memberId, memberContent)
+}
+
+/*
+Variant of JoinGroup that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) JoinGroupCtx(ctx context.Context, groupPrefix string, memberId string, memberContent string) (err error) {
+	ctx, op := cli.startOperation(ctx, "JoinGroup", groupPrefix)
+	defer func() { op.end(err) }()
+
+	_, err = cli.PutKeyCtx(ctx, fmt.Sprintf("%s%s", groupPrefix, memberId), memberContent)
 	return err
 }
 
@@ -17,7 +28,17 @@ func (cli *EtcdClient) JoinGroup(groupPrefix string, memberId string, memberCont
 Leave a group as represented by groupPrefix. A member with id memberId will be removed.
 */
 func (cli *EtcdClient) LeaveGroup(groupPrefix string, memberId string) (error) {
-	return cli.DeleteKey(fmt.Sprintf("%s%s", groupPrefix, memberId))
+	return cli.LeaveGroupCtx(cli.Context, groupPrefix, memberId)
+}
+
+/*
+Variant of LeaveGroup that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) LeaveGroupCtx(ctx context.Context, groupPrefix string, memberId string) (err error) {
+	ctx, op := cli.startOperation(ctx, "LeaveGroup", groupPrefix)
+	defer func() { op.end(err) }()
+
+	return cli.DeleteKeyCtx(ctx, fmt.Sprintf("%s%s", groupPrefix, memberId))
 }
 
 /*
@@ -26,7 +47,17 @@ First return value are a map of members, with its keys being member ids and valu
 Second return value is the etcd revision at the time the result was obtained
 */
 func (cli *EtcdClient) GetGroupMembers(groupPrefix string) (map[string]string, int64, error) {
-	info, err := cli.GetPrefix(groupPrefix)
+	return cli.GetGroupMembersCtx(cli.Context, groupPrefix)
+}
+
+/*
+Variant of GetGroupMembers that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) GetGroupMembersCtx(ctx context.Context, groupPrefix string) (_ map[string]string, _ int64, err error) {
+	ctx, op := cli.startOperation(ctx, "GetGroupMembers", groupPrefix)
+	defer func() { op.end(err) }()
+
+	info, err := cli.GetPrefixCtx(ctx, groupPrefix)
 	if err != nil {
 		return nil, -1, err
 	}
@@ -40,10 +71,21 @@ Last argument is a done channel that can be closed to halt the wait.
 Return argument is a channel that will received an error if there is an issue or otherwise will be closed when the wait condition is fulfilled
 */
 func (cli *EtcdClient) WaitGroupCountThreshold(groupPrefix string, threshold int64, doneCh <-chan struct{}) <-chan error {
+	return cli.WaitGroupCountThresholdCtx(cli.Context, groupPrefix, threshold, doneCh)
+}
+
+/*
+Variant of WaitGroupCountThreshold that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) WaitGroupCountThresholdCtx(ctx context.Context, groupPrefix string, threshold int64, doneCh <-chan struct{}) <-chan error {
 	errCh := make(chan error)
 	go func() {
+		var err error
+		ctx, op := cli.startOperation(ctx, "WaitGroupCountThreshold", groupPrefix)
+		defer func() { op.end(err) }()
+
 		defer close(errCh)
-		members, rev, err := cli.GetGroupMembers(groupPrefix)
+		members, rev, err := cli.GetGroupMembersCtx(ctx, groupPrefix)
 		if err != nil {
 			errCh <- err
 			return
@@ -53,17 +95,19 @@ func (cli *EtcdClient) WaitGroupCountThreshold(groupPrefix string, threshold int
 			return
 		}
 
-		wcCh := cli.Watch(groupPrefix, WatchOptions{IsPrefix: true, TrimPrefix: true, Revision: rev + 1})
+		wcCh := cli.WatchCtx(ctx, groupPrefix, WatchOptions{IsPrefix: true, TrimPrefix: true, Revision: rev + 1})
 		for true {
 			select {
 			case res, ok :=  <-wcCh:
 				if !ok {
-					errCh <- errors.New("Watch stopped before reaching threshold")
+					err = errors.New("Watch stopped before reaching threshold")
+					errCh <- err
 					return
 				}
 
 				if res.Error != nil {
-					errCh <- res.Error
+					err = res.Error
+					errCh <- err
 					return
 				}
 
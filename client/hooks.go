@@ -0,0 +1,170 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+/*
+Information on a request attempt against the etcd cluster, as reported to the operation hooks.
+*/
+type AttemptInfo struct {
+	//Name of the client method that made the request, ie: "PutKey"
+	Operation string
+	//Key, prefix or range start the operation is about. Empty for operations that are not about keys.
+	Key       string
+	//Attempt number, starting at 1
+	Attempt   uint64
+	//Error returned by the attempt. Nil for OnAttempt and OnSuccess.
+	Error     error
+	//Interval of time that will be waited before the next attempt. Only set for OnRetry.
+	Interval  time.Duration
+	//Time elapsed since the first attempt of the request
+	Elapsed   time.Duration
+}
+
+/*
+Hooks that are called during the requests the client makes to the etcd cluster.
+Note that methods that combine several requests, like PutChunkedKey or AcquireLock, will trigger the hooks for each request with the method's name as the operation.
+*/
+type OperationHooks interface {
+	//Called before each attempt of a request
+	OnAttempt(ctx context.Context, info AttemptInfo)
+	//Called when an attempt failed and the request will be attempted again
+	OnRetry(ctx context.Context, info AttemptInfo)
+	//Called when a request failed and will not be attempted again
+	OnError(ctx context.Context, info AttemptInfo)
+	//Called when a request succeeded
+	OnSuccess(ctx context.Context, info AttemptInfo)
+}
+
+/*
+Implementation of OperationHooks with a function for each hook.
+Functions that are nil are skipped, which is convenient when only some hooks are needed.
+*/
+type OperationHookFuncs struct {
+	Attempt func(ctx context.Context, info AttemptInfo)
+	Retry   func(ctx context.Context, info AttemptInfo)
+	Error   func(ctx context.Context, info AttemptInfo)
+	Success func(ctx context.Context, info AttemptInfo)
+}
+
+func (h OperationHookFuncs) OnAttempt(ctx context.Context, info AttemptInfo) {
+	if h.Attempt != nil {
+		h.Attempt(ctx, info)
+	}
+}
+
+func (h OperationHookFuncs) OnRetry(ctx context.Context, info AttemptInfo) {
+	if h.Retry != nil {
+		h.Retry(ctx, info)
+	}
+}
+
+func (h OperationHookFuncs) OnError(ctx context.Context, info AttemptInfo) {
+	if h.Error != nil {
+		h.Error(ctx, info)
+	}
+}
+
+func (h OperationHookFuncs) OnSuccess(ctx context.Context, info AttemptInfo) {
+	if h.Success != nil {
+		h.Success(ctx, info)
+	}
+}
+
+/*
+Returns a copy of the EtcdClient instance with different operation hooks.
+Note that the underlying client connection to the etcd cluster is reused.
+*/
+func (cli *EtcdClient) SetHooks(hooks OperationHooks) *EtcdClient {
+	copy := *cli
+	copy.Hooks = hooks
+	return &copy
+}
+
+/*
+Returns a copy of the EtcdClient instance with a different logger.
+Note that the underlying client connection to the etcd cluster is reused and keeps logging with the logger it was created with.
+*/
+func (cli *EtcdClient) SetLogger(logger *zap.Logger) *EtcdClient {
+	copy := *cli
+	copy.Logger = logger
+	return &copy
+}
+
+func (cli *EtcdClient) getLogger() *zap.Logger {
+	if cli.Logger != nil {
+		return cli.Logger
+	}
+
+	return zap.NewNop()
+}
+
+func (cli *EtcdClient) onAttempt(ctx context.Context, info AttemptInfo) {
+	cli.getLogger().Debug(
+		"Attempting etcd request",
+		zap.String("operation", info.Operation),
+		zap.String("key", info.Key),
+		zap.Uint64("attempt", info.Attempt),
+	)
+
+	if cli.Hooks != nil {
+		cli.Hooks.OnAttempt(ctx, info)
+	}
+}
+
+func (cli *EtcdClient) onRetry(ctx context.Context, info AttemptInfo) {
+	cli.getLogger().Warn(
+		"Etcd request failed, retrying",
+		zap.String("operation", info.Operation),
+		zap.String("key", info.Key),
+		zap.Uint64("attempt", info.Attempt),
+		zap.Duration("interval", info.Interval),
+		zap.Error(info.Error),
+	)
+
+	if cli.Metrics != nil {
+		cli.Metrics.ObserveRetry(info.Operation, info.Error)
+	}
+
+	if cli.Hooks != nil {
+		cli.Hooks.OnRetry(ctx, info)
+	}
+}
+
+func (cli *EtcdClient) onError(ctx context.Context, info AttemptInfo) {
+	cli.getLogger().Error(
+		"Etcd request failed",
+		zap.String("operation", info.Operation),
+		zap.String("key", info.Key),
+		zap.Uint64("attempt", info.Attempt),
+		zap.Duration("elapsed", info.Elapsed),
+		zap.Error(info.Error),
+	)
+
+	if cli.Hooks != nil {
+		cli.Hooks.OnError(ctx, info)
+	}
+}
+
+func (cli *EtcdClient) onSuccess(ctx context.Context, info AttemptInfo) {
+	logger := cli.getLogger()
+	fields := []zap.Field{
+		zap.String("operation", info.Operation),
+		zap.String("key", info.Key),
+		zap.Uint64("attempt", info.Attempt),
+		zap.Duration("elapsed", info.Elapsed),
+	}
+	if info.Attempt > 1 {
+		logger.Info("Etcd request succeeded after retries", fields...)
+	} else {
+		logger.Debug("Etcd request succeeded", fields...)
+	}
+
+	if cli.Hooks != nil {
+		cli.Hooks.OnSuccess(ctx, info)
+	}
+}
@@ -0,0 +1,68 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestOperationHooks(t *testing.T) {
+	calls := map[string][]AttemptInfo{}
+	record := func(hook string) func(ctx context.Context, info AttemptInfo) {
+		return func(ctx context.Context, info AttemptInfo) {
+			calls[hook] = append(calls[hook], info)
+		}
+	}
+
+	core, logs := observer.New(zap.DebugLevel)
+	cli := (&EtcdClient{Retries: 3, RetryInterval: time.Millisecond}).SetHooks(OperationHookFuncs{
+		Attempt: record("attempt"),
+		Retry:   record("retry"),
+		Error:   record("error"),
+		Success: record("success"),
+	}).SetLogger(zap.New(core))
+
+	ctx, _ := cli.startOperation(context.Background(), "PutKey", "test")
+	ctx, _ = cli.startOperation(ctx, "GetKey", "other")
+	attempts := 0
+	cli.withRetries(ctx, func() error {
+		attempts += 1
+		if attempts < 3 {
+			return status.Error(codes.Unavailable, "unavailable")
+		}
+		return nil
+	})
+
+	if len(calls["attempt"]) != 3 || len(calls["retry"]) != 2 || len(calls["success"]) != 1 || len(calls["error"]) != 0 {
+		t.Errorf("Expected 3 attempts, 2 retries and a success to be reported and got: %v", calls)
+	}
+
+	success := calls["success"][0]
+	if success.Operation != "PutKey" || success.Key != "test" || success.Attempt != 3 {
+		t.Errorf("Expected success to be reported for the outermost operation on the third attempt and got: %v", success)
+	}
+
+	if calls["retry"][1].Attempt != 2 || calls["retry"][1].Interval != time.Millisecond || calls["retry"][1].Error == nil {
+		t.Errorf("Expected retry to be reported with its attempt, interval and error and got: %v", calls["retry"][1])
+	}
+
+	if logs.FilterMessage("Etcd request failed, retrying").Len() != 2 || logs.FilterMessage("Etcd request succeeded after retries").Len() != 1 {
+		t.Errorf("Expected retries and the final success to be logged and got: %v", logs.All())
+	}
+
+	calls = map[string][]AttemptInfo{}
+	notRetryable := errors.New("Not retryable")
+	cli.withRetries(ctx, func() error {
+		return notRetryable
+	})
+
+	if len(calls["attempt"]) != 1 || len(calls["error"]) != 1 || calls["error"][0].Error != notRetryable {
+		t.Errorf("Expected a single attempt and the final error to be reported and got: %v", calls)
+	}
+}
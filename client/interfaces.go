@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+)
+
+/*
+Operations on individual keys.
+*/
+type KeyStore interface {
+	PutKey(key string, val string) (int64, error)
+	PutKeyCtx(ctx context.Context, key string, val string) (int64, error)
+	GetKey(key string, opts GetKeyOptions) (KeyInfo, error)
+	GetKeyCtx(ctx context.Context, key string, opts GetKeyOptions) (KeyInfo, error)
+	DeleteKey(key string) error
+	DeleteKeyCtx(ctx context.Context, key string) error
+}
+
+/*
+Operations on ranges of keys.
+*/
+type RangeStore interface {
+	GetKeyRange(key string, rangeEnd string) (KeyRangeInfo, error)
+	GetKeyRangeCtx(ctx context.Context, key string, rangeEnd string) (KeyRangeInfo, error)
+	DeleteKeyRange(key string, rangeEnd string) error
+	DeleteKeyRangeCtx(ctx context.Context, key string, rangeEnd string) error
+}
+
+/*
+Operations on keys sharing a prefix.
+*/
+type PrefixStore interface {
+	GetPrefix(prefix string) (KeyRangeInfo, error)
+	GetPrefixCtx(ctx context.Context, prefix string) (KeyRangeInfo, error)
+	DeletePrefix(prefix string) error
+	DeletePrefixCtx(ctx context.Context, prefix string) error
+	DiffBetweenPrefixes(srcPrefix string, dstPrefix string) (KeyDiff, error)
+	DiffBetweenPrefixesCtx(ctx context.Context, srcPrefix string, dstPrefix string) (KeyDiff, error)
+	ApplyDiffToPrefix(prefix string, diff KeyDiff) error
+	ApplyDiffToPrefixCtx(ctx context.Context, prefix string, diff KeyDiff) error
+}
+
+/*
+Watching of changes on keys.
+*/
+type Watcher interface {
+	Watch(wKey string, opts WatchOptions) <-chan WatchNotification
+	WatchCtx(ctx context.Context, wKey string, opts WatchOptions) <-chan WatchNotification
+}
+
+/*
+Lease-based locks.
+*/
+type LockManager interface {
+	AcquireLock(opts AcquireLockOptions) (*Lock, bool, error)
+	AcquireLockCtx(ctx context.Context, opts AcquireLockOptions) (*Lock, bool, error)
+	ReadLock(key string) (*Lock, error)
+	ReadLockCtx(ctx context.Context, key string) (*Lock, error)
+	ReleaseLock(key string) error
+	ReleaseLockCtx(ctx context.Context, key string) error
+	ReleaseHeldLock(lock *Lock) error
+	ReleaseHeldLockCtx(ctx context.Context, lock *Lock) error
+}
+
+/*
+Group membership.
+*/
+type GroupManager interface {
+	JoinGroup(groupPrefix string, memberId string, memberContent string) error
+	JoinGroupCtx(ctx context.Context, groupPrefix string, memberId string, memberContent string) error
+	LeaveGroup(groupPrefix string, memberId string) error
+	LeaveGroupCtx(ctx context.Context, groupPrefix string, memberId string) error
+	GetGroupMembers(groupPrefix string) (map[string]string, int64, error)
+	GetGroupMembersCtx(ctx context.Context, groupPrefix string) (map[string]string, int64, error)
+	WaitGroupCountThreshold(groupPrefix string, threshold int64, doneCh <-chan struct{}) <-chan error
+	WaitGroupCountThresholdCtx(ctx context.Context, groupPrefix string, threshold int64, doneCh <-chan struct{}) <-chan error
+}
+
+/*
+Keys whose values are split into chunks, to store values larger than etcd's request size limit.
+*/
+type ChunkedKeyStore interface {
+	PutChunkedKey(key *ChunkedKeyPayload) error
+	PutChunkedKeyCtx(ctx context.Context, key *ChunkedKeyPayload) error
+	GetChunkedKey(key string) (*ChunkedKeyPayload, error)
+	GetChunkedKeyCtx(ctx context.Context, key string) (*ChunkedKeyPayload, error)
+	DeleteChunkedKey(key string) error
+	DeleteChunkedKeyCtx(ctx context.Context, key string) error
+}
+
+/*
+All the key-value operations of the client.
+Dependent code can take this interface instead of an EtcdClient to be unit tested against the in-memory implementation of the memory package.
+*/
+type KeyValueStore interface {
+	KeyStore
+	RangeStore
+	PrefixStore
+	Watcher
+	LockManager
+	GroupManager
+	ChunkedKeyStore
+}
+
+var _ KeyValueStore = (*EtcdClient)(nil)
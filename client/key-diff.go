@@ -1,5 +1,9 @@
 package client
 
+import (
+	"bytes"
+)
+
 /*
 Differential between two key spaces
 */
@@ -109,3 +113,150 @@ func GetKeyDiff(src map[string]string, dst map[string]string) KeyDiff {
 
 	return diffs
 }
+
+/*
+Variant of the KeyDiff structure with values as byte slices, for binary values.
+*/
+type KeyBytesDiff struct {
+	//List of keys to insert with the insert value to make it like the source
+	Inserts   map[string][]byte
+	//List of keys to update with the update value to make it like the source
+	Updates   map[string][]byte
+	//List of keys to delete in the target to make it like the source
+	Deletions []string
+}
+
+/*
+Returns true if a KeyBytesDiff Structure indicates not modifications to the destination
+*/
+func (diff *KeyBytesDiff) IsEmpty() bool {
+	return len(diff.Inserts) == 0 && len(diff.Updates) == 0 && len(diff.Deletions) == 0
+}
+
+/*
+Filter the keys of a KeyBytesDiff structure by applying a function on each key and only keeping the keys for which the function returns true.
+The result of the filter is returned into a separate structure.
+*/
+func (diff *KeyBytesDiff) FilterKeys(fn KeyDiffFilter) *KeyBytesDiff {
+	copy := KeyBytesDiff{
+		Inserts: map[string][]byte{},
+		Updates: map[string][]byte{},
+		Deletions: []string{},
+	}
+
+	for key, val := range diff.Inserts {
+		if fn(key) {
+			copy.Inserts[key] = val
+		}
+	}
+
+	for key, val := range diff.Updates {
+		if fn(key) {
+			copy.Updates[key] = val
+		}
+	}
+
+	for _, key :=  range diff.Deletions {
+		if fn(key) {
+			copy.Deletions = append(copy.Deletions, key)
+		}
+	}
+
+	return &copy
+}
+
+/*
+Change the keys of a KeyBytesDiff structure by applying a function on each key.
+The result of the filter is returned into a separate structure.
+*/
+func (diff *KeyBytesDiff) TransformKeys(fn KeyDiffTransform) *KeyBytesDiff {
+	copy := KeyBytesDiff{
+		Inserts: map[string][]byte{},
+		Updates: map[string][]byte{},
+		Deletions: []string{},
+	}
+
+	for key, val := range diff.Inserts {
+		copy.Inserts[fn(key)] = val
+	}
+
+	for key, val := range diff.Updates {
+		copy.Updates[fn(key)] = val
+	}
+
+	for _, key :=  range diff.Deletions {
+		copy.Deletions = append(copy.Deletions, fn(key))
+	}
+
+	return &copy
+}
+
+/*
+Converts a KeyDiff structure to a KeyBytesDiff structure, copying the values to byte slices.
+*/
+func (diff *KeyDiff) ToBytes() KeyBytesDiff {
+	res := KeyBytesDiff{
+		Inserts:   make(map[string][]byte),
+		Updates:   make(map[string][]byte),
+		Deletions: diff.Deletions,
+	}
+
+	for key, val := range diff.Inserts {
+		res.Inserts[key] = []byte(val)
+	}
+
+	for key, val := range diff.Updates {
+		res.Updates[key] = []byte(val)
+	}
+
+	return res
+}
+
+/*
+Converts a KeyBytesDiff structure to a KeyDiff structure, copying the values to strings.
+*/
+func (diff *KeyBytesDiff) ToStrings() KeyDiff {
+	res := KeyDiff{
+		Inserts:   make(map[string]string),
+		Updates:   make(map[string]string),
+		Deletions: diff.Deletions,
+	}
+
+	for key, val := range diff.Inserts {
+		res.Inserts[key] = string(val)
+	}
+
+	for key, val := range diff.Updates {
+		res.Updates[key] = string(val)
+	}
+
+	return res
+}
+
+/*
+Variant of GetKeyDiff for binary values.
+*/
+func GetKeyBytesDiff(src map[string][]byte, dst map[string][]byte) KeyBytesDiff {
+	diffs := KeyBytesDiff{
+		Inserts:   make(map[string][]byte),
+		Updates:   make(map[string][]byte),
+		Deletions: []string{},
+	}
+
+	for key, _ := range dst {
+		if _, ok := src[key]; !ok {
+			diffs.Deletions = append(diffs.Deletions, key)
+		}
+	}
+
+	for key, srcVal := range src {
+		dstVal, ok := dst[key]
+		if !ok {
+			diffs.Inserts[key] = srcVal
+		} else if !bytes.Equal(dstVal, srcVal) {
+			diffs.Updates[key] = srcVal
+		}
+	}
+
+	return diffs
+}
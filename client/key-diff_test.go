@@ -1,6 +1,7 @@
 package client
 
 import (
+	"bytes"
 	"testing"
 )
 
@@ -64,4 +65,25 @@ func TestGetKeyDiff(t *testing.T) {
 	if (!ok) || len(diff.Inserts) != 1 || val != "test" {
 		t.Errorf("Excepted GetKeyDiff to flag the right inserted element with the right value and it didn't")
 	}
-}
\ No newline at end of file
+}
+func TestGetKeyBytesDiff(t *testing.T) {
+	src := map[string][]byte{"same": {0x00, 0xff}, "changed": {0x01}, "new": {0xfe}}
+	dst := map[string][]byte{"same": {0x00, 0xff}, "changed": {0x02}, "old": {0x03}}
+
+	diff := GetKeyBytesDiff(src, dst)
+	if len(diff.Inserts) != 1 || !bytes.Equal(diff.Inserts["new"], []byte{0xfe}) {
+		t.Errorf("Expected binary key diff to have the new key as its only insert and got: %v", diff.Inserts)
+	}
+	if len(diff.Updates) != 1 || !bytes.Equal(diff.Updates["changed"], []byte{0x01}) {
+		t.Errorf("Expected binary key diff to have the changed key as its only update and got: %v", diff.Updates)
+	}
+	if len(diff.Deletions) != 1 || diff.Deletions[0] != "old" {
+		t.Errorf("Expected binary key diff to have the old key as its only deletion and got: %v", diff.Deletions)
+	}
+
+	strDiff := diff.ToStrings()
+	roundTrip := strDiff.ToBytes()
+	if !bytes.Equal(roundTrip.Inserts["new"], []byte{0xfe}) || !bytes.Equal(roundTrip.Updates["changed"], []byte{0x01}) {
+		t.Errorf("Expected binary values to be preserved when converting a key diff to strings and back and they weren't")
+	}
+}
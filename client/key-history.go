@@ -0,0 +1,338 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"google.golang.org/grpc/metadata"
+)
+
+/*
+Options that get passed to the GetKeyHistory method.
+The history is walked backward, by windows of revisions of growing size, until one of the bounds is reached or until the compaction boundary, past which etcd no longer has the history.
+*/
+type KeyHistoryOptions struct {
+	//Revision to start walking backward from. Defaults to the current revision of the etcd store if 0.
+	Revision    int64
+	//Maximum number of changes to return. Ignored if 0.
+	Limit       int64
+	//Oldest revision to return changes from. Ignored if 0.
+	MinRevision int64
+	//Maximum time spent walking the history, after which an error matching ErrWatchFailed is returned. Ignored if 0.
+	//It bounds the duration of the walk, not the time range of the changes: etcd does not record when revisions were made, so the history cannot be bounded by time.
+	Timeout     time.Duration
+}
+
+/*
+Past changes of a key, as returned by the GetKeyHistory method.
+*/
+type KeyHistory struct {
+	//Changes of the key, from the most recent to the oldest.
+	//Each change is the state of the key after it. Deletions are reported with a KeyInfo that is not found, whose ModRevision is the revision of the deletion.
+	Changes         []KeyInfo
+	//Set to true if the walk reached the compaction boundary, in which case older changes were discarded by etcd
+	Truncated       bool
+	//Revision etcd was compacted at. Only set if the history is truncated.
+	CompactRevision int64
+	//Revision from which to continue walking backward if the walk stopped at the limit. 0 if there is nothing older to walk.
+	NextRevision    int64
+}
+
+/*
+Past changes of the keys under a prefix, as returned by the GetPrefixHistory method.
+*/
+type PrefixHistory struct {
+	//Changes of each key, from the most recent to the oldest. They are reported the same way as in the KeyHistory structure.
+	Keys            map[string][]KeyInfo
+	//Set to true if the compaction boundary was reached before the minimum revision, in which case older changes were discarded by etcd
+	Truncated       bool
+	//Revision etcd was compacted at. Only set if the history is truncated.
+	CompactRevision int64
+}
+
+/*
+Size of the first window of revisions that is replayed when walking a history backward. It is doubled for each subsequent window.
+*/
+const historyWindow = int64(100)
+
+/*
+Interval of time a replay waits for events before requesting a progress notification. It is doubled each time the replay stays idle, up to the maximum.
+*/
+const (
+	replayProgressInterval    = 10 * time.Millisecond
+	maxReplayProgressInterval = time.Second
+)
+
+func historyChange(ev *clientv3.Event) KeyInfo {
+	if ev.Type == mvccpb.DELETE {
+		return KeyInfo{
+			Key:         string(ev.Kv.Key),
+			ModRevision: ev.Kv.ModRevision,
+		}
+	}
+
+	info := newKeyBytesInfo(ev.Kv)
+	return info.ToKeyInfo()
+}
+
+/*
+Returns the past changes of a key, including deletions and recreations, walking backward from the most recent.
+*/
+func (cli *EtcdClient) GetKeyHistory(key string, opts KeyHistoryOptions) (KeyHistory, error) {
+	return cli.GetKeyHistoryCtx(cli.Context, key, opts)
+}
+
+/*
+Variant of GetKeyHistory that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) GetKeyHistoryCtx(ctx context.Context, key string, opts KeyHistoryOptions) (_ KeyHistory, err error) {
+	ctx, op := cli.startOperation(ctx, "GetKeyHistory", key)
+	defer func() { op.end(err) }()
+
+	history := KeyHistory{Changes: []KeyInfo{}}
+
+	maxRev := opts.Revision
+	if maxRev <= 0 {
+		maxRev, err = cli.getCurrentRevision(ctx, key)
+		if err != nil {
+			return history, err
+		}
+	}
+
+	minRev := opts.MinRevision
+	if minRev <= 0 {
+		minRev = 1
+	}
+
+	if minRev > maxRev {
+		return history, nil
+	}
+
+	replayCtx := ctx
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		replayCtx, cancel = context.WithTimeoutCause(ctx, opts.Timeout, fmt.Errorf("%w: History was not replayed within %s", ErrWatchFailed, opts.Timeout))
+		defer cancel()
+	}
+
+	//The windows are replayed from the most recent, so that the walk stops as soon as the limit is reached
+	window := historyWindow
+	for hi := maxRev; hi >= minRev; {
+		lo := max(hi - window + 1, minRev)
+		events, compactRev, err := cli.replayEvents(replayCtx, key, false, lo, hi)
+		if err != nil {
+			if replayCtx.Err() != nil && ctx.Err() == nil {
+				return history, context.Cause(replayCtx)
+			}
+			return history, err
+		}
+
+		for idx := len(events) - 1; idx >= 0; idx-- {
+			if opts.Limit > 0 && int64(len(history.Changes)) >= opts.Limit {
+				history.NextRevision = events[idx].Kv.ModRevision
+				return history, nil
+			}
+
+			history.Changes = append(history.Changes, historyChange(events[idx]))
+		}
+
+		if compactRev > 0 {
+			history.Truncated = true
+			history.CompactRevision = compactRev
+			return history, nil
+		}
+
+		hi = lo - 1
+		window = window * 2
+
+		if hi >= minRev && opts.Limit > 0 && int64(len(history.Changes)) >= opts.Limit {
+			history.NextRevision = hi
+			return history, nil
+		}
+	}
+
+	op.setRevision(maxRev)
+	return history, nil
+}
+
+/*
+Returns the changes of all the keys under a prefix between two revisions, inclusively.
+The maximum revision defaults to the current revision of the etcd store if 0.
+*/
+func (cli *EtcdClient) GetPrefixHistory(prefix string, minRevision int64, maxRevision int64) (PrefixHistory, error) {
+	return cli.GetPrefixHistoryCtx(cli.Context, prefix, minRevision, maxRevision)
+}
+
+/*
+Variant of GetPrefixHistory that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) GetPrefixHistoryCtx(ctx context.Context, prefix string, minRevision int64, maxRevision int64) (_ PrefixHistory, err error) {
+	ctx, op := cli.startOperation(ctx, "GetPrefixHistory", prefix)
+	defer func() { op.end(err) }()
+
+	history := PrefixHistory{Keys: make(map[string][]KeyInfo)}
+
+	if maxRevision <= 0 {
+		maxRevision, err = cli.getCurrentRevision(ctx, prefix)
+		if err != nil {
+			return history, err
+		}
+	}
+
+	if minRevision <= 0 {
+		minRevision = 1
+	}
+
+	if minRevision > maxRevision {
+		return history, nil
+	}
+
+	events, compactRev, err := cli.replayEvents(ctx, prefix, true, minRevision, maxRevision)
+	if err != nil {
+		return history, err
+	}
+
+	for idx := len(events) - 1; idx >= 0; idx-- {
+		key := string(events[idx].Kv.Key)
+		history.Keys[key] = append(history.Keys[key], historyChange(events[idx]))
+	}
+
+	if compactRev > 0 {
+		history.Truncated = true
+		history.CompactRevision = compactRev
+	}
+
+	op.setRevision(maxRevision)
+	return history, nil
+}
+
+func (cli *EtcdClient) getCurrentRevision(ctx context.Context, key string) (int64, error) {
+	var res *clientv3.GetResponse
+	err := cli.withRetries(ctx, func() error {
+		ctx, cancel := context.WithTimeout(ctx, cli.RequestTimeout)
+		defer cancel()
+
+		var err error
+		res, err = cli.Client.Get(ctx, key, clientv3.WithCountOnly())
+		return err
+	})
+	if err != nil {
+		return -1, err
+	}
+
+	return res.Header.Revision, nil
+}
+
+/*
+Replays the events of a key or prefix between two revisions, inclusively, in ascending order.
+If the minimum revision was compacted, the events are replayed from the compaction revision instead, which is returned.
+*/
+func (cli *EtcdClient) replayEvents(ctx context.Context, key string, isPrefix bool, minRev int64, maxRev int64) ([]*clientv3.Event, int64, error) {
+	compactRev := int64(0)
+	events := []*clientv3.Event{}
+
+	err := cli.withRetries(ctx, func() error {
+		for {
+			var err error
+			events, err = cli.replayEventsFrom(ctx, key, isPrefix, max(minRev, compactRev), maxRev)
+
+			//The replay is started again from the compaction revision, which can move forward if etcd is compacted again in the meantime
+			var compactErr *compactedError
+			if errors.As(err, &compactErr) {
+				compactRev = compactErr.Revision
+				continue
+			}
+
+			return err
+		}
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return events, compactRev, nil
+}
+
+type compactedError struct {
+	Revision int64
+}
+
+func (e *compactedError) Error() string {
+	return fmt.Sprintf("%s: %d", rpctypes.ErrCompacted.Error(), e.Revision)
+}
+
+/*
+Replays events with a single watch from the minimum revision until an event or a progress notification goes past the maximum revision.
+As etcd does not report when a watch has caught up with the past events, progress notifications are requested when the watch is idle.
+etcd ignores these requests until all the watches of the stream caught up, so they are made less and less often while the watch stays idle.
+The watches of replays share a stream separate from the client's other watches, as progress notifications are sent to all the watches of a stream.
+*/
+func (cli *EtcdClient) replayEventsFrom(ctx context.Context, key string, isPrefix bool, minRev int64, maxRev int64) ([]*clientv3.Event, error) {
+	op := getOperation(ctx)
+	events := []*clientv3.Event{}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, "etcd-sdk-stream", "replay")
+
+	watchOpts := []clientv3.OpOption{clientv3.WithRev(minRev)}
+	if isPrefix {
+		watchOpts = append(watchOpts, clientv3.WithPrefix())
+	}
+
+	wc := cli.Client.Watch(ctx, key, watchOpts...)
+	idle := replayProgressInterval
+	timer := time.NewTimer(idle)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			//Errors are ignored as the request is made again if the watch stays idle
+			cli.Client.RequestProgress(ctx)
+			idle = min(idle * 2, maxReplayProgressInterval)
+			timer.Reset(idle)
+		case res, ok := <-wc:
+			if !ok {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				return nil, fmt.Errorf("%w: Watcher was closed before the history was replayed", ErrWatchFailed)
+			}
+
+			if res.CompactRevision > 0 {
+				return nil, &compactedError{Revision: res.CompactRevision}
+			}
+
+			if res.Err() != nil {
+				return nil, res.Err()
+			}
+
+			for _, ev := range res.Events {
+				if ev.Kv.ModRevision > maxRev {
+					return events, nil
+				}
+
+				op.addBytes(int64(len(ev.Kv.Key) + len(ev.Kv.Value)), 0)
+				err := cli.decodeEvent(ev)
+				if err != nil {
+					return nil, err
+				}
+				events = append(events, ev)
+			}
+
+			if res.IsProgressNotify() && res.Header.Revision >= maxRev {
+				return events, nil
+			}
+
+			//The watch may have caught up with the events it received, in which case a progress notification can be sent right away
+			idle = replayProgressInterval
+			timer.Reset(idle)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Ferlab-Ste-Justine/etcd-sdk/testutils"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+/*
+Watcher that records the revisions its watches start from.
+*/
+type revisionsWatcher struct {
+	clientv3.Watcher
+	revisions []int64
+}
+
+func (w *revisionsWatcher) Watch(ctx context.Context, key string, opts ...clientv3.OpOption) clientv3.WatchChan {
+	w.revisions = append(w.revisions, clientv3.OpGet(key, opts...).Rev())
+	return w.Watcher.Watch(ctx, key, opts...)
+}
+
+func TestKeyHistory(t *testing.T) {
+	tearDown, launchErr := testutils.LaunchTestEtcdCluster("../test", testutils.EtcdTestClusterOpts{})
+	if launchErr != nil {
+		t.Errorf("Error occured launching test etcd cluster: %s", launchErr.Error())
+		return
+	}
+
+	defer func() {
+		errs := tearDown()
+		if len(errs) > 0 {
+			t.Errorf("Errors occured tearing down etcd cluster: %s", errs[0].Error())
+		}
+	}()
+
+	retryInterval, _ := time.ParseDuration("1s")
+	timeouts, _ := time.ParseDuration("10s")
+	retries := uint64(10)
+	cli := setupTestEnv(t, timeouts, retryInterval, retries)
+
+	rev1, _ := cli.PutKey("/history/a", "v1")
+	cli.PutKey("/history/b", "other")
+	rev2, _ := cli.PutKey("/history/a", "v2")
+	cli.DeleteKey("/history/a")
+	rev4, _ := cli.PutKey("/history/a", "v3")
+
+	history, err := cli.GetKeyHistory("/history/a", KeyHistoryOptions{})
+	if err != nil {
+		t.Errorf("Error occured getting key history: %s", err.Error())
+		return
+	}
+	if len(history.Changes) != 4 || history.Truncated || history.NextRevision != 0 {
+		t.Errorf("Expected the full history of the key to have 4 changes and got: %v", history)
+		return
+	}
+	if history.Changes[0].Value != "v3" || history.Changes[0].Version != 1 || history.Changes[0].ModRevision != rev4 {
+		t.Errorf("Expected the most recent change to be the recreation of the key and got: %v", history.Changes[0])
+	}
+	if history.Changes[1].Found() || history.Changes[1].ModRevision != rev4-1 {
+		t.Errorf("Expected the second change to be the deletion of the key and got: %v", history.Changes[1])
+	}
+	if history.Changes[2].Value != "v2" || history.Changes[3].Value != "v1" || history.Changes[3].ModRevision != rev1 {
+		t.Errorf("Expected the oldest changes to be the first versions of the key and got: %v", history.Changes[2:])
+	}
+
+	history, _ = cli.GetKeyHistory("/history/a", KeyHistoryOptions{Limit: 2})
+	if len(history.Changes) != 2 || history.NextRevision != rev2 {
+		t.Errorf("Expected a limited history to stop at the limit with the revision to continue from and got: %v", history)
+	}
+
+	history, _ = cli.GetKeyHistory("/history/a", KeyHistoryOptions{Revision: history.NextRevision, MinRevision: rev2})
+	if len(history.Changes) != 1 || history.Changes[0].Value != "v2" {
+		t.Errorf("Expected a history between two revisions to only contain the changes between them and got: %v", history)
+	}
+
+	for idx := 0; idx < int(historyWindow); idx++ {
+		cli.PutKey(fmt.Sprintf("/history/other/%d", idx), "other")
+	}
+	cli.PutKey("/history/limited", "v1")
+	cli.PutKey("/history/limited", "v2")
+	cli.PutKey("/history/limited", "v3")
+
+	watcher := &revisionsWatcher{Watcher: cli.Client.Watcher}
+	cli.Client.Watcher = watcher
+	history, err = cli.GetKeyHistory("/history/limited", KeyHistoryOptions{Limit: 2})
+	cli.Client.Watcher = watcher.Watcher
+	if err != nil || len(history.Changes) != 2 || history.Changes[0].Value != "v3" || history.Changes[1].Value != "v2" {
+		t.Errorf("Expected a limited history to return the most recent changes and got: %v", history)
+	}
+	for _, revision := range watcher.revisions {
+		if revision <= rev4 {
+			t.Errorf("Expected a limited history to only replay the most recent revisions and it replayed from revision %d", revision)
+		}
+	}
+
+	_, err = cli.GetKeyHistory("/history/a", KeyHistoryOptions{Timeout: time.Nanosecond})
+	if !errors.Is(err, ErrWatchFailed) {
+		t.Errorf("Expected a history that is not replayed within its timeout to return a watch failure error and got: %v", err)
+	}
+
+	prefixHistory, err := cli.GetPrefixHistory("/history/", rev1, rev2)
+	if err != nil {
+		t.Errorf("Error occured getting prefix history: %s", err.Error())
+		return
+	}
+	if len(prefixHistory.Keys) != 2 || len(prefixHistory.Keys["/history/a"]) != 2 || prefixHistory.Keys["/history/b"][0].Value != "other" {
+		t.Errorf("Expected prefix history to contain the changes of all the keys between the revisions and got: %v", prefixHistory)
+	}
+
+	_, err = cli.Client.Compact(cli.Context, rev2)
+	if err != nil {
+		t.Errorf("Error occured compacting etcd: %s", err.Error())
+		return
+	}
+
+	history, err = cli.GetKeyHistory("/history/a", KeyHistoryOptions{})
+	if err != nil {
+		t.Errorf("Error occured getting compacted key history: %s", err.Error())
+		return
+	}
+	if !history.Truncated || history.CompactRevision != rev2 || len(history.Changes) != 3 {
+		t.Errorf("Expected key history to be truncated at the compaction revision and got: %v", history)
+	}
+
+	prefixHistory, _ = cli.GetPrefixHistory("/history/", 0, 0)
+	if !prefixHistory.Truncated || len(prefixHistory.Keys["/history/a"]) != 3 {
+		t.Errorf("Expected prefix history to be truncated at the compaction revision and got: %v", prefixHistory)
+	}
+}
@@ -0,0 +1,298 @@
+package client
+
+import (
+	"context"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const DefaultPageSize = int64(1000)
+
+/*
+Options that get passed to the IterateRange and IteratePrefix methods.
+*/
+type IterateOptions struct {
+	//Maximum number of keys retrieved per request. Defaults to DefaultPageSize if 0 and a negative value retrieves all the keys in a single request.
+	PageSize     int64
+	//Revision of the etcd store to read the keys at. Defaults to the revision of the first request if 0.
+	Revision     int64
+	//If true, only the keys are retrieved, without their values
+	KeysOnly     bool
+	//If true, the keys are read from the member the requests are sent to without going through the leader.
+	//The reads have a lower latency, but can return stale data.
+	Serializable bool
+}
+
+/*
+Iterator over the keys of a range, in sorted order, as returned by the IterateRange and IteratePrefix methods.
+The keys are retrieved by pages as the iteration progresses and all the pages are read at the same revision, for a consistent view of the range.
+Its Next method should be called before each key is read, like so:
+	it := cli.IteratePrefix(prefix, IterateOptions{})
+	for it.Next() {
+		info := it.Key()
+	}
+	if it.Err() != nil {
+		...
+	}
+The iteration is reported as a single operation to the metrics and hooks of the client, with a span for each page.
+It lasts from the retrieval of the first page until the last key is reached, an error occurs or the Close method is called.
+*/
+type KeyIterator struct {
+	cli      *EtcdClient
+	ctx      context.Context
+	name     string
+	start    string
+	rangeEnd string
+	opts     IterateOptions
+	page     []*mvccpb.KeyValue
+	idx      int
+	more     bool
+	started  bool
+	revision int64
+	count    int64
+	err      error
+	op       *operation
+}
+
+/*
+Advances the iterator to the next key, retrieving the next page of keys if needed.
+Returns false when there are no more keys or if an error occured, in which case it is returned by the Err method.
+*/
+func (it *KeyIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	if it.idx + 1 < len(it.page) {
+		it.idx++
+		return true
+	}
+
+	if it.started && (!it.more) {
+		it.page = nil
+		it.end(nil)
+		return false
+	}
+
+	it.err = it.fetch()
+	if it.err != nil || len(it.page) == 0 {
+		it.page = nil
+		it.end(it.err)
+		return false
+	}
+
+	it.idx = 0
+	return true
+}
+
+/*
+Stops the iteration before its end, so that it is reported as done. It is not needed if the Next method returned false.
+*/
+func (it *KeyIterator) Close() {
+	it.page = nil
+	it.started = true
+	it.more = false
+	it.end(nil)
+}
+
+func (it *KeyIterator) end(err error) {
+	if it.op != nil {
+		it.op.end(err)
+		it.op = nil
+	}
+}
+
+/*
+Returns the current key of the iterator.
+*/
+func (it *KeyIterator) Key() KeyInfo {
+	info := it.KeyBytes()
+	return info.ToKeyInfo()
+}
+
+/*
+Variant of Key for binary values.
+*/
+func (it *KeyIterator) KeyBytes() KeyBytesInfo {
+	if it.idx >= len(it.page) {
+		return KeyBytesInfo{}
+	}
+
+	return newKeyBytesInfo(it.page[it.idx])
+}
+
+/*
+Returns the error that stopped the iteration, if any.
+*/
+func (it *KeyIterator) Err() error {
+	return it.err
+}
+
+/*
+Returns the revision of the etcd store the keys are read at. It is set once the Next method was called.
+*/
+func (it *KeyIterator) Revision() int64 {
+	return it.revision
+}
+
+/*
+Returns the total number of keys in the range. It is set once the Next method was called.
+*/
+func (it *KeyIterator) Count() int64 {
+	return it.count
+}
+
+func (it *KeyIterator) fetch() (err error) {
+	if it.op == nil {
+		it.ctx, it.op = it.cli.startOperation(it.ctx, it.name, it.start)
+	}
+	op := it.op
+
+	ctx, span := op.startStep(it.ctx, "etcd.Get", attribute.String("etcd.key", it.start))
+	defer func() { endSpan(span, err) }()
+
+	getOpts := []clientv3.OpOption{clientv3.WithRange(it.rangeEnd)}
+	if it.opts.PageSize > 0 {
+		getOpts = append(getOpts, clientv3.WithLimit(it.opts.PageSize))
+	}
+	if it.revision > 0 {
+		getOpts = append(getOpts, clientv3.WithRev(it.revision))
+	}
+	if it.opts.KeysOnly {
+		getOpts = append(getOpts, clientv3.WithKeysOnly())
+	}
+	if it.opts.Serializable || it.cli.SerializableReads {
+		getOpts = append(getOpts, clientv3.WithSerializable())
+	}
+
+	var res *clientv3.GetResponse
+	err = it.cli.withRetries(ctx, func() error {
+		ctx, cancel := context.WithTimeout(ctx, it.cli.RequestTimeout)
+		defer cancel()
+
+		var err error
+		res, err = it.cli.Client.Get(ctx, it.start, getOpts...)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	//When a revision is requested, the response header has the current revision of the store instead
+	if !it.started {
+		if it.revision == 0 {
+			it.revision = res.Header.Revision
+		}
+		it.count = res.Count
+		it.started = true
+	}
+
+	op.setRevision(it.revision)
+	for _, kv := range res.Kvs {
+		op.addBytes(int64(len(kv.Key) + len(kv.Value)), 0)
+	}
+
+	it.page = res.Kvs
+	it.more = res.More
+	if len(res.Kvs) > 0 {
+		//Smallest key that is greater than the last key of the page
+		it.start = string(res.Kvs[len(res.Kvs) - 1].Key) + "\x00"
+	}
+
+	return nil
+}
+
+/*
+Iterates over all the keys within a certain range of values, retrieving them by pages.
+If you want to iterate over all the keys prefixed by a certain value, consider using the IteratePrefix method instead.
+*/
+func (cli *EtcdClient) IterateRange(key string, rangeEnd string, opts IterateOptions) *KeyIterator {
+	return cli.IterateRangeCtx(cli.Context, key, rangeEnd, opts)
+}
+
+/*
+Variant of IterateRange that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) IterateRangeCtx(ctx context.Context, key string, rangeEnd string, opts IterateOptions) *KeyIterator {
+	return cli.newKeyIterator(ctx, "IterateRange", key, rangeEnd, opts)
+}
+
+/*
+Iterates over all the keys that are prefixed by a given value, retrieving them by pages.
+*/
+func (cli *EtcdClient) IteratePrefix(prefix string, opts IterateOptions) *KeyIterator {
+	return cli.IteratePrefixCtx(cli.Context, prefix, opts)
+}
+
+/*
+Variant of IteratePrefix that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) IteratePrefixCtx(ctx context.Context, prefix string, opts IterateOptions) *KeyIterator {
+	return cli.newKeyIterator(ctx, "IteratePrefix", prefix, clientv3.GetPrefixRangeEnd(prefix), opts)
+}
+
+func (cli *EtcdClient) newKeyIterator(ctx context.Context, name string, key string, rangeEnd string, opts IterateOptions) *KeyIterator {
+	if opts.PageSize == 0 {
+		opts.PageSize = DefaultPageSize
+	}
+
+	return &KeyIterator{
+		cli:      cli,
+		ctx:      ctx,
+		name:     name,
+		start:    key,
+		rangeEnd: rangeEnd,
+		opts:     opts,
+		revision: opts.Revision,
+	}
+}
+
+/*
+Returns the number of keys within a certain range of values, without retrieving them.
+*/
+func (cli *EtcdClient) CountKeyRange(key string, rangeEnd string) (int64, error) {
+	return cli.CountKeyRangeCtx(cli.Context, key, rangeEnd)
+}
+
+/*
+Variant of CountKeyRange that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) CountKeyRangeCtx(ctx context.Context, key string, rangeEnd string) (_ int64, err error) {
+	ctx, op := cli.startOperation(ctx, "CountKeyRange", key)
+	defer func() { op.end(err) }()
+
+	var res *clientv3.GetResponse
+	err = cli.withRetries(ctx, func() error {
+		ctx, cancel := context.WithTimeout(ctx, cli.RequestTimeout)
+		defer cancel()
+
+		var err error
+		res, err = cli.Client.Get(ctx, key, clientv3.WithRange(rangeEnd), clientv3.WithCountOnly())
+		return err
+	})
+	if err != nil {
+		return -1, err
+	}
+
+	op.setRevision(res.Header.Revision)
+	return res.Count, nil
+}
+
+/*
+Returns the number of keys that are prefixed by a given value, without retrieving them.
+*/
+func (cli *EtcdClient) CountPrefix(prefix string) (int64, error) {
+	return cli.CountPrefixCtx(cli.Context, prefix)
+}
+
+/*
+Variant of CountPrefix that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) CountPrefixCtx(ctx context.Context, prefix string) (_ int64, err error) {
+	ctx, op := cli.startOperation(ctx, "CountPrefix", prefix)
+	defer func() { op.end(err) }()
+
+	return cli.CountKeyRangeCtx(ctx, prefix, clientv3.GetPrefixRangeEnd(prefix))
+}
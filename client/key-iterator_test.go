@@ -0,0 +1,110 @@
+package client
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Ferlab-Ste-Justine/etcd-sdk/testutils"
+)
+
+func TestIteratePrefix(t *testing.T) {
+	tearDown, launchErr := testutils.LaunchTestEtcdCluster("../test", testutils.EtcdTestClusterOpts{})
+	if launchErr != nil {
+		t.Errorf("Error occured launching test etcd cluster: %s", launchErr.Error())
+		return
+	}
+
+	defer func() {
+		errs := tearDown()
+		if len(errs) > 0 {
+			t.Errorf("Errors occured tearing down etcd cluster: %s", errs[0].Error())
+		}
+	}()
+
+	retryInterval, _ := time.ParseDuration("1s")
+	timeouts, _ := time.ParseDuration("10s")
+	retries := uint64(10)
+	cli := setupTestEnv(t, timeouts, retryInterval, retries)
+
+	for i := 0; i < 25; i++ {
+		cli.PutKey(fmt.Sprintf("/iterate/%02d", i), "v1")
+	}
+	cli.PutKey("/other", "other")
+
+	it := cli.IteratePrefix("/iterate/", IterateOptions{PageSize: 10})
+	idx := 0
+	for it.Next() {
+		if idx == 1 {
+			//Changes made during the iteration should not be seen by the iterator
+			cli.PutKey("/iterate/24", "v2")
+			cli.PutKey("/iterate/25", "v2")
+		}
+
+		info := it.Key()
+		if info.Key != fmt.Sprintf("/iterate/%02d", idx) || info.Value != "v1" {
+			t.Errorf("Expected key %d of the iteration to be /iterate/%02d with value v1 and got %s with value %s", idx, idx, info.Key, info.Value)
+		}
+		idx++
+	}
+	if it.Err() != nil {
+		t.Errorf("Error occured iterating over prefix: %s", it.Err().Error())
+	}
+	if idx != 25 || it.Count() != 25 {
+		t.Errorf("Expected iteration to return the 25 keys at the revision of the first page and got %d keys with a count of %d", idx, it.Count())
+	}
+
+	it = cli.IteratePrefix("/iterate/", IterateOptions{PageSize: 7, KeysOnly: true})
+	idx = 0
+	for it.Next() {
+		if it.Key().Value != "" {
+			t.Errorf("Expected keys-only iteration not to return values and got: %s", it.Key().Value)
+		}
+		idx++
+	}
+	if idx != 26 {
+		t.Errorf("Expected keys-only iteration to return 26 keys and got %d", idx)
+	}
+
+	count, err := cli.CountPrefix("/iterate/")
+	if err != nil || count != 26 {
+		t.Errorf("Expected count of the prefix to be 26 and got %d", count)
+	}
+
+	paged := *cli
+	paged.PageSize = 4
+	prefix, err := paged.GetPrefix("/iterate/")
+	if err != nil || len(prefix.Keys) != 26 || prefix.Keys["/iterate/24"].Value != "v2" {
+		t.Errorf("Expected paged prefix get to return all the keys and got %d keys", len(prefix.Keys))
+	}
+
+	for i := 0; i < 20; i++ {
+		cli.PutKey(fmt.Sprintf("/iterate-copy/%02d", i), "v1")
+	}
+	cli.PutKey("/iterate-copy/03", "changed")
+	cli.PutKey("/iterate-copy/30", "extra")
+
+	diff, err := paged.DiffBetweenPrefixes("/iterate/", "/iterate-copy/")
+	if err != nil {
+		t.Errorf("Error occured diffing paged prefixes: %s", err.Error())
+		return
+	}
+	if len(diff.Inserts) != 6 || diff.Inserts["24"] != "v2" || len(diff.Updates) != 1 || diff.Updates["03"] != "v1" || len(diff.Deletions) != 1 || diff.Deletions[0] != "30" {
+		t.Errorf("Expected paged diff to have 6 inserts, 1 update and 1 deletion and got: %v", diff)
+	}
+
+	metrics := &testMetrics{}
+	it = cli.SetMetrics(metrics).IteratePrefix("/iterate/", IterateOptions{PageSize: 4})
+	for it.Next() {}
+	if it.Err() != nil || len(metrics.operations) != 1 || metrics.operations[0] != "IteratePrefix" {
+		t.Errorf("Expected an iteration over several pages to be reported as a single operation and got: %v", metrics.operations)
+	}
+
+	metrics = &testMetrics{}
+	it = cli.SetMetrics(metrics).IteratePrefix("/iterate/", IterateOptions{PageSize: 4})
+	it.Next()
+	it.Close()
+	if it.Next() || len(metrics.operations) != 1 {
+		t.Errorf("Expected a closed iteration to stop and to be reported as a single operation and got: %v", metrics.operations)
+	}
+}
@@ -1,9 +1,9 @@
 package client
 
 import (
+	"bytes"
 	"context"
-	"errors"
-	"time"
+	"strings"
 
 	clientv3 "go.etcd.io/etcd/client/v3"
 )
@@ -13,54 +13,99 @@ Convenience method that returns a KeyDiff structure containing all the operation
 Note that for comparative purpose, a relative representation of both keyspaces without their respective prefixes is assumed.
 */
 func (cli *EtcdClient) DiffBetweenPrefixes(srcPrefix string, dstPrefix string) (KeyDiff, error) {
-	src, srcErr := cli.GetKeyRange(srcPrefix, clientv3.GetPrefixRangeEnd(srcPrefix))
-	if srcErr != nil {
-		return KeyDiff{}, srcErr
-	}
+	return cli.DiffBetweenPrefixesCtx(cli.Context, srcPrefix, dstPrefix)
+}
+
+/*
+Variant of DiffBetweenPrefixes that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) DiffBetweenPrefixesCtx(ctx context.Context, srcPrefix string, dstPrefix string) (_ KeyDiff, err error) {
+	ctx, op := cli.startOperation(ctx, "DiffBetweenPrefixes", srcPrefix)
+	defer func() { op.end(err) }()
 
-	dst, dstErr := cli.GetKeyRange(dstPrefix, clientv3.GetPrefixRangeEnd(dstPrefix))
-	if dstErr != nil {
-		return KeyDiff{}, dstErr
+	diff, err := cli.DiffBetweenPrefixesBytesCtx(ctx, srcPrefix, dstPrefix)
+	if err != nil {
+		return KeyDiff{}, err
 	}
 
-	return GetKeyDiff(src.Keys.ToValueMap(srcPrefix), dst.Keys.ToValueMap(dstPrefix)), nil
+	return diff.ToStrings(), nil
 }
 
-func (cli *EtcdClient) applyDiffToPrefixWithRetries(prefix string, diff KeyDiff, retries uint64) error {
-	ctx, cancel := context.WithTimeout(cli.Context, cli.RequestTimeout)
-	defer cancel()
+/*
+Variant of DiffBetweenPrefixes for binary values.
+Both prefixes are iterated over in sorted order at the same revision, so that only the differences between them are held in memory.
+*/
+func (cli *EtcdClient) DiffBetweenPrefixesBytes(srcPrefix string, dstPrefix string) (KeyBytesDiff, error) {
+	return cli.DiffBetweenPrefixesBytesCtx(cli.Context, srcPrefix, dstPrefix)
+}
 
-	ops := []clientv3.Op{}
+/*
+Variant of DiffBetweenPrefixesBytes that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) DiffBetweenPrefixesBytesCtx(ctx context.Context, srcPrefix string, dstPrefix string) (_ KeyBytesDiff, err error) {
+	ctx, op := cli.startOperation(ctx, "DiffBetweenPrefixesBytes", srcPrefix)
+	defer func() { op.end(err) }()
 
-	for _, key := range diff.Deletions {
-		ops = append(ops, clientv3.OpDelete(prefix + key))
-	}
+	return cli.diffBetweenPrefixes(ctx, op, srcPrefix, dstPrefix, 0)
+}
 
-	for key, val := range diff.Inserts {
-		ops = append(ops, clientv3.OpPut(prefix + key, val))
+/*
+Diffs two prefixes at the given revision, or at the current one if 0.
+*/
+func (cli *EtcdClient) diffBetweenPrefixes(ctx context.Context, op *operation, srcPrefix string, dstPrefix string, revision int64) (KeyBytesDiff, error) {
+	diff := KeyBytesDiff{
+		Inserts:   make(map[string][]byte),
+		Updates:   make(map[string][]byte),
+		Deletions: []string{},
 	}
 
-	for key, val := range diff.Updates {
-		ops = append(ops, clientv3.OpPut(prefix + key, val))
+	src := cli.newKeyIterator(ctx, "IteratePrefix", srcPrefix, clientv3.GetPrefixRangeEnd(srcPrefix), cli.rangeIterateOptions(revision))
+	srcOk := src.Next()
+	if src.Err() != nil {
+		return KeyBytesDiff{}, src.Err()
 	}
 
-	tx := cli.Client.Txn(ctx).Then(ops...)
+	dst := cli.newKeyIterator(ctx, "IteratePrefix", dstPrefix, clientv3.GetPrefixRangeEnd(dstPrefix), cli.rangeIterateOptions(src.Revision()))
+	dstOk := dst.Next()
 
-	resp, txErr := tx.Commit()
-	if txErr != nil {
-		if !shouldRetry(txErr, retries) {
-			return txErr
+	//As the keys of both prefixes are sorted, the relative keys are sorted as well and can be merged
+	for srcOk || dstOk {
+		var srcInfo, dstInfo KeyBytesInfo
+		var srcKey, dstKey string
+		if srcOk {
+			srcInfo = src.KeyBytes()
+			srcKey = strings.TrimPrefix(srcInfo.Key, srcPrefix)
+		}
+		if dstOk {
+			dstInfo = dst.KeyBytes()
+			dstKey = strings.TrimPrefix(dstInfo.Key, dstPrefix)
+		}
+
+		if srcOk && ((!dstOk) || srcKey < dstKey) {
+			diff.Inserts[srcKey] = srcInfo.Value
+			srcOk = src.Next()
+		} else if dstOk && ((!srcOk) || dstKey < srcKey) {
+			diff.Deletions = append(diff.Deletions, dstKey)
+			dstOk = dst.Next()
+		} else {
+			if !bytes.Equal(srcInfo.Value, dstInfo.Value) {
+				diff.Updates[srcKey] = srcInfo.Value
+			}
+			srcOk = src.Next()
+			dstOk = dst.Next()
 		}
+	}
 
-		time.Sleep(cli.RetryInterval)
-		return cli.applyDiffToPrefixWithRetries(prefix, diff, retries-1)
+	if src.Err() != nil {
+		return KeyBytesDiff{}, src.Err()
 	}
 
-	if !resp.Succeeded {
-		return errors.New("Transaction failed")
+	if dst.Err() != nil {
+		return KeyBytesDiff{}, dst.Err()
 	}
 
-	return nil
+	op.setRevision(src.Revision())
+	return diff, nil
 }
 
 /*
@@ -68,21 +113,127 @@ Applies the operation predicated by KeyDiff argument on all the keys prefixed wi
 Note that all the keys referenced in the KeyDiff structure are assumed to be relative keys without the prefix.
 As such, the prefix will be prepended to all the keys in the Keydiff before applying the operations.
 Also note that all the operations in the KeyDiff are applied atomically in a single transaction.
+Returns ErrTransactionFailed if the transaction did not succeed.
 */
 func (cli *EtcdClient) ApplyDiffToPrefix(prefix string, diff KeyDiff) error {
-	return cli.applyDiffToPrefixWithRetries(prefix, diff, cli.Retries)
+	return cli.ApplyDiffToPrefixCtx(cli.Context, prefix, diff)
+}
+
+/*
+Variant of ApplyDiffToPrefix that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) ApplyDiffToPrefixCtx(ctx context.Context, prefix string, diff KeyDiff) (err error) {
+	ctx, op := cli.startOperation(ctx, "ApplyDiffToPrefix", prefix)
+	defer func() { op.end(err) }()
+
+	return applyDiffToPrefix(cli, ctx, op, prefix, diff.Deletions, diff.Inserts, diff.Updates)
+}
+
+/*
+Variant of ApplyDiffToPrefix for binary values.
+*/
+func (cli *EtcdClient) ApplyBytesDiffToPrefix(prefix string, diff KeyBytesDiff) error {
+	return cli.ApplyBytesDiffToPrefixCtx(cli.Context, prefix, diff)
+}
+
+/*
+Variant of ApplyBytesDiffToPrefix that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) ApplyBytesDiffToPrefixCtx(ctx context.Context, prefix string, diff KeyBytesDiff) (err error) {
+	ctx, op := cli.startOperation(ctx, "ApplyBytesDiffToPrefix", prefix)
+	defer func() { op.end(err) }()
+
+	return applyDiffToPrefix(cli, ctx, op, prefix, diff.Deletions, diff.Inserts, diff.Updates)
+}
+
+func applyDiffToPrefix[V string | []byte](cli *EtcdClient, ctx context.Context, op *operation, prefix string, deletions []string, inserts map[string]V, updates map[string]V) error {
+	ops := []clientv3.Op{}
+	written := int64(0)
+
+	for _, key := range deletions {
+		ops = append(ops, clientv3.OpDelete(prefix + key))
+	}
+
+	for key, val := range inserts {
+		ops = append(ops, clientv3.OpPut(prefix + key, string(val)))
+		written += int64(len(prefix) + len(key) + len(val))
+	}
+
+	for key, val := range updates {
+		ops = append(ops, clientv3.OpPut(prefix + key, string(val)))
+		written += int64(len(prefix) + len(key) + len(val))
+	}
+
+	var resp *clientv3.TxnResponse
+	txErr := cli.withRetries(ctx, func() error {
+		ctx, cancel := context.WithTimeout(ctx, cli.RequestTimeout)
+		defer cancel()
+
+		var err error
+		resp, err = cli.Client.Txn(ctx).Then(ops...).Commit()
+		return err
+	})
+	if txErr != nil {
+		return txErr
+	}
+
+	op.setTxnSucceeded(resp.Succeeded)
+	op.setRevision(resp.Header.Revision)
+	if !resp.Succeeded {
+		return ErrTransactionFailed
+	}
+
+	op.addBytes(0, written)
+	return nil
 }
 
 /*
 Delete all the keys that are prefixed by a given value
 */
 func (cli *EtcdClient) DeletePrefix(prefix string) error {
-	return cli.DeleteKeyRange(prefix, clientv3.GetPrefixRangeEnd(prefix))
+	return cli.DeletePrefixCtx(cli.Context, prefix)
+}
+
+/*
+Variant of DeletePrefix that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) DeletePrefixCtx(ctx context.Context, prefix string) (err error) {
+	ctx, op := cli.startOperation(ctx, "DeletePrefix", prefix)
+	defer func() { op.end(err) }()
+
+	return cli.DeleteKeyRangeCtx(ctx, prefix, clientv3.GetPrefixRangeEnd(prefix))
 }
 
 /*
 Get all the keys that are prefixed by a given value
 */
 func (cli *EtcdClient) GetPrefix(prefix string) (KeyRangeInfo, error) {
-	return cli.GetKeyRange(prefix, clientv3.GetPrefixRangeEnd(prefix))
-}
\ No newline at end of file
+	return cli.GetPrefixCtx(cli.Context, prefix)
+}
+
+/*
+Variant of GetPrefix that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) GetPrefixCtx(ctx context.Context, prefix string) (_ KeyRangeInfo, err error) {
+	ctx, op := cli.startOperation(ctx, "GetPrefix", prefix)
+	defer func() { op.end(err) }()
+
+	return cli.GetKeyRangeCtx(ctx, prefix, clientv3.GetPrefixRangeEnd(prefix))
+}
+
+/*
+Variant of GetPrefix for binary values.
+*/
+func (cli *EtcdClient) GetPrefixBytes(prefix string) (KeyRangeBytesInfo, error) {
+	return cli.GetPrefixBytesCtx(cli.Context, prefix)
+}
+
+/*
+Variant of GetPrefixBytes that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) GetPrefixBytesCtx(ctx context.Context, prefix string) (_ KeyRangeBytesInfo, err error) {
+	ctx, op := cli.startOperation(ctx, "GetPrefixBytes", prefix)
+	defer func() { op.end(err) }()
+
+	return cli.GetKeyRangeBytesCtx(ctx, prefix, clientv3.GetPrefixRangeEnd(prefix))
+}
@@ -3,7 +3,6 @@ package client
 import (
 	"context"
 	"strings"
-	"time"
 
 	clientv3 "go.etcd.io/etcd/client/v3"
 )
@@ -29,6 +28,26 @@ func (info *KeyInfoMap) ToValueMap(prefixTrim string) map[string]string {
 	return res
 }
 
+/*
+Variant of the KeyInfoMap type with values as byte slices, for binary values.
+*/
+type KeyBytesInfoMap map[string]KeyBytesInfo
+
+/*
+Flatten a KeyBytesInfoMap structure to a simple map of key/value pairs.
+The method accepts a prefix argument that will be trimmed from the beginning of the keys.
+*/
+func (info *KeyBytesInfoMap) ToValueMap(prefixTrim string) map[string][]byte {
+	res := make(map[string][]byte)
+
+	for key, val := range *info {
+		key := strings.TrimPrefix(key, prefixTrim)
+		res[key] = val.Value
+	}
+
+	return res
+}
+
 /*
 Result from a key range query.
 It returns a KeyInfoMap structure containing the result.
@@ -39,41 +58,27 @@ type KeyRangeInfo struct {
 	Revision int64
 }
 
-func (cli *EtcdClient) getKeyRangeWithRetries(key string, rangeEnd string, retries uint64) (KeyRangeInfo, error) {
-	ctx, cancel := context.WithTimeout(cli.Context, cli.RequestTimeout)
-	defer cancel()
+/*
+Variant of the KeyRangeInfo structure with values as byte slices, for binary values.
+*/
+type KeyRangeBytesInfo struct {
+	Keys KeyBytesInfoMap
+	Revision int64
+}
 
+/*
+Converts the KeyRangeBytesInfo structure to a KeyRangeInfo structure, copying the values to strings.
+*/
+func (info *KeyRangeBytesInfo) ToKeyRangeInfo() KeyRangeInfo {
 	keys := KeyInfoMap(make(map[string]KeyInfo))
-
-	res, err := cli.Client.Get(ctx, key, clientv3.WithRange(rangeEnd))
-	if err != nil {
-		if !shouldRetry(err, retries) {
-			return KeyRangeInfo{
-				Keys: keys, 
-				Revision: -1,
-			}, err
-		}
-
-		time.Sleep(cli.RetryInterval)
-		return cli.getKeyRangeWithRetries(key, rangeEnd, retries-1)
-	}
-
-	for _, kv := range res.Kvs {
-		key, value, createRevision, modRevision, version, lease := string(kv.Key), string(kv.Value), kv.CreateRevision, kv.ModRevision, kv.Version, kv.Lease
-		keys[key] = KeyInfo{
-			Key:            key,
-			Value:          value,
-			Version:        version,
-			CreateRevision: createRevision,
-			ModRevision:    modRevision,
-			Lease:          lease,
-		}
+	for key, val := range info.Keys {
+		keys[key] = val.ToKeyInfo()
 	}
 
 	return KeyRangeInfo{
-		Keys: keys, 
-		Revision: res.Header.Revision,
-	}, nil
+		Keys: keys,
+		Revision: info.Revision,
+	}
 }
 
 /*
@@ -81,24 +86,85 @@ Get all the keys within a certain range of values.
 If you want to get all the keys prefixed by a certain value, consider using the GetPrefix method instead.
 */
 func (cli *EtcdClient) GetKeyRange(key string, rangeEnd string) (KeyRangeInfo, error) {
-	return cli.getKeyRangeWithRetries(key, rangeEnd, cli.Retries)
+	return cli.GetKeyRangeCtx(cli.Context, key, rangeEnd)
+}
+
+/*
+Variant of GetKeyRange that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) GetKeyRangeCtx(ctx context.Context, key string, rangeEnd string) (_ KeyRangeInfo, err error) {
+	ctx, op := cli.startOperation(ctx, "GetKeyRange", key)
+	defer func() { op.end(err) }()
+
+	info, err := cli.GetKeyRangeBytesCtx(ctx, key, rangeEnd)
+	return info.ToKeyRangeInfo(), err
+}
+
+/*
+Variant of GetKeyRange for binary values.
+The values of the result are the ones received from etcd, without copies.
+*/
+func (cli *EtcdClient) GetKeyRangeBytes(key string, rangeEnd string) (KeyRangeBytesInfo, error) {
+	return cli.GetKeyRangeBytesCtx(cli.Context, key, rangeEnd)
+}
+
+/*
+Variant of GetKeyRangeBytes that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) GetKeyRangeBytesCtx(ctx context.Context, key string, rangeEnd string) (_ KeyRangeBytesInfo, err error) {
+	ctx, op := cli.startOperation(ctx, "GetKeyRangeBytes", key)
+	defer func() { op.end(err) }()
+
+	return cli.getKeyRangeBytes(ctx, op, key, rangeEnd, 0)
 }
 
-func (cli *EtcdClient) deleteKeyRangeWithRetries(key string, rangeEnd string, retries uint64) error {
-	ctx, cancel := context.WithTimeout(cli.Context, cli.RequestTimeout)
-	defer cancel()
+/*
+Gets the keys of a range at the given revision, or at the current one if 0.
+*/
+func (cli *EtcdClient) getKeyRangeBytes(ctx context.Context, op *operation, key string, rangeEnd string, revision int64) (KeyRangeBytesInfo, error) {
+	keys := KeyBytesInfoMap(make(map[string]KeyBytesInfo))
+
+	it := cli.newKeyIterator(ctx, "IterateRange", key, rangeEnd, cli.rangeIterateOptions(revision))
+	for it.Next() {
+		info := it.KeyBytes()
+		keys[info.Key] = info
+	}
 
-	_, err := cli.Client.Delete(ctx, key, clientv3.WithRange(rangeEnd))
+	err := it.Err()
 	if err != nil {
-		if !shouldRetry(err, retries) {
-			return err
-		}
+		return KeyRangeBytesInfo{
+			Keys: keys, 
+			Revision: -1,
+		}, err
+	}
+
+	op.setRevision(it.Revision())
+	return KeyRangeBytesInfo{
+		Keys: keys, 
+		Revision: it.Revision(),
+	}, nil
+}
 
-		time.Sleep(cli.RetryInterval)
-		return cli.deleteKeyRangeWithRetries(key, rangeEnd, retries-1)
+/*
+Returns the page size used to get ranges, which is negative to get them in a single request.
+*/
+func (cli *EtcdClient) rangePageSize() int64 {
+	if cli.PageSize > 0 {
+		return cli.PageSize
 	}
 
-	return nil
+	return -1
+}
+
+/*
+Returns the options of the iterators used to get whole ranges at the given revision, which follow the client's read options.
+*/
+func (cli *EtcdClient) rangeIterateOptions(revision int64) IterateOptions {
+	return IterateOptions{
+		PageSize:     cli.rangePageSize(),
+		Revision:     revision,
+		Serializable: cli.SerializableReads,
+	}
 }
 
 /*
@@ -106,5 +172,21 @@ Delete all the keys within a certain range of values.
 If you want to delete all the keys prefixed by a certain value, consider using the DeletePrefix method instead.
 */
 func (cli *EtcdClient) DeleteKeyRange(key string, rangeEnd string) error {
-	return cli.deleteKeyRangeWithRetries(key, rangeEnd, cli.Retries)
+	return cli.DeleteKeyRangeCtx(cli.Context, key, rangeEnd)
+}
+
+/*
+Variant of DeleteKeyRange that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) DeleteKeyRangeCtx(ctx context.Context, key string, rangeEnd string) (err error) {
+	ctx, op := cli.startOperation(ctx, "DeleteKeyRange", key)
+	defer func() { op.end(err) }()
+
+	return cli.withRetries(ctx, func() error {
+		ctx, cancel := context.WithTimeout(ctx, cli.RequestTimeout)
+		defer cancel()
+
+		_, err := cli.Client.Delete(ctx, key, clientv3.WithRange(rangeEnd))
+		return err
+	})
 }
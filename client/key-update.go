@@ -0,0 +1,192 @@
+package client
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+const DefaultUpdateAttempts = uint64(10)
+
+/*
+Function computing the new value of a key from its current value.
+The KeyInfo argument is not found if the key does not exist. If the delete return value is true, the key is deleted instead.
+The function can be called several times if the key is modified concurrently and should not have side effects.
+*/
+type KeyUpdateFn func(old KeyInfo) (newValue string, delete bool, err error)
+
+/*
+Function computing the changes to make on a set of keys from their current values.
+The KeyInfoMap argument only contains the keys that exist. The puts return value contains the keys to upsert with their new values.
+The function can be called several times if the keys are modified concurrently and should not have side effects.
+*/
+type KeysUpdateFn func(old KeyInfoMap) (puts map[string]string, deletions []string, err error)
+
+/*
+Options that get passed to the UpdateKey and UpdateKeys methods.
+*/
+type UpdateKeyOptions struct {
+	//Maximum number of times the update is attempted when the keys are modified concurrently. Defaults to DefaultUpdateAttempts if 0.
+	MaxAttempts      uint64
+	//Interval of time to wait before attempting the update again after a conflict
+	ConflictInterval time.Duration
+}
+
+/*
+Updates a key based on its current value in a read-modify-write cycle.
+The key is read, its new value is computed by the function and it is written back with a transaction that only succeeds if the key was not modified in the meantime.
+If the key was modified, the cycle is repeated up to the maximum number of attempts, after which an error matching ErrUpdateConflict is returned.
+Errors returned by the function abort the update and are returned as is. Errors reaching the etcd cluster are retried like for the other methods, except for the write which is only retried on errors that guarantee it was not applied.
+Returns the revision of the etcd store after the update.
+*/
+func (cli *EtcdClient) UpdateKey(key string, fn KeyUpdateFn, opts UpdateKeyOptions) (int64, error) {
+	return cli.UpdateKeyCtx(cli.Context, key, fn, opts)
+}
+
+/*
+Variant of UpdateKey that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) UpdateKeyCtx(ctx context.Context, key string, fn KeyUpdateFn, opts UpdateKeyOptions) (_ int64, err error) {
+	ctx, op := cli.startOperation(ctx, "UpdateKey", key)
+	defer func() { op.end(err) }()
+
+	return cli.updateKeys(ctx, op, []string{key}, func(old KeyInfoMap) (map[string]string, []string, error) {
+		newValue, del, err := fn(old[key])
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if del {
+			return nil, []string{key}, nil
+		}
+
+		return map[string]string{key: newValue}, nil, nil
+	}, opts)
+}
+
+/*
+Variant of UpdateKey that updates several keys atomically based on their current values.
+The keys are read at the same revision and the changes are written back with a transaction that only succeeds if none of the keys were modified in the meantime.
+Note that only the keys that were read are guarded: changes returned by the function on other keys are applied unconditionally.
+*/
+func (cli *EtcdClient) UpdateKeys(keys []string, fn KeysUpdateFn, opts UpdateKeyOptions) (int64, error) {
+	return cli.UpdateKeysCtx(cli.Context, keys, fn, opts)
+}
+
+/*
+Variant of UpdateKeys that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) UpdateKeysCtx(ctx context.Context, keys []string, fn KeysUpdateFn, opts UpdateKeyOptions) (_ int64, err error) {
+	ctx, op := cli.startOperation(ctx, "UpdateKeys", strings.Join(keys, ","))
+	defer func() { op.end(err) }()
+
+	return cli.updateKeys(ctx, op, keys, fn, opts)
+}
+
+/*
+Reads the given keys at a single revision.
+*/
+func (cli *EtcdClient) readKeys(ctx context.Context, keys []string) (KeyInfoMap, error) {
+	op := getOperation(ctx)
+
+	gets := []clientv3.Op{}
+	for _, key := range keys {
+		gets = append(gets, clientv3.OpGet(key))
+	}
+
+	var resp *clientv3.TxnResponse
+	err := cli.withRetries(ctx, func() error {
+		ctx, cancel := context.WithTimeout(ctx, cli.RequestTimeout)
+		defer cancel()
+
+		var err error
+		resp, err = cli.Client.Txn(ctx).Then(gets...).Commit()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	infos := KeyInfoMap(make(map[string]KeyInfo))
+	for _, res := range resp.Responses {
+		for _, kv := range res.GetResponseRange().Kvs {
+			op.addBytes(int64(len(kv.Key) + len(kv.Value)), 0)
+			info := newKeyBytesInfo(kv)
+			infos[info.Key] = info.ToKeyInfo()
+		}
+	}
+
+	return infos, nil
+}
+
+func (cli *EtcdClient) updateKeys(ctx context.Context, op *operation, keys []string, fn KeysUpdateFn, opts UpdateKeyOptions) (int64, error) {
+	maxAttempts := opts.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = DefaultUpdateAttempts
+	}
+
+	//The write is not idempotent, so it is only retried on errors that guarantee it was not applied
+	writeCli := cli.SetRetryPolicy(unappliedRetryPolicy{cli.getRetryPolicy()})
+
+	for attempt := uint64(1); ; attempt++ {
+		old, err := cli.readKeys(ctx, keys)
+		if err != nil {
+			return -1, err
+		}
+
+		puts, deletions, err := fn(old)
+		if err != nil {
+			return -1, err
+		}
+
+		//A key that does not exist has a modification revision of 0 in comparisons
+		guards := []clientv3.Cmp{}
+		for _, key := range keys {
+			guards = append(guards, clientv3.Compare(clientv3.ModRevision(key), "=", old[key].ModRevision))
+		}
+
+		ops := []clientv3.Op{}
+		written := int64(0)
+		for _, key := range deletions {
+			ops = append(ops, clientv3.OpDelete(key))
+		}
+		for key, val := range puts {
+			ops = append(ops, clientv3.OpPut(key, val))
+			written += int64(len(key) + len(val))
+		}
+
+		var resp *clientv3.TxnResponse
+		err = writeCli.withRetries(ctx, func() error {
+			ctx, cancel := context.WithTimeout(ctx, writeCli.RequestTimeout)
+			defer cancel()
+
+			var err error
+			resp, err = writeCli.Client.Txn(ctx).If(guards...).Then(ops...).Commit()
+			return err
+		})
+		if err != nil {
+			return -1, err
+		}
+
+		op.setTxnSucceeded(resp.Succeeded)
+		if resp.Succeeded {
+			op.setRevision(resp.Header.Revision)
+			op.addBytes(0, written)
+			return resp.Header.Revision, nil
+		}
+
+		if attempt >= maxAttempts {
+			return -1, &KeyError{Key: strings.Join(keys, ","), Kind: ErrUpdateConflict}
+		}
+
+		op.span.AddEvent("conflict", trace.WithAttributes(attribute.Int64("etcd.attempt", int64(attempt))))
+		waitErr := sleepCtx(ctx, opts.ConflictInterval)
+		if waitErr != nil {
+			return -1, waitErr
+		}
+	}
+}
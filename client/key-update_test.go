@@ -0,0 +1,205 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Ferlab-Ste-Justine/etcd-sdk/testutils"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUpdateKey(t *testing.T) {
+	tearDown, launchErr := testutils.LaunchTestEtcdCluster("../test", testutils.EtcdTestClusterOpts{})
+	if launchErr != nil {
+		t.Errorf("Error occured launching test etcd cluster: %s", launchErr.Error())
+		return
+	}
+
+	defer func() {
+		errs := tearDown()
+		if len(errs) > 0 {
+			t.Errorf("Errors occured tearing down etcd cluster: %s", errs[0].Error())
+		}
+	}()
+
+	retryInterval, _ := time.ParseDuration("1s")
+	timeouts, _ := time.ParseDuration("10s")
+	retries := uint64(10)
+	cli := setupTestEnv(t, timeouts, retryInterval, retries)
+
+	increment := func(old KeyInfo) (string, bool, error) {
+		if !old.Found() {
+			return "1", false, nil
+		}
+
+		count, err := strconv.Atoi(old.Value)
+		return strconv.Itoa(count + 1), false, err
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				_, err := cli.UpdateKey("counter", increment, UpdateKeyOptions{MaxAttempts: 100})
+				if err != nil {
+					t.Errorf("Error occured incrementing counter: %s", err.Error())
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	info, _ := cli.GetKey("counter", GetKeyOptions{})
+	if info.Value != "50" {
+		t.Errorf("Expected concurrent updates not to overwrite each other and got a counter of %s instead of 50", info.Value)
+	}
+
+	calls := 0
+	_, err := cli.UpdateKey("counter", func(old KeyInfo) (string, bool, error) {
+		calls++
+		cli.PutKey("counter", "interference")
+		return "updated", false, nil
+	}, UpdateKeyOptions{MaxAttempts: 3})
+	if !errors.Is(err, ErrUpdateConflict) || calls != 3 {
+		t.Errorf("Expected an update conflicting on each attempt to fail with a conflict error after 3 attempts and got %v after %d attempts", err, calls)
+	}
+
+	fnErr := errors.New("Aborted")
+	_, err = cli.UpdateKey("counter", func(old KeyInfo) (string, bool, error) {
+		return "", false, fnErr
+	}, UpdateKeyOptions{})
+	if !errors.Is(err, fnErr) || errors.Is(err, ErrUpdateConflict) {
+		t.Errorf("Expected the error of the update function to be returned as is and got: %v", err)
+	}
+
+	_, err = cli.UpdateKey("counter", func(old KeyInfo) (string, bool, error) {
+		return "", true, nil
+	}, UpdateKeyOptions{})
+	info, _ = cli.GetKey("counter", GetKeyOptions{})
+	if err != nil || info.Found() {
+		t.Errorf("Expected update to delete the key and it didn't")
+	}
+
+	cli.PutKey("account/a", "10")
+	cli.PutKey("account/b", "0")
+	_, err = cli.UpdateKeys([]string{"account/a", "account/b", "account/c"}, func(old KeyInfoMap) (map[string]string, []string, error) {
+		if _, ok := old["account/c"]; ok {
+			return nil, nil, errors.New("Unexpected key")
+		}
+
+		a, _ := strconv.Atoi(old["account/a"].Value)
+		b, _ := strconv.Atoi(old["account/b"].Value)
+		return map[string]string{"account/a": strconv.Itoa(a - 5), "account/b": strconv.Itoa(b + 5)}, nil, nil
+	}, UpdateKeyOptions{})
+	if err != nil {
+		t.Errorf("Error occured updating several keys: %s", err.Error())
+	}
+
+	accounts, _ := cli.GetPrefix("account/")
+	values := accounts.Keys.ToValueMap("account/")
+	if values["a"] != "5" || values["b"] != "5" {
+		t.Errorf("Expected multi-key update to be applied on both keys and got: %v", values)
+	}
+}
+
+/*
+KV that applies writing transactions but reports an error that does not tell whether they were applied, as when the connection drops before the response arrives.
+If key is set, only the transactions writing it fail.
+*/
+type ambiguousKV struct {
+	clientv3.KV
+	failures int
+	key      string
+}
+
+func (kv *ambiguousKV) Txn(ctx context.Context) clientv3.Txn {
+	return &ambiguousTxn{Txn: kv.KV.Txn(ctx), kv: kv}
+}
+
+type ambiguousTxn struct {
+	clientv3.Txn
+	kv     *ambiguousKV
+	writes bool
+}
+
+func (txn *ambiguousTxn) If(cmps ...clientv3.Cmp) clientv3.Txn {
+	txn.Txn = txn.Txn.If(cmps...)
+	return txn
+}
+
+func (txn *ambiguousTxn) Then(ops ...clientv3.Op) clientv3.Txn {
+	for _, op := range ops {
+		txn.writes = txn.writes || ((op.IsPut() || op.IsDelete()) && (txn.kv.key == "" || string(op.KeyBytes()) == txn.kv.key))
+	}
+
+	txn.Txn = txn.Txn.Then(ops...)
+	return txn
+}
+
+func (txn *ambiguousTxn) Else(ops ...clientv3.Op) clientv3.Txn {
+	txn.Txn = txn.Txn.Else(ops...)
+	return txn
+}
+
+func (txn *ambiguousTxn) Commit() (*clientv3.TxnResponse, error) {
+	res, err := txn.Txn.Commit()
+	if err != nil || txn.kv.failures == 0 || (!txn.writes) || (!res.Succeeded) {
+		return res, err
+	}
+
+	txn.kv.failures--
+	return nil, status.Error(codes.Unavailable, "transport is closing")
+}
+
+func TestUpdateKeyAmbiguousError(t *testing.T) {
+	tearDown, launchErr := testutils.LaunchTestEtcdCluster("../test", testutils.EtcdTestClusterOpts{})
+	if launchErr != nil {
+		t.Errorf("Error occured launching test etcd cluster: %s", launchErr.Error())
+		return
+	}
+
+	defer func() {
+		errs := tearDown()
+		if len(errs) > 0 {
+			t.Errorf("Errors occured tearing down etcd cluster: %s", errs[0].Error())
+		}
+	}()
+
+	retryInterval, _ := time.ParseDuration("1s")
+	timeouts, _ := time.ParseDuration("10s")
+	retries := uint64(10)
+	cli := setupTestEnv(t, timeouts, retryInterval, retries)
+
+	_, err := cli.PutKey("counter", "1")
+	if err != nil {
+		t.Errorf("Error occured putting key: %s", err.Error())
+		return
+	}
+
+	kv := cli.Client.KV
+	cli.Client.KV = &ambiguousKV{KV: kv, failures: 1}
+	defer func() { cli.Client.KV = kv }()
+
+	calls := 0
+	_, err = cli.UpdateKey("counter", func(old KeyInfo) (string, bool, error) {
+		calls++
+		count, err := strconv.Atoi(old.Value)
+		return strconv.Itoa(count + 1), false, err
+	}, UpdateKeyOptions{})
+	if err == nil || calls != 1 {
+		t.Errorf("Expected an ambiguous error on the write to be returned without retrying the update and got %v after %d attempts", err, calls)
+	}
+
+	info, _ := cli.GetKey("counter", GetKeyOptions{})
+	if info.Value != "2" {
+		t.Errorf("Expected the update to be applied once and got a counter of %s instead of 2", info.Value)
+	}
+}
@@ -2,9 +2,9 @@ package client
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"go.etcd.io/etcd/api/v3/mvccpb"
 	clientv3 "go.etcd.io/etcd/client/v3"
@@ -55,6 +55,72 @@ type WatchNotification struct {
 	Error   error
 }
 
+/*
+Variant of the WatchKeyInfo structure with the value as a byte slice, for binary values.
+*/
+type WatchKeyBytesInfo struct {
+	Value          []byte
+	Version        int64
+	CreateRevision int64
+	ModRevision    int64
+	Lease          int64
+}
+
+/*
+Variant of the WatchInfo structure with values as byte slices, for binary values.
+*/
+type WatchBytesInfo struct {
+	//Inserted or updated keys. The map keys are the keys that were upserted.
+	Upserts   map[string]WatchKeyBytesInfo
+	//List of keys that were deleted
+	Deletions []string
+}
+
+/*
+Apply the changes of a WatchBytesInfo structure to a map of byte slices
+*/
+func (info *WatchBytesInfo) ApplyOn(dest map[string][]byte) {
+	for key, val := range info.Upserts {
+		dest[key] = val.Value
+	}
+
+	for _, key := range info.Deletions {
+		delete(dest, key)
+	}
+}
+
+/*
+Converts the WatchBytesInfo structure to a WatchInfo structure, copying the values to strings.
+*/
+func (info *WatchBytesInfo) ToWatchInfo() WatchInfo {
+	res := WatchInfo{
+		Upserts:   make(map[string]WatchKeyInfo),
+		Deletions: info.Deletions,
+	}
+
+	for key, val := range info.Upserts {
+		res.Upserts[key] = WatchKeyInfo{
+			Value: string(val.Value),
+			Version: val.Version,
+			CreateRevision: val.CreateRevision,
+			ModRevision: val.ModRevision,
+			Lease: val.Lease,
+		}
+	}
+
+	return res
+}
+
+/*
+Variant of the WatchNotification structure with values as byte slices, for binary values.
+*/
+type WatchBytesNotification struct {
+	//Changes that are reported if it is not an error
+	Changes WatchBytesInfo
+	//Error that is reported if it is an error
+	Error   error
+}
+
 /*
 Options for the watch method
 */
@@ -69,69 +135,137 @@ type WatchOptions struct {
 
 /*
 Watch the keys of a given prefix for changes and returns a channel that notifies of any changes
+Errors reported in the notifications match ErrWatchFailed and wrap the underlying etcd error. They are also reported to the OnError hook.
 */
 func (cli *EtcdClient) Watch(wKey string, opts WatchOptions) <-chan WatchNotification {
+	return cli.WatchCtx(cli.Context, wKey, opts)
+}
+
+/*
+Variant of Watch that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) WatchCtx(ctx context.Context, wKey string, opts WatchOptions) <-chan WatchNotification {
 	outChan := make(chan WatchNotification)
 
 	go func() {
-		ctx, cancel := context.WithCancel(cli.Context)
-		defer cancel()
 		defer close(outChan)
+		cli.watch(ctx, "Watch", wKey, opts, func(notif WatchBytesNotification) {
+			if notif.Error != nil {
+				outChan <- WatchNotification{Error: notif.Error}
+				return
+			}
 
-		watchOpts := []clientv3.OpOption{}
-		if opts.IsPrefix {
-			watchOpts = append(watchOpts, clientv3.WithPrefix())
-		}
+			outChan <- WatchNotification{Changes: notif.Changes.ToWatchInfo()}
+		})
+	}()
 
-		if opts.Revision > 0 {
-			watchOpts = append(watchOpts, clientv3.WithRev(opts.Revision))
-		}
+	return outChan
+}
+
+/*
+Variant of Watch for binary values.
+The values of the reported changes are the ones received from etcd, without copies.
+*/
+func (cli *EtcdClient) WatchBytes(wKey string, opts WatchOptions) <-chan WatchBytesNotification {
+	return cli.WatchBytesCtx(cli.Context, wKey, opts)
+}
+
+/*
+Variant of WatchBytes that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) WatchBytesCtx(ctx context.Context, wKey string, opts WatchOptions) <-chan WatchBytesNotification {
+	outChan := make(chan WatchBytesNotification)
+
+	go func() {
+		defer close(outChan)
+		cli.watch(ctx, "WatchBytes", wKey, opts, func(notif WatchBytesNotification) {
+			outChan <- notif
+		})
+	}()
+
+	return outChan
+}
+
+/*
+Watches for changes and passes them to the notify function until the watch fails or the context is cancelled.
+*/
+func (cli *EtcdClient) watch(ctx context.Context, name string, wKey string, opts WatchOptions, notify func(WatchBytesNotification)) {
+	var err error
+	ctx, op := cli.startOperation(ctx, name, wKey)
+	defer func() { op.end(err) }()
+
+	if cli.Metrics != nil {
+		cli.Metrics.AddWatches(1)
+		defer cli.Metrics.AddWatches(-1)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	watchOpts := []clientv3.OpOption{}
+	if opts.IsPrefix {
+		watchOpts = append(watchOpts, clientv3.WithPrefix())
+	}
+
+	if opts.Revision > 0 {
+		watchOpts = append(watchOpts, clientv3.WithRev(opts.Revision))
+	}
 
-		wc := cli.Client.Watch(ctx, wKey, watchOpts...)
-		if wc == nil {
-			outChan <- WatchNotification{Error: errors.New("Failed to watch changes: Watcher could not be established")}
+	start := time.Now()
+	wc := cli.Client.Watch(ctx, wKey, watchOpts...)
+	if wc == nil {
+		err = fmt.Errorf("%w: Watcher could not be established", ErrWatchFailed)
+		cli.onError(ctx, AttemptInfo{Operation: op.Name, Key: op.Key, Attempt: 1, Error: err, Elapsed: time.Since(start)})
+		notify(WatchBytesNotification{Error: err})
+		return
+	}
+
+	for res := range wc {
+		err = res.Err()
+		if err != nil {
+			err = fmt.Errorf("%w: %w", ErrWatchFailed, err)
+			cli.onError(ctx, AttemptInfo{Operation: op.Name, Key: op.Key, Attempt: 1, Error: err, Elapsed: time.Since(start)})
+			notify(WatchBytesNotification{Error: err})
 			return
 		}
 
-		for res := range wc {
-			err := res.Err()
+		output := WatchBytesNotification{
+			Error: nil,
+			Changes: WatchBytesInfo{
+				Upserts:   make(map[string]WatchKeyBytesInfo),
+				Deletions: []string{},
+			},
+		}
+
+		for _, ev := range res.Events {
+			op.addBytes(int64(len(ev.Kv.Key) + len(ev.Kv.Value)), 0)
+			err = cli.decodeEvent(ev)
 			if err != nil {
-				outChan <- WatchNotification{Error: errors.New(fmt.Sprintf("Failed to watch changes: %s", err.Error()))}
+				cli.onError(ctx, AttemptInfo{Operation: op.Name, Key: op.Key, Attempt: 1, Error: err, Elapsed: time.Since(start)})
+				notify(WatchBytesNotification{Error: err})
 				return
 			}
 
-			output := WatchNotification{
-				Error: nil,
-				Changes: WatchInfo{
-					Upserts:   make(map[string]WatchKeyInfo),
-					Deletions: []string{},
-				},
+			key := string(ev.Kv.Key)
+			if opts.TrimPrefix {
+				key = strings.TrimPrefix(key, wKey)
 			}
-
-			for _, ev := range res.Events {
-				key := string(ev.Kv.Key)
-				if opts.TrimPrefix {
-					key = strings.TrimPrefix(key, wKey)
-				}
-				if ev.Type == mvccpb.DELETE {
-					output.Changes.Deletions = append(
-						output.Changes.Deletions, 
-						key,
-					)
-				} else if ev.Type == mvccpb.PUT {
-					output.Changes.Upserts[key] = WatchKeyInfo{
-						Value: string(ev.Kv.Value),
-						Version: ev.Kv.Version,
-						CreateRevision: ev.Kv.CreateRevision,
-						ModRevision: ev.Kv.ModRevision,
-						Lease: ev.Kv.Lease,
-					}
+			if ev.Type == mvccpb.DELETE {
+				output.Changes.Deletions = append(
+					output.Changes.Deletions, 
+					key,
+				)
+			} else if ev.Type == mvccpb.PUT {
+				output.Changes.Upserts[key] = WatchKeyBytesInfo{
+					Value: ev.Kv.Value,
+					Version: ev.Kv.Version,
+					CreateRevision: ev.Kv.CreateRevision,
+					ModRevision: ev.Kv.ModRevision,
+					Lease: ev.Kv.Lease,
 				}
 			}
-
-			outChan <- output
 		}
-	}()
 
-	return outChan
-}
\ No newline at end of file
+		notify(output)
+	}
+}
@@ -2,8 +2,8 @@ package client
 
 import (
 	"context"
-	"time"
 
+	"go.etcd.io/etcd/api/v3/mvccpb"
 	clientv3 "go.etcd.io/etcd/client/v3"
 )
 
@@ -33,20 +33,54 @@ func (info *KeyInfo) Found() bool {
 	return info.CreateRevision > 0
 }
 
-func (cli *EtcdClient) putKeyWithRetries(key string, val string, retries uint64) (int64, error) {
-	ctx, cancel := context.WithTimeout(cli.Context, cli.RequestTimeout)
-	defer cancel()
+/*
+Variant of the KeyInfo structure with the value as a byte slice, for binary values.
+*/
+type KeyBytesInfo struct {
+	//Key
+	Key            string
+	//Value stored at the key
+	Value          []byte
+	//Etcd version of the key, which is incremented when a key changes and reset to 0 when it is deleted
+	Version        int64
+	//Revision of the etcd store when the key was created
+	CreateRevision int64
+	//Revision of the etcd store when the key was last modified
+	ModRevision    int64
+	//Id of the lease that created the key if the key was created with a lease
+	Lease          int64
+}
 
-	resp, err := cli.Client.Put(ctx, key, val)
-	if err != nil {
-		if !shouldRetry(err, retries) {
-			return 0, err
-		}
+/*
+Returns whether the KeyBytesInfo structure stores a key that was found.
+*/
+func (info *KeyBytesInfo) Found() bool {
+	return info.CreateRevision > 0
+}
 
-		time.Sleep(cli.RetryInterval)
-		return cli.putKeyWithRetries(key, val, retries-1)
+/*
+Converts the KeyBytesInfo structure to a KeyInfo structure, copying the value to a string.
+*/
+func (info *KeyBytesInfo) ToKeyInfo() KeyInfo {
+	return KeyInfo{
+		Key:            info.Key,
+		Value:          string(info.Value),
+		Version:        info.Version,
+		CreateRevision: info.CreateRevision,
+		ModRevision:    info.ModRevision,
+		Lease:          info.Lease,
+	}
+}
+
+func newKeyBytesInfo(kv *mvccpb.KeyValue) KeyBytesInfo {
+	return KeyBytesInfo{
+		Key:            string(kv.Key),
+		Value:          kv.Value,
+		Version:        kv.Version,
+		CreateRevision: kv.CreateRevision,
+		ModRevision:    kv.ModRevision,
+		Lease:          kv.Lease,
 	}
-	return resp.Header.Revision, nil
 }
 
 /*
@@ -54,43 +88,53 @@ Upsert the given value in the key.
 Returns the revision of the store right after the key was upserted.
 */
 func (cli *EtcdClient) PutKey(key string, val string) (int64, error) {
-	return cli.putKeyWithRetries(key, val, cli.Retries)
+	return cli.PutKeyCtx(cli.Context, key, val)
 }
 
-func (cli *EtcdClient) getKeyWithRetries(key string, revision int64, retries uint64) (KeyInfo, error) {
-	ctx, cancel := context.WithTimeout(cli.Context, cli.RequestTimeout)
-	defer cancel()
-
-	var err error
-	var getRes *clientv3.GetResponse
+/*
+Variant of PutKey that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) PutKeyCtx(ctx context.Context, key string, val string) (_ int64, err error) {
+	ctx, op := cli.startOperation(ctx, "PutKey", key)
+	defer func() { op.end(err) }()
 
-	if revision <= 0 {
-		getRes, err = cli.Client.Get(ctx, key)
-	} else {
-		getRes, err = cli.Client.Get(ctx, key, clientv3.WithRev(revision))
-	}
+	var revision int64
+	err = cli.withRetries(ctx, func() error {
+		ctx, cancel := context.WithTimeout(ctx, cli.RequestTimeout)
+		defer cancel()
 
-	if err != nil {
-		if !shouldRetry(err, retries) {
-			return KeyInfo{}, err
+		resp, err := cli.Client.Put(ctx, key, val)
+		if err != nil {
+			return err
 		}
 
-		time.Sleep(cli.RetryInterval)
-		return cli.getKeyWithRetries(key, revision, retries-1)
+		revision = resp.Header.Revision
+		return nil
+	})
+	if err == nil {
+		op.addBytes(0, int64(len(key) + len(val)))
+		op.setRevision(revision)
 	}
 
-	if len(getRes.Kvs) == 0 {
-		return KeyInfo{}, nil
-	}
+	return revision, err
+}
 
-	return KeyInfo{
-		Key:            key,
-		Value:          string(getRes.Kvs[0].Value),
-		Version:        getRes.Kvs[0].Version,
-		CreateRevision: getRes.Kvs[0].CreateRevision,
-		ModRevision:    getRes.Kvs[0].ModRevision,
-		Lease:          getRes.Kvs[0].Lease,
-	}, nil
+/*
+Variant of PutKey for binary values.
+*/
+func (cli *EtcdClient) PutKeyBytes(key string, val []byte) (int64, error) {
+	return cli.PutKeyBytesCtx(cli.Context, key, val)
+}
+
+/*
+Variant of PutKeyBytes that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) PutKeyBytesCtx(ctx context.Context, key string, val []byte) (_ int64, err error) {
+	ctx, op := cli.startOperation(ctx, "PutKeyBytes", key)
+	defer func() { op.end(err) }()
+
+	//The etcd client takes the value as a string, so a single conversion is made here
+	return cli.PutKeyCtx(ctx, key, string(val))
 }
 
 /*
@@ -99,36 +143,100 @@ Options that get passed to GetKey method.
 type GetKeyOptions struct {
 	//Specifies that the value of the key at a given store revision is wanted.
 	//Can be left at the default 0 value if the latest version of the key is desired.
-	Revision int64
+	Revision     int64
+	//If true, the key is read from the member the request is sent to without going through the leader.
+	//The read has a lower latency, but can return stale data.
+	Serializable bool
 }
 
 /*
 Get information on the given key including the value.
 */
 func (cli *EtcdClient) GetKey(key string, opts GetKeyOptions) (KeyInfo, error) {
-	return cli.getKeyWithRetries(key, opts.Revision, cli.Retries)
+	return cli.GetKeyCtx(cli.Context, key, opts)
 }
 
-func (cli *EtcdClient) deleteKeyWithRetries(key string, retries uint64) error {
-	ctx, cancel := context.WithTimeout(cli.Context, cli.RequestTimeout)
-	defer cancel()
+/*
+Variant of GetKey that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) GetKeyCtx(ctx context.Context, key string, opts GetKeyOptions) (_ KeyInfo, err error) {
+	ctx, op := cli.startOperation(ctx, "GetKey", key)
+	defer func() { op.end(err) }()
+
+	info, err := cli.GetKeyBytesCtx(ctx, key, opts)
+	if err != nil || (!info.Found()) {
+		return KeyInfo{}, err
+	}
+
+	return info.ToKeyInfo(), nil
+}
+
+/*
+Variant of GetKey for binary values.
+The value of the result is the one received from etcd, without copies.
+*/
+func (cli *EtcdClient) GetKeyBytes(key string, opts GetKeyOptions) (KeyBytesInfo, error) {
+	return cli.GetKeyBytesCtx(cli.Context, key, opts)
+}
+
+/*
+Variant of GetKeyBytes that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) GetKeyBytesCtx(ctx context.Context, key string, opts GetKeyOptions) (_ KeyBytesInfo, err error) {
+	ctx, op := cli.startOperation(ctx, "GetKeyBytes", key)
+	defer func() { op.end(err) }()
+
+	getOpts := []clientv3.OpOption{}
+	if opts.Revision > 0 {
+		getOpts = append(getOpts, clientv3.WithRev(opts.Revision))
+	}
+	if opts.Serializable || cli.SerializableReads {
+		getOpts = append(getOpts, clientv3.WithSerializable())
+	}
+
+	var getRes *clientv3.GetResponse
+	err = cli.withRetries(ctx, func() error {
+		ctx, cancel := context.WithTimeout(ctx, cli.RequestTimeout)
+		defer cancel()
+
+		var err error
+		getRes, err = cli.Client.Get(ctx, key, getOpts...)
+		return err
+	})
 
-	_, err := cli.Client.Delete(ctx, key)
 	if err != nil {
-		if !shouldRetry(err, retries) {
-			return err
-		}
+		return KeyBytesInfo{}, err
+	}
 
-		time.Sleep(cli.RetryInterval)
-		return cli.deleteKeyWithRetries(key, retries-1)
+	op.setRevision(getRes.Header.Revision)
+	if len(getRes.Kvs) == 0 {
+		return KeyBytesInfo{}, nil
 	}
 
-	return nil
+	op.addBytes(int64(len(getRes.Kvs[0].Key) + len(getRes.Kvs[0].Value)), 0)
+
+	return newKeyBytesInfo(getRes.Kvs[0]), nil
 }
 
 /*
 Delete a key.
 */
 func (cli *EtcdClient) DeleteKey(key string) error {
-	return cli.deleteKeyWithRetries(key, cli.Retries)
+	return cli.DeleteKeyCtx(cli.Context, key)
+}
+
+/*
+Variant of DeleteKey that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) DeleteKeyCtx(ctx context.Context, key string) (err error) {
+	ctx, op := cli.startOperation(ctx, "DeleteKey", key)
+	defer func() { op.end(err) }()
+
+	return cli.withRetries(ctx, func() error {
+		ctx, cancel := context.WithTimeout(ctx, cli.RequestTimeout)
+		defer cancel()
+
+		_, err := cli.Client.Delete(ctx, key)
+		return err
+	})
 }
@@ -1,6 +1,8 @@
 package client
 
 import (
+	"bytes"
+	"context"
 	"sync"
 	"testing"
 	"time"
@@ -196,4 +198,79 @@ func TestDeleteKey(t *testing.T) {
 
 	close(done)
 	wg.Wait()
-}
\ No newline at end of file
+}
+func TestBinaryValues(t *testing.T) {
+	tearDown, launchErr := testutils.LaunchTestEtcdCluster("../test", testutils.EtcdTestClusterOpts{})
+	if launchErr != nil {
+		t.Errorf("Error occured launching test etcd cluster: %s", launchErr.Error())
+		return
+	}
+
+	defer func() {
+		errs := tearDown()
+		if len(errs) > 0 {
+			t.Errorf("Errors occured tearing down etcd cluster: %s", errs[0].Error())
+		}
+	}()
+
+	retryInterval, _ := time.ParseDuration("1s")
+	timeouts, _ := time.ParseDuration("10s")
+	retries := uint64(10)
+	cli := setupTestEnv(t, timeouts, retryInterval, retries)
+
+	value := []byte{0x00, 0xff, 0xfe, 0x80, 0x00}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watch := cli.WatchBytesCtx(ctx, "/bin/", WatchOptions{IsPrefix: true, TrimPrefix: true})
+
+	rev, err := cli.PutKeyBytes("/bin/a", value)
+	if err != nil {
+		t.Errorf("Error occured setting binary key value: %s", err.Error())
+		return
+	}
+
+	info, err := cli.GetKeyBytes("/bin/a", GetKeyOptions{})
+	if err != nil {
+		t.Errorf("Error occured getting binary key value: %s", err.Error())
+		return
+	}
+	if !info.Found() || !bytes.Equal(info.Value, value) || info.ModRevision != rev {
+		t.Errorf("Expected binary value to be read back identically and got: %v", info.Value)
+	}
+
+	notif := <-watch
+	if notif.Error != nil || !bytes.Equal(notif.Changes.Upserts["a"].Value, value) {
+		t.Errorf("Expected binary value to be reported identically by the watch and got: %v", notif)
+	}
+
+	err = cli.ApplyBytesDiffToPrefix("/bin/", KeyBytesDiff{Inserts: map[string][]byte{"b": {0xff}}, Updates: map[string][]byte{"a": {0x01}}})
+	if err != nil {
+		t.Errorf("Error occured applying binary key diff: %s", err.Error())
+		return
+	}
+
+	prefix, err := cli.GetPrefixBytes("/bin/")
+	if err != nil {
+		t.Errorf("Error occured getting binary prefix: %s", err.Error())
+		return
+	}
+	values := prefix.Keys.ToValueMap("/bin/")
+	if len(values) != 2 || !bytes.Equal(values["a"], []byte{0x01}) || !bytes.Equal(values["b"], []byte{0xff}) {
+		t.Errorf("Expected binary prefix to contain the values of the applied diff and got: %v", values)
+	}
+
+	diff, err := cli.DiffBetweenPrefixesBytes("/other/", "/bin/")
+	if err != nil {
+		t.Errorf("Error occured diffing binary prefixes: %s", err.Error())
+		return
+	}
+	if len(diff.Deletions) != 2 {
+		t.Errorf("Expected diff with an empty prefix to delete both binary keys and got: %v", diff)
+	}
+
+	notif = <-watch
+	if notif.Error != nil || len(notif.Changes.Upserts) != 2 || !bytes.Equal(notif.Changes.Upserts["b"].Value, []byte{0xff}) {
+		t.Errorf("Expected binary diff to be reported by the watch and got: %v", notif)
+	}
+}
@@ -0,0 +1,392 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.uber.org/zap"
+)
+
+type LeaseInfo struct {
+	Id         clientv3.LeaseID
+	//Remaining time to live of the lease, in seconds
+	Ttl        int64
+	//Time to live the lease was granted with, in seconds
+	GrantedTtl int64
+	//Keys attached to the lease. Only filled if they were requested.
+	Keys       []string
+}
+
+func leaseErr(lease clientv3.LeaseID, err error) error {
+	if errors.Is(err, rpctypes.ErrLeaseNotFound) {
+		return &LeaseError{Lease: lease, Kind: ErrNotFound, Cause: err}
+	}
+
+	return err
+}
+
+/*
+Grants a lease with the given time to live in seconds. The cluster may grant a longer time to live than requested.
+*/
+func (cli *EtcdClient) GrantLease(ttl int64) (LeaseInfo, error) {
+	return cli.GrantLeaseCtx(cli.Context, ttl)
+}
+
+/*
+Variant of GrantLease that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) GrantLeaseCtx(ctx context.Context, ttl int64) (_ LeaseInfo, err error) {
+	ctx, op := cli.startOperation(ctx, "GrantLease", "")
+	defer func() { op.end(err) }()
+
+	var res *clientv3.LeaseGrantResponse
+	err = cli.withRetries(ctx, func() error {
+		ctx, cancel := context.WithTimeout(ctx, cli.RequestTimeout)
+		defer cancel()
+
+		var err error
+		res, err = cli.Client.Grant(ctx, ttl)
+		return err
+	})
+	if err != nil {
+		return LeaseInfo{}, err
+	}
+
+	op.setRevision(res.ResponseHeader.Revision)
+	return LeaseInfo{Id: res.ID, Ttl: res.TTL, GrantedTtl: res.TTL}, nil
+}
+
+/*
+Revokes a lease, which deletes the keys attached to it.
+Returns a LeaseError matching ErrNotFound if the lease does not exist, which includes leases that expired.
+*/
+func (cli *EtcdClient) RevokeLease(lease clientv3.LeaseID) error {
+	return cli.RevokeLeaseCtx(cli.Context, lease)
+}
+
+/*
+Variant of RevokeLease that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) RevokeLeaseCtx(ctx context.Context, lease clientv3.LeaseID) (err error) {
+	ctx, op := cli.startOperation(ctx, "RevokeLease", "")
+	defer func() { op.end(err) }()
+
+	return leaseErr(lease, cli.releaseLease(ctx, lease))
+}
+
+/*
+Returns the time to live of a lease and optionally the keys attached to it.
+Returns a LeaseError matching ErrNotFound if the lease does not exist, which includes leases that expired.
+*/
+func (cli *EtcdClient) GetLeaseInfo(lease clientv3.LeaseID, withKeys bool) (LeaseInfo, error) {
+	return cli.GetLeaseInfoCtx(cli.Context, lease, withKeys)
+}
+
+/*
+Variant of GetLeaseInfo that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) GetLeaseInfoCtx(ctx context.Context, lease clientv3.LeaseID, withKeys bool) (_ LeaseInfo, err error) {
+	ctx, op := cli.startOperation(ctx, "GetLeaseInfo", "")
+	defer func() { op.end(err) }()
+
+	opts := []clientv3.LeaseOption{}
+	if withKeys {
+		opts = append(opts, clientv3.WithAttachedKeys())
+	}
+
+	var res *clientv3.LeaseTimeToLiveResponse
+	err = cli.withRetries(ctx, func() error {
+		ctx, cancel := context.WithTimeout(ctx, cli.RequestTimeout)
+		defer cancel()
+
+		var err error
+		res, err = cli.Client.TimeToLive(ctx, lease, opts...)
+		return err
+	})
+	if err != nil {
+		return LeaseInfo{}, leaseErr(lease, err)
+	}
+
+	//Etcd reports leases that do not exist with a time to live of -1 rather than an error
+	if res.TTL < 0 {
+		return LeaseInfo{}, &LeaseError{Lease: lease, Kind: ErrNotFound}
+	}
+
+	info := LeaseInfo{Id: res.ID, Ttl: res.TTL, GrantedTtl: res.GrantedTTL, Keys: []string{}}
+	for _, key := range res.Keys {
+		info.Keys = append(info.Keys, string(key))
+	}
+
+	return info, nil
+}
+
+/*
+Returns the ids of all the leases of the etcd cluster.
+*/
+func (cli *EtcdClient) ListLeases() ([]clientv3.LeaseID, error) {
+	return cli.ListLeasesCtx(cli.Context)
+}
+
+/*
+Variant of ListLeases that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) ListLeasesCtx(ctx context.Context) (_ []clientv3.LeaseID, err error) {
+	ctx, op := cli.startOperation(ctx, "ListLeases", "")
+	defer func() { op.end(err) }()
+
+	var res *clientv3.LeaseLeasesResponse
+	err = cli.withRetries(ctx, func() error {
+		ctx, cancel := context.WithTimeout(ctx, cli.RequestTimeout)
+		defer cancel()
+
+		var err error
+		res, err = cli.Client.Leases(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	leases := []clientv3.LeaseID{}
+	for _, lease := range res.Leases {
+		leases = append(leases, lease.ID)
+	}
+
+	return leases, nil
+}
+
+/*
+Puts a key attached to a lease, so that it is deleted when the lease expires or is revoked.
+Returns a LeaseError matching ErrNotFound if the lease does not exist.
+*/
+func (cli *EtcdClient) PutKeyWithLease(key string, val string, lease clientv3.LeaseID) (int64, error) {
+	return cli.PutKeyWithLeaseCtx(cli.Context, key, val, lease)
+}
+
+/*
+Variant of PutKeyWithLease that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) PutKeyWithLeaseCtx(ctx context.Context, key string, val string, lease clientv3.LeaseID) (_ int64, err error) {
+	ctx, op := cli.startOperation(ctx, "PutKeyWithLease", key)
+	defer func() { op.end(err) }()
+
+	var revision int64
+	err = cli.withRetries(ctx, func() error {
+		ctx, cancel := context.WithTimeout(ctx, cli.RequestTimeout)
+		defer cancel()
+
+		resp, err := cli.Client.Put(ctx, key, val, clientv3.WithLease(lease))
+		if err != nil {
+			return err
+		}
+
+		revision = resp.Header.Revision
+		return nil
+	})
+	if err != nil {
+		return revision, leaseErr(lease, err)
+	}
+
+	op.addBytes(0, int64(len(key) + len(val)))
+	op.setRevision(revision)
+	return revision, nil
+}
+
+/*
+Puts a key that expires after the given time to live in seconds, by attaching it to a new lease.
+Returns the lease, which can be kept alive or revoked, and the revision of the put.
+*/
+func (cli *EtcdClient) PutKeyWithTTL(key string, val string, ttl int64) (clientv3.LeaseID, int64, error) {
+	return cli.PutKeyWithTTLCtx(cli.Context, key, val, ttl)
+}
+
+/*
+Variant of PutKeyWithTTL that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) PutKeyWithTTLCtx(ctx context.Context, key string, val string, ttl int64) (_ clientv3.LeaseID, _ int64, err error) {
+	ctx, op := cli.startOperation(ctx, "PutKeyWithTTL", key)
+	defer func() { op.end(err) }()
+
+	lease, err := cli.GrantLeaseCtx(ctx, ttl)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	revision, err := cli.PutKeyWithLeaseCtx(ctx, key, val, lease.Id)
+	if err != nil {
+		//The lease is revoked even if the operation was cancelled, so that it does not linger
+		cli.releaseLease(context.WithoutCancel(ctx), lease.Id)
+		return 0, 0, err
+	}
+
+	return lease.Id, revision, nil
+}
+
+/*
+Notification that a lease kept alive by a LeaseKeeper was lost.
+*/
+type LeaseLoss struct {
+	Lease clientv3.LeaseID
+	//LeaseError matching ErrLeaseLost, whose cause is the error that revealed the loss, if any
+	Error error
+}
+
+/*
+Keeps a set of leases alive until they are removed or the keeper is closed.
+If the keepalive stream of a lease breaks, the keeper checks whether the lease still exists and restarts keeping it alive if it does.
+Leases that expired, were revoked or whose existence could not be verified are removed from the keeper and reported on the Lost channel.
+It should be instanciated with the NewLeaseKeeper method.
+*/
+type LeaseKeeper struct {
+	cli       *EtcdClient
+	ctx       context.Context
+	cancel    context.CancelFunc
+	lost      chan LeaseLoss
+	closeOnce sync.Once
+	mutex     sync.Mutex
+	leases    map[clientv3.LeaseID]*keptLease
+	wg        sync.WaitGroup
+}
+
+/*
+Registration of a lease in a keeper. Each call to Add creates a new one, so that a goroutine can tell whether its lease was removed and added again.
+*/
+type keptLease struct {
+	cancel context.CancelFunc
+}
+
+/*
+Creates a keeper of leases. It should be closed when it is no longer needed.
+*/
+func (cli *EtcdClient) NewLeaseKeeper() *LeaseKeeper {
+	return cli.NewLeaseKeeperCtx(cli.Context)
+}
+
+/*
+Variant of NewLeaseKeeper that takes a context. The leases stop being kept alive when it is cancelled.
+*/
+func (cli *EtcdClient) NewLeaseKeeperCtx(ctx context.Context) *LeaseKeeper {
+	ctx, cancel := context.WithCancel(ctx)
+	return &LeaseKeeper{
+		cli:    cli,
+		ctx:    ctx,
+		cancel: cancel,
+		lost:   make(chan LeaseLoss, 16),
+		leases: make(map[clientv3.LeaseID]*keptLease),
+	}
+}
+
+/*
+Starts keeping a lease alive. Adding a lease that is already kept alive has no effect.
+*/
+func (k *LeaseKeeper) Add(lease clientv3.LeaseID) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	if _, ok := k.leases[lease]; ok || k.ctx.Err() != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(k.ctx)
+	kept := &keptLease{cancel: cancel}
+	k.leases[lease] = kept
+	k.wg.Add(1)
+	go k.keepAlive(ctx, lease, kept)
+}
+
+/*
+Stops keeping a lease alive, without revoking it.
+*/
+func (k *LeaseKeeper) Remove(lease clientv3.LeaseID) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	if kept, ok := k.leases[lease]; ok {
+		kept.cancel()
+		delete(k.leases, lease)
+	}
+}
+
+/*
+Returns the leases currently kept alive.
+*/
+func (k *LeaseKeeper) Leases() []clientv3.LeaseID {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	leases := []clientv3.LeaseID{}
+	for lease := range k.leases {
+		leases = append(leases, lease)
+	}
+
+	return leases
+}
+
+/*
+Returns a channel on which the leases that were lost are reported.
+It is closed when the keeper is closed.
+*/
+func (k *LeaseKeeper) Lost() <-chan LeaseLoss {
+	return k.lost
+}
+
+/*
+Stops keeping all the leases alive, without revoking them, and closes the Lost channel.
+*/
+func (k *LeaseKeeper) Close() {
+	k.mutex.Lock()
+	k.cancel()
+	k.leases = make(map[clientv3.LeaseID]*keptLease)
+	k.mutex.Unlock()
+
+	k.wg.Wait()
+	k.closeOnce.Do(func() { close(k.lost) })
+}
+
+func (k *LeaseKeeper) keepAlive(ctx context.Context, lease clientv3.LeaseID, kept *keptLease) {
+	defer k.wg.Done()
+	defer kept.cancel()
+
+	logger := k.cli.getLogger()
+	for {
+		ch, err := k.cli.Client.KeepAlive(ctx, lease)
+		if err == nil {
+			for range ch {}
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		//The keepalive channel is closed both when the lease expired and when the stream broke for longer than the lease's time to live
+		info, infoErr := k.cli.GetLeaseInfoCtx(ctx, lease, false)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if infoErr == nil && info.Ttl > 0 {
+			logger.Warn("Lease keepalive was interrupted, restarting it", zap.Int64("lease", int64(lease)), zap.Int64("ttl", info.Ttl))
+			if sleepCtx(ctx, k.cli.RetryInterval) != nil {
+				return
+			}
+			continue
+		}
+
+		//The lease may have been removed and added again in the meantime, in which case the new registration is left as is
+		k.mutex.Lock()
+		if k.leases[lease] == kept {
+			delete(k.leases, lease)
+		}
+		k.mutex.Unlock()
+
+		logger.Warn("Lease was lost", zap.Int64("lease", int64(lease)), zap.Error(infoErr))
+		select {
+		case k.lost <- LeaseLoss{Lease: lease, Error: &LeaseError{Lease: lease, Kind: ErrLeaseLost, Cause: infoErr}}:
+		case <-ctx.Done():
+		}
+		return
+	}
+}
@@ -0,0 +1,125 @@
+package client
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Ferlab-Ste-Justine/etcd-sdk/testutils"
+)
+
+func TestLeases(t *testing.T) {
+	tearDown, launchErr := testutils.LaunchTestEtcdCluster("../test", testutils.EtcdTestClusterOpts{})
+	if launchErr != nil {
+		t.Errorf("Error occured launching test etcd cluster: %s", launchErr.Error())
+		return
+	}
+
+	defer func() {
+		errs := tearDown()
+		if len(errs) > 0 {
+			t.Errorf("Errors occured tearing down etcd cluster: %s", errs[0].Error())
+		}
+	}()
+
+	retryInterval, _ := time.ParseDuration("1s")
+	timeouts, _ := time.ParseDuration("10s")
+	retries := uint64(10)
+	cli := setupTestEnv(t, timeouts, retryInterval, retries)
+
+	lease, err := cli.GrantLease(60)
+	if err != nil {
+		t.Errorf("Error occured granting lease: %s", err.Error())
+		return
+	}
+
+	_, err = cli.PutKeyWithLease("/leased/a", "a", lease.Id)
+	if err != nil {
+		t.Errorf("Error occured putting key with lease: %s", err.Error())
+		return
+	}
+
+	info, err := cli.GetLeaseInfo(lease.Id, true)
+	if err != nil || info.GrantedTtl != 60 || info.Ttl <= 0 || len(info.Keys) != 1 || info.Keys[0] != "/leased/a" {
+		t.Errorf("Expected lease info to include its time to live and its keys and got: %v, %v", info, err)
+	}
+
+	leases, err := cli.ListLeases()
+	if err != nil || !slices.Contains(leases, lease.Id) {
+		t.Errorf("Expected granted lease to be listed and got: %v, %v", leases, err)
+	}
+
+	err = cli.RevokeLease(lease.Id)
+	if err != nil {
+		t.Errorf("Error occured revoking lease: %s", err.Error())
+	}
+
+	keyInfo, _ := cli.GetKey("/leased/a", GetKeyOptions{})
+	if keyInfo.Found() {
+		t.Errorf("Expected key to be deleted with its lease and it wasn't")
+	}
+
+	err = cli.RevokeLease(lease.Id)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected revoking a revoked lease to return a not found error and got: %v", err)
+	}
+
+	_, err = cli.GetLeaseInfo(lease.Id, false)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected getting the info of a revoked lease to return a not found error and got: %v", err)
+	}
+
+	_, err = cli.PutKeyWithLease("/leased/a", "a", lease.Id)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected putting a key with a revoked lease to return a not found error and got: %v", err)
+	}
+
+	expiring, _, err := cli.PutKeyWithTTL("/leased/expiring", "expiring", 2)
+	if err != nil {
+		t.Errorf("Error occured putting key with ttl: %s", err.Error())
+		return
+	}
+	kept, _, _ := cli.PutKeyWithTTL("/leased/kept", "kept", 2)
+	lost, _, _ := cli.PutKeyWithTTL("/leased/lost", "lost", 2)
+
+	keeper := cli.NewLeaseKeeper()
+	keeper.Add(kept)
+	keeper.Add(lost)
+
+	time.Sleep(4 * time.Second)
+
+	keyInfo, _ = cli.GetKey("/leased/expiring", GetKeyOptions{})
+	if keyInfo.Found() {
+		t.Errorf("Expected key with a ttl to expire and it didn't")
+	}
+
+	keyInfo, _ = cli.GetKey("/leased/kept", GetKeyOptions{})
+	if !keyInfo.Found() {
+		t.Errorf("Expected key whose lease is kept alive not to expire and it did")
+	}
+
+	cli.RevokeLease(lost)
+	select {
+	case loss := <-keeper.Lost():
+		if loss.Lease != lost || !errors.Is(loss.Error, ErrLeaseLost) {
+			t.Errorf("Expected revoked lease to be reported as lost and got: %v", loss)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("Expected revoked lease to be reported as lost and it wasn't")
+	}
+
+	if leases := keeper.Leases(); len(leases) != 1 || leases[0] != kept {
+		t.Errorf("Expected lost lease to be removed from the keeper and got: %v", leases)
+	}
+
+	keeper.Close()
+	if _, ok := <-keeper.Lost(); ok {
+		t.Errorf("Expected lost channel to be closed with the keeper and it wasn't")
+	}
+
+	_, err = cli.GetLeaseInfo(expiring, false)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected getting the info of an expired lease to return a not found error and got: %v", err)
+	}
+}
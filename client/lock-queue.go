@@ -0,0 +1,252 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+/*
+Prefix under which the acquirers waiting for a lock in queued mode register themselves.
+*/
+func lockQueuePrefix(key string) string {
+	return fmt.Sprintf("%s/queue/", key)
+}
+
+/*
+Key with which an acquirer holding the given lease waits in the queue of a lock.
+*/
+func lockQueueKey(key string, lease clientv3.LeaseID) string {
+	return fmt.Sprintf("%s%016x", lockQueuePrefix(key), int64(lease))
+}
+
+/*
+Registers a lease in a queue and returns the create revision of its entry, which is its position in the queue.
+*/
+func (cli *EtcdClient) enqueue(ctx context.Context, queueKey string, value string, lease clientv3.LeaseID) (int64, error) {
+	//An entry applied by an attempt whose outcome is unknown would be left behind by a retry, so retries are limited to errors that guarantee it was not applied
+	writeCli := cli.SetRetryPolicy(unappliedRetryPolicy{cli.getRetryPolicy()})
+
+	var position int64
+	err := writeCli.withRetries(ctx, func() error {
+		ctx, cancel := context.WithTimeout(ctx, writeCli.RequestTimeout)
+		defer cancel()
+
+		//An entry already registered with the lease keeps its position
+		res, err := writeCli.Client.Txn(ctx).If(
+			clientv3.Compare(clientv3.CreateRevision(queueKey), "=", 0),
+		).Then(
+			clientv3.OpPut(queueKey, value, clientv3.WithLease(lease)),
+		).Else(
+			clientv3.OpGet(queueKey),
+		).Commit()
+		if err != nil {
+			return err
+		}
+
+		if res.Succeeded {
+			position = res.Header.Revision
+		} else {
+			position = res.Responses[0].GetResponseRange().Kvs[0].CreateRevision
+		}
+		return nil
+	})
+
+	return position, err
+}
+
+/*
+Waits for a key to be modified or deleted after the given revision, or for the context to be done.
+If onlyDeletes is true, modifications are ignored.
+*/
+func (cli *EtcdClient) waitKeyChange(ctx context.Context, key string, revision int64, onlyDeletes bool) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	opts := []clientv3.OpOption{clientv3.WithRev(revision + 1)}
+	if onlyDeletes {
+		opts = append(opts, clientv3.WithFilterPut())
+	}
+
+	//Any interruption of the watch simply causes the caller to check the state of the keys again
+	for res := range cli.Client.Watch(clientv3.WithRequireLeader(ctx), key, opts...) {
+		if res.Err() != nil || len(res.Events) > 0 {
+			return nil
+		}
+	}
+
+	return ctx.Err()
+}
+
+/*
+Returns the key of the entry queued right before the given position under a prefix, or an empty key if there is none, along with the revision it was read at.
+*/
+func (cli *EtcdClient) getPredecessor(ctx context.Context, prefix string, position int64) (string, int64, error) {
+	var res *clientv3.GetResponse
+	err := cli.withRetries(ctx, func() error {
+		ctx, cancel := context.WithTimeout(ctx, cli.RequestTimeout)
+		defer cancel()
+
+		var err error
+		opts := append([]clientv3.OpOption{clientv3.WithMaxCreateRev(position - 1), clientv3.WithKeysOnly()}, clientv3.WithLastCreate()...)
+		res, err = cli.Client.Get(ctx, prefix, opts...)
+		return err
+	})
+	if err != nil {
+		return "", 0, err
+	}
+
+	if len(res.Kvs) == 0 {
+		return "", res.Header.Revision, nil
+	}
+
+	return string(res.Kvs[0].Key), res.Header.Revision, nil
+}
+
+/*
+Refreshes the ttl of a lease that was kept alive while waiting for a lock, so that the lock does not expire sooner than its timestamp implies.
+*/
+func (cli *EtcdClient) refreshLockLease(ctx context.Context, lock *Lock) error {
+	lock.Timestamp = time.Now()
+	return cli.withRetries(ctx, func() error {
+		ctx, cancel := context.WithTimeout(ctx, cli.RequestTimeout)
+		defer cancel()
+
+		_, err := cli.Client.KeepAliveOnce(ctx, lock.Lease)
+		return err
+	})
+}
+
+/*
+Grants a lease and waits with it for a lock until the deadline, using the given wait function.
+The lease is kept alive while waiting and revoked if the lock could not be acquired, which removes the keys the wait function attached to it.
+*/
+func (cli *EtcdClient) acquireWithLease(ctx context.Context, key string, ttl int64, deadline time.Time, wait func(ctx context.Context, leaseRes *clientv3.LeaseGrantResponse) (*Lock, error)) (*Lock, bool, error) {
+	waitCtx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+
+	var leaseRes *clientv3.LeaseGrantResponse
+	err := cli.withRetries(ctx, func() error {
+		ctx, cancel := context.WithTimeout(ctx, cli.RequestTimeout)
+		defer cancel()
+
+		var err error
+		leaseRes, err = cli.Client.Grant(ctx, ttl)
+		return err
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	//The wait can outlast the ttl of the lease, so it is kept alive until the lock is acquired
+	keeper := cli.NewLeaseKeeperCtx(waitCtx)
+	keeper.Add(leaseRes.ID)
+	defer keeper.Close()
+
+	lock, err := wait(waitCtx, leaseRes)
+	if err != nil {
+		//Revoking the lease removes the queue entry. The cleanup is done even if the operation was cancelled.
+		cli.releaseLease(context.WithoutCancel(ctx), leaseRes.ID)
+		if ctx.Err() == nil && time.Now().After(deadline) {
+			return nil, true, &KeyError{Key: key, Kind: ErrLockTimeout}
+		}
+
+		return nil, false, err
+	}
+
+	return lock, false, nil
+}
+
+/*
+Acquires a lock by waiting in its queue for the acquirers that arrived before to release it.
+Each waiter watches the deletion of the queue entry right before its own, so the lock is handed over in order without polling.
+*/
+func (cli *EtcdClient) acquireQueuedLock(ctx context.Context, opts AcquireLockOptions, deadline time.Time) (*Lock, bool, error) {
+	return cli.acquireWithLease(ctx, opts.Key, opts.Ttl, deadline, func(ctx context.Context, leaseRes *clientv3.LeaseGrantResponse) (*Lock, error) {
+		return cli.waitQueuedLock(ctx, opts, leaseRes)
+	})
+}
+
+func (cli *EtcdClient) waitQueuedLock(ctx context.Context, opts AcquireLockOptions, leaseRes *clientv3.LeaseGrantResponse) (*Lock, error) {
+	op := getOperation(ctx)
+	queuePrefix := lockQueuePrefix(opts.Key)
+	position, err := cli.enqueue(ctx, lockQueueKey(opts.Key, leaseRes.ID), "", leaseRes.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		//Waits for the entry queued right before ours to be removed
+		predecessor, revision, err := cli.getPredecessor(ctx, queuePrefix, position)
+		if err != nil {
+			return nil, err
+		}
+
+		if predecessor != "" {
+			err = cli.waitKeyChange(ctx, predecessor, revision, true)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		//First in the queue
+		lock := Lock{
+			Lease:    leaseRes.ID,
+			Ttl:      opts.Ttl,
+			Revision: leaseRes.ResponseHeader.Revision,
+			Key:      opts.Key,
+		}
+		err = cli.refreshLockLease(ctx, &lock)
+		if err != nil {
+			return nil, err
+		}
+		output, _ := json.Marshal(lock)
+
+		txIfs := []clientv3.Cmp{clientv3.Compare(clientv3.Version(opts.Key), "=", 0)}
+		txIfs = append(txIfs, opts.ExtraConditions...)
+
+		//A retry after an acquisition whose outcome is unknown would wait on the lock it acquired, so retries are limited to errors that guarantee it was not applied
+		writeCli := cli.SetRetryPolicy(unappliedRetryPolicy{cli.getRetryPolicy()})
+
+		var txResp *clientv3.TxnResponse
+		err = writeCli.withRetries(ctx, func() error {
+			ctx, cancel := context.WithTimeout(ctx, writeCli.RequestTimeout)
+			defer cancel()
+
+			var err error
+			txResp, err = writeCli.Client.Txn(ctx).If(
+				txIfs...
+			).Then(
+				clientv3.OpPut(opts.Key, string(output), clientv3.WithLease(leaseRes.ID)),
+			).Else(
+				clientv3.OpGet(opts.Key, clientv3.WithKeysOnly()),
+			).Commit()
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		op.setTxnSucceeded(txResp.Succeeded)
+		if txResp.Succeeded {
+			lock.FencingToken = txResp.Header.Revision
+			op.setRevision(txResp.Header.Revision)
+			return &lock, nil
+		}
+
+		//The lock is held by an acquirer that did not queue or the extra conditions are not fulfilled.
+		//The release of the lock is waited on, but the conditions can only be checked again after the retry interval.
+		if len(txResp.Responses[0].GetResponseRange().Kvs) > 0 {
+			err = cli.waitKeyChange(ctx, opts.Key, txResp.Header.Revision, true)
+		} else {
+			err = sleepCtx(ctx, opts.RetryInterval)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
@@ -4,72 +4,145 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"time"
 
 	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
 )
 
 type Lock struct {
-	Lease     clientv3.LeaseID
-	Ttl       int64
-	Timestamp time.Time
-	Revision  int64
+	Lease        clientv3.LeaseID
+	Ttl          int64
+	Timestamp    time.Time
+	Revision     int64
+	//Key of the lock. It is not stored in the lock's value.
+	Key          string           `json:"-"`
+	//Create revision of the lock's key. It increases each time the lock is acquired, so systems protected by the lock can reject holders with a token lower than the last one they saw.
+	FencingToken int64            `json:"-"`
+	ctx          context.Context
+	cancel       context.CancelCauseFunc
 }
 
-func (cli *EtcdClient) releaseLeaseWithRetries(lease clientv3.LeaseID, retries uint64) error {
-	ctx, cancel := context.WithTimeout(cli.Context, cli.RequestTimeout)
-	defer cancel()
+/*
+Returns a context derived from the one the lock was acquired with, which is cancelled when the lock is lost or released with the client that acquired it.
+If the lock was lost, the cause of the cancellation returned by context.Cause is a LeaseError matching ErrLeaseLost.
+A lock acquired without keepalive is considered lost when its ttl elapses. A lock acquired with keepalive is lost when its lease could not be kept alive.
+Locks that were not acquired by the etcd client, like the ones returned by ReadLock, have a context that is never cancelled.
+*/
+func (l *Lock) Context() context.Context {
+	if l.ctx == nil {
+		return context.Background()
+	}
 
-	_, err := cli.Client.Revoke(ctx, lease)
-	if err != nil {
-		if !shouldRetry(err, retries) {
-			return err
+	return l.ctx
+}
+
+/*
+Returns a channel that is closed when the lock is lost or released. It is the Done channel of the lock's context.
+*/
+func (l *Lock) Lost() <-chan struct{} {
+	return l.Context().Done()
+}
+
+/*
+Sets up the context of an acquired lock and keeps its lease alive if requested, until the lock is lost or released.
+*/
+func (cli *EtcdClient) holdLock(ctx context.Context, lock *Lock, keepAlive bool) {
+	lock.ctx, lock.cancel = context.WithCancelCause(ctx)
+
+	if keepAlive {
+		keeper := cli.NewLeaseKeeperCtx(lock.ctx)
+		keeper.Add(lock.Lease)
+		go func() {
+			defer keeper.Close()
+			select {
+			case loss := <-keeper.Lost():
+				cli.getLogger().Warn("Lock was lost", zap.Int64("lease", int64(lock.Lease)), zap.Error(loss.Error))
+				lock.cancel(loss.Error)
+			case <-lock.ctx.Done():
+			}
+		}()
+	} else {
+		//The lease was granted after the timestamp was taken, so the lock expires no sooner than this
+		timer := time.AfterFunc(time.Until(lock.Timestamp.Add(time.Duration(lock.Ttl) * time.Second)), func() {
+			lock.cancel(&LeaseError{Lease: lock.Lease, Kind: ErrLeaseLost, Cause: context.DeadlineExceeded})
+		})
+		context.AfterFunc(lock.ctx, func() { timer.Stop() })
+	}
+
+	if cli.heldLocks != nil {
+		cli.heldLocks.Store(lock.Lease, lock)
+		if cli.Metrics != nil {
+			cli.Metrics.AddHeldLocks(1)
 		}
 
-		time.Sleep(cli.RetryInterval)
-		return cli.releaseLeaseWithRetries(lease, retries-1)
+		//The lock stops being held once its context is done, whether it was released, expired or lost
+		context.AfterFunc(lock.ctx, func() {
+			_, held := cli.heldLocks.LoadAndDelete(lock.Lease)
+			if held && cli.Metrics != nil {
+				cli.Metrics.AddHeldLocks(-1)
+			}
+		})
 	}
+}
 
-	return nil
+/*
+Cancels the context of a lock acquired by the client when it is released.
+*/
+func (cli *EtcdClient) unholdLock(lease clientv3.LeaseID) {
+	if cli.heldLocks == nil {
+		return
+	}
+
+	if held, ok := cli.heldLocks.Load(lease); ok {
+		held.(*Lock).cancel(nil)
+	}
 }
 
-func (cli *EtcdClient) acquireLockWithRetries(opts AcquireLockOptions, deadline time.Time, retries uint64) (*Lock, bool, error) {
-	//If acquisition deadline has expired, fail
+func (cli *EtcdClient) releaseLease(ctx context.Context, lease clientv3.LeaseID) error {
+	return cli.withRetries(ctx, func() error {
+		ctx, cancel := context.WithTimeout(ctx, cli.RequestTimeout)
+		defer cancel()
+
+		_, err := cli.Client.Revoke(ctx, lease)
+		return err
+	})
+}
+
+/*
+Makes a single attempt at acquiring the lock.
+A nil lock is returned without an error if the lock is already held.
+*/
+func (cli *EtcdClient) tryAcquireLock(ctx context.Context, opts AcquireLockOptions) (*Lock, error) {
 	now := time.Now()
-	if now.After(deadline) {
-		return nil, true, errors.New(fmt.Sprintf("Could not acquire lock on key %s before deadline", opts.Key))
-	}
+	op := getOperation(ctx)
 
 	//Exploratory get without getting a lease to see if a lock already exists
 	//Seems more efficient not to create a lease unless likelyhood is high we can get a lock
-	info, err := cli.GetKey(opts.Key, GetKeyOptions{})
-	if err != nil {
-		if !shouldRetry(err, retries) {
-			return nil, false, err
-		}
+	getCtx, getSpan := op.startStep(ctx, "etcd.Get", attribute.String("etcd.key", opts.Key))
+	getCtx, getCancel := context.WithTimeout(getCtx, cli.RequestTimeout)
+	defer getCancel()
 
-		time.Sleep(cli.RetryInterval)
-		return cli.acquireLockWithRetries(opts, deadline, retries-1)
+	getRes, err := cli.Client.Get(getCtx, opts.Key)
+	endSpan(getSpan, err)
+	if err != nil {
+		return nil, err
 	}
 
-	if info.Found() {
-		time.Sleep(opts.RetryInterval)
-		return cli.acquireLockWithRetries(opts, deadline, retries)
+	if len(getRes.Kvs) > 0 {
+		return nil, nil
 	}
 
 	//Changes are good we can get a lock, so create a lease
-	ctx, cancel := context.WithTimeout(cli.Context, cli.RequestTimeout)
-	defer cancel()
+	leaseCtx, leaseSpan := op.startStep(ctx, "etcd.LeaseGrant", attribute.Int64("etcd.lease.ttl", opts.Ttl))
+	leaseCtx, leaseCancel := context.WithTimeout(leaseCtx, cli.RequestTimeout)
+	defer leaseCancel()
 
-	leaseResp, leaseErr := cli.Client.Grant(ctx, opts.Ttl)
+	leaseResp, leaseErr := cli.Client.Grant(leaseCtx, opts.Ttl)
+	endSpan(leaseSpan, leaseErr)
 	if leaseErr != nil {
-		if !shouldRetry(leaseErr, retries) {
-			return nil, false, leaseErr
-		}
-
-		time.Sleep(cli.RetryInterval)
-		return cli.acquireLockWithRetries(opts, deadline, retries-1)
+		return nil, leaseErr
 	}
 
 	//Create a lock with a transaction as safeguard, in case another acquirer narrowly beat us to the punch
@@ -78,6 +151,7 @@ func (cli *EtcdClient) acquireLockWithRetries(opts AcquireLockOptions, deadline
 		Ttl:       opts.Ttl,
 		Timestamp: now,
 		Revision: leaseResp.ResponseHeader.Revision,
+		Key:       opts.Key,
 	}
 	output, _ := json.Marshal(lock)
 
@@ -86,7 +160,8 @@ func (cli *EtcdClient) acquireLockWithRetries(opts AcquireLockOptions, deadline
 		txIfs = append(txIfs,opts.ExtraConditions...)
 	}
 
-	txCtx, txCancel := context.WithTimeout(cli.Context, cli.RequestTimeout)
+	txCtx, txSpan := op.startStep(ctx, "etcd.Txn", attribute.String("etcd.key", opts.Key))
+	txCtx, txCancel := context.WithTimeout(txCtx, cli.RequestTimeout)
 	defer txCancel()
 	tx := cli.Client.Txn(txCtx).If(
 		txIfs...
@@ -94,30 +169,57 @@ func (cli *EtcdClient) acquireLockWithRetries(opts AcquireLockOptions, deadline
 		clientv3.OpPut(opts.Key, string(output), clientv3.WithLease(leaseResp.ID)),
 	)
 	txResp, txErr := tx.Commit()
+	if txErr == nil {
+		txSpan.SetAttributes(attribute.Bool("etcd.txn.succeeded", txResp.Succeeded))
+		op.setTxnSucceeded(txResp.Succeeded)
+	}
+	endSpan(txSpan, txErr)
 
-	//Transaction error
+	//Transaction error. Revoking the lease also cleans up the lock if the transaction went through after all.
+	//The cleanup is done even if the operation was cancelled.
 	if txErr != nil {
-		releaseErr := cli.releaseLeaseWithRetries(leaseResp.ID, cli.Retries)
-		if (!shouldRetry(txErr, retries)) || releaseErr != nil {
-			return nil, false, txErr
-		}
-
-		time.Sleep(cli.RetryInterval)
-		return cli.acquireLockWithRetries(opts, deadline, retries-1)
+		cli.releaseLease(context.WithoutCancel(ctx), leaseResp.ID)
+		return nil, txErr
 	}
 
 	//Someone beat us to the punch acquiring the lock
 	if !txResp.Succeeded {
-		releaseErr := cli.releaseLeaseWithRetries(leaseResp.ID, cli.Retries)
-		if releaseErr != nil {
-			return nil, false, releaseErr
+		releaseErr := cli.releaseLease(context.WithoutCancel(ctx), leaseResp.ID)
+		return nil, releaseErr
+	}
+
+	//The lock's key is created by the transaction, so its create revision is the transaction's revision
+	lock.FencingToken = txResp.Header.Revision
+	op.setRevision(txResp.Header.Revision)
+	return &lock, nil
+}
+
+func (cli *EtcdClient) acquireLock(ctx context.Context, opts AcquireLockOptions, deadline time.Time) (*Lock, bool, error) {
+	for {
+		//If acquisition deadline has expired, fail
+		if time.Now().After(deadline) {
+			return nil, true, &KeyError{Key: opts.Key, Kind: ErrLockTimeout}
 		}
 
-		time.Sleep(opts.RetryInterval)
-		return cli.acquireLockWithRetries(opts, deadline, retries)
-	}
+		var lock *Lock
+		err := cli.withRetries(ctx, func() error {
+			var err error
+			lock, err = cli.tryAcquireLock(ctx, opts)
+			return err
+		})
+		if err != nil {
+			return nil, false, err
+		}
 
-	return &lock, false, nil
+		if lock != nil {
+			return lock, false, nil
+		}
+
+		waitErr := sleepCtx(ctx, opts.RetryInterval)
+		if waitErr != nil {
+			return nil, false, waitErr
+		}
+	}
 }
 
 type AcquireLockOptions struct {
@@ -126,9 +228,31 @@ type AcquireLockOptions struct {
 	Timeout         time.Duration
 	RetryInterval   time.Duration
 	ExtraConditions []clientv3.Cmp
+	//If true, the lease of the lock is kept alive in the background until the lock is released or its context is cancelled.
+	//A shorter ttl is then advisable, as it bounds the time it takes to detect that the lease could not be kept alive.
+	KeepAlive       bool
+	//If true, the acquirer waits in a queue under the lock's key and watches for its turn instead of polling the lock at each retry interval.
+	//Queued acquirers get the lock in their order of arrival, as soon as it is released.
+	Queued          bool
 }
 
+/*
+Acquires a lock on the key specified in the options, waiting for it to be released if it is already held.
+If the lock could not be acquired before the timeout, the second return value is true and the error matches ErrLockTimeout.
+*/
 func (cli *EtcdClient) AcquireLock(opts AcquireLockOptions) (*Lock, bool, error) {
+	return cli.AcquireLockCtx(cli.Context, opts)
+}
+
+/*
+Variant of AcquireLock that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) AcquireLockCtx(ctx context.Context, opts AcquireLockOptions) (_ *Lock, _ bool, err error) {
+	//The context of the lock outlives the method, so it is not derived from the operation's context
+	lockCtx := ctx
+	ctx, op := cli.startOperation(ctx, "AcquireLock", opts.Key)
+	defer func() { op.end(err) }()
+
 	if opts.Ttl == 0 {
 		opts.Ttl = 600
 	}
@@ -140,19 +264,46 @@ func (cli *EtcdClient) AcquireLock(opts AcquireLockOptions) (*Lock, bool, error)
 	}
 
 	now := time.Now()
-	return cli.acquireLockWithRetries(opts, now.Add(opts.Timeout), cli.Retries)
+	var lock *Lock
+	var timeout bool
+	if opts.Queued {
+		lock, timeout, err = cli.acquireQueuedLock(ctx, opts, now.Add(opts.Timeout))
+	} else {
+		lock, timeout, err = cli.acquireLock(ctx, opts, now.Add(opts.Timeout))
+	}
+	if err != nil {
+		return lock, timeout, err
+	}
+
+	cli.holdLock(lockCtx, lock, opts.KeepAlive)
+
+	return lock, timeout, err
 }
 
+/*
+Reads the lock stored at the given key.
+Returns an error matching ErrNotFound if there is no lock at the key.
+*/
 func (cli *EtcdClient) ReadLock(key string) (*Lock, error) {
-	info, err := cli.GetKey(key, GetKeyOptions{})
+	return cli.ReadLockCtx(cli.Context, key)
+}
+
+/*
+Variant of ReadLock that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) ReadLockCtx(ctx context.Context, key string) (_ *Lock, err error) {
+	ctx, op := cli.startOperation(ctx, "ReadLock", key)
+	defer func() { op.end(err) }()
+
+	info, err := cli.GetKeyCtx(ctx, key, GetKeyOptions{})
 	if err != nil {
 		return nil, err
 	}
 	if !info.Found() {
-		return nil, errors.New(fmt.Sprintf("Could not acquire lock at key %s as it didn't exist", key))
+		return nil, &KeyError{Key: key, Kind: ErrNotFound}
 	}
 
-	lock := Lock{}
+	lock := Lock{Key: key, FencingToken: info.CreateRevision}
 	unmarshalErr := json.Unmarshal([]byte(info.Value), &lock)
 	if unmarshalErr != nil {
 		return nil, unmarshalErr
@@ -161,12 +312,92 @@ func (cli *EtcdClient) ReadLock(key string) (*Lock, error) {
 	return &lock, nil
 }
 
+/*
+Releases the lock stored at the given key by revoking its lease.
+Returns an error matching ErrNotFound if there is no lock at the key.
+Note that the lock is released whoever holds it, so a process whose lock expired can release the lock of another. ReleaseHeldLock should be preferred to release an acquired lock.
+*/
 func (cli *EtcdClient) ReleaseLock(key string) error {
-	lock, lockErr := cli.ReadLock(key)
+	return cli.ReleaseLockCtx(cli.Context, key)
+}
+
+/*
+Variant of ReleaseLock that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) ReleaseLockCtx(ctx context.Context, key string) (err error) {
+	ctx, op := cli.startOperation(ctx, "ReleaseLock", key)
+	defer func() { op.end(err) }()
+
+	lock, lockErr := cli.ReadLockCtx(ctx, key)
 	if lockErr != nil {
 		return lockErr
 	}
 
-	releaseErr := cli.releaseLeaseWithRetries(lock.Lease, cli.Retries)
+	releaseErr := cli.releaseLease(ctx, lock.Lease)
+	if releaseErr == nil {
+		cli.unholdLock(lock.Lease)
+	}
+
 	return releaseErr
 }
+
+/*
+Releases a lock acquired with AcquireLock, only if it is still held by it.
+The lock is verified to be held with its lease and its fencing token in the same transaction that deletes it.
+Returns a KeyError matching ErrLockNotHeld if the lock expired or is held by another acquirer, in which case the lock is left as is.
+*/
+func (cli *EtcdClient) ReleaseHeldLock(lock *Lock) error {
+	return cli.ReleaseHeldLockCtx(cli.Context, lock)
+}
+
+/*
+Variant of ReleaseHeldLock that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) ReleaseHeldLockCtx(ctx context.Context, lock *Lock) (err error) {
+	ctx, op := cli.startOperation(ctx, "ReleaseHeldLock", lock.Key)
+	defer func() { op.end(err) }()
+
+	//A retry after a release whose outcome is unknown would find the lock gone and report it as not held, so retries are limited to errors that guarantee it was not applied
+	writeCli := cli.SetRetryPolicy(unappliedRetryPolicy{cli.getRetryPolicy()})
+
+	var txResp *clientv3.TxnResponse
+	err = writeCli.withRetries(ctx, func() error {
+		ctx, cancel := context.WithTimeout(ctx, writeCli.RequestTimeout)
+		defer cancel()
+
+		var err error
+		txResp, err = writeCli.Client.Txn(ctx).If(
+			clientv3.Compare(clientv3.CreateRevision(lock.Key), "=", lock.FencingToken),
+			clientv3.Compare(clientv3.LeaseValue(lock.Key), "=", lock.Lease),
+		).Then(
+			clientv3.OpDelete(lock.Key),
+			clientv3.OpDelete(lockQueueKey(lock.Key, lock.Lease)),
+		).Commit()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	op.setTxnSucceeded(txResp.Succeeded)
+
+	//The lease is ours either way, so it is revoked to clean up after the lock even if it is no longer held
+	releaseErr := leaseErr(lock.Lease, cli.releaseLease(ctx, lock.Lease))
+	if !txResp.Succeeded {
+		notHeldErr := &KeyError{Key: lock.Key, Kind: ErrLockNotHeld}
+		if lock.cancel != nil {
+			lock.cancel(notHeldErr)
+		}
+		return notHeldErr
+	}
+
+	op.setRevision(txResp.Header.Revision)
+	if releaseErr != nil && !errors.Is(releaseErr, ErrNotFound) {
+		return releaseErr
+	}
+
+	if lock.cancel != nil {
+		lock.cancel(nil)
+	}
+
+	return nil
+}
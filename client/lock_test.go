@@ -0,0 +1,418 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/Ferlab-Ste-Justine/etcd-sdk/testutils"
+)
+
+func TestLockKeepAlive(t *testing.T) {
+	tearDown, launchErr := testutils.LaunchTestEtcdCluster("../test", testutils.EtcdTestClusterOpts{})
+	if launchErr != nil {
+		t.Errorf("Error occured launching test etcd cluster: %s", launchErr.Error())
+		return
+	}
+
+	defer func() {
+		errs := tearDown()
+		if len(errs) > 0 {
+			t.Errorf("Errors occured tearing down etcd cluster: %s", errs[0].Error())
+		}
+	}()
+
+	retryInterval, _ := time.ParseDuration("1s")
+	timeouts, _ := time.ParseDuration("10s")
+	retries := uint64(10)
+	cli := setupTestEnv(t, timeouts, retryInterval, retries)
+
+	kept, _, err := cli.AcquireLock(AcquireLockOptions{Key: "/locks/kept", Ttl: 2, KeepAlive: true})
+	if err != nil {
+		t.Errorf("Error occured acquiring lock with keepalive: %s", err.Error())
+		return
+	}
+
+	expiring, _, err := cli.AcquireLock(AcquireLockOptions{Key: "/locks/expiring", Ttl: 2})
+	if err != nil {
+		t.Errorf("Error occured acquiring lock without keepalive: %s", err.Error())
+		return
+	}
+
+	time.Sleep(4 * time.Second)
+
+	select {
+	case <-kept.Lost():
+		t.Errorf("Expected lock with keepalive not to be lost and it was: %v", context.Cause(kept.Context()))
+	default:
+	}
+
+	_, err = cli.ReadLock("/locks/kept")
+	if err != nil {
+		t.Errorf("Expected lock with keepalive to still be held after its ttl and got: %v", err)
+	}
+
+	select {
+	case <-expiring.Lost():
+		if !errors.Is(context.Cause(expiring.Context()), ErrLeaseLost) {
+			t.Errorf("Expected lock without keepalive to be lost when its ttl elapsed and got: %v", context.Cause(expiring.Context()))
+		}
+	default:
+		t.Errorf("Expected lock without keepalive to be lost when its ttl elapsed and it wasn't")
+	}
+
+	cli.RevokeLease(kept.Lease)
+	select {
+	case <-kept.Lost():
+		if !errors.Is(context.Cause(kept.Context()), ErrLeaseLost) {
+			t.Errorf("Expected lock whose lease was revoked to be lost and got: %v", context.Cause(kept.Context()))
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("Expected lock whose lease was revoked to be reported as lost and it wasn't")
+	}
+
+	released, _, err := cli.AcquireLock(AcquireLockOptions{Key: "/locks/released", Ttl: 2, KeepAlive: true})
+	if err != nil {
+		t.Errorf("Error occured acquiring lock with keepalive: %s", err.Error())
+		return
+	}
+
+	err = cli.ReleaseLock("/locks/released")
+	if err != nil {
+		t.Errorf("Error occured releasing lock: %s", err.Error())
+	}
+
+	select {
+	case <-released.Lost():
+		if !errors.Is(context.Cause(released.Context()), context.Canceled) {
+			t.Errorf("Expected the context of a released lock to be cancelled and got: %v", context.Cause(released.Context()))
+		}
+	default:
+		t.Errorf("Expected the context of a released lock to be cancelled and it wasn't")
+	}
+
+	read, _ := cli.ReadLock("/locks/kept")
+	if read != nil {
+		t.Errorf("Expected lock whose lease was revoked not to be found and it was")
+	}
+}
+
+func TestReleaseHeldLock(t *testing.T) {
+	tearDown, launchErr := testutils.LaunchTestEtcdCluster("../test", testutils.EtcdTestClusterOpts{})
+	if launchErr != nil {
+		t.Errorf("Error occured launching test etcd cluster: %s", launchErr.Error())
+		return
+	}
+
+	defer func() {
+		errs := tearDown()
+		if len(errs) > 0 {
+			t.Errorf("Errors occured tearing down etcd cluster: %s", errs[0].Error())
+		}
+	}()
+
+	retryInterval, _ := time.ParseDuration("1s")
+	timeouts, _ := time.ParseDuration("10s")
+	retries := uint64(10)
+	cli := setupTestEnv(t, timeouts, retryInterval, retries)
+
+	expired, _, err := cli.AcquireLock(AcquireLockOptions{Key: "/locks/fenced", Ttl: 1})
+	if err != nil {
+		t.Errorf("Error occured acquiring lock: %s", err.Error())
+		return
+	}
+
+	current, _, err := cli.AcquireLock(AcquireLockOptions{Key: "/locks/fenced", Ttl: 60, Timeout: 10 * time.Second, RetryInterval: 100 * time.Millisecond})
+	if err != nil {
+		t.Errorf("Error occured acquiring lock after the previous one expired: %s", err.Error())
+		return
+	}
+
+	if current.Key != "/locks/fenced" || current.FencingToken <= expired.FencingToken {
+		t.Errorf("Expected the fencing token to increase with each acquisition and got %d after %d", current.FencingToken, expired.FencingToken)
+	}
+
+	read, _ := cli.ReadLock("/locks/fenced")
+	if read == nil || read.FencingToken != current.FencingToken || read.Lease != current.Lease {
+		t.Errorf("Expected the read lock to have the fencing token of its holder and got: %v", read)
+	}
+
+	err = cli.ReleaseHeldLock(expired)
+	if !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("Expected releasing an expired lock to return a not held error and got: %v", err)
+	}
+
+	read, _ = cli.ReadLock("/locks/fenced")
+	if read == nil || read.Lease != current.Lease {
+		t.Errorf("Expected releasing an expired lock to leave the current holder's lock as is and it didn't")
+	}
+
+	err = cli.ReleaseHeldLock(current)
+	if err != nil {
+		t.Errorf("Error occured releasing held lock: %s", err.Error())
+	}
+
+	_, err = cli.ReadLock("/locks/fenced")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected released lock not to be found and got: %v", err)
+	}
+
+	select {
+	case <-current.Lost():
+	default:
+		t.Errorf("Expected the context of a released lock to be cancelled and it wasn't")
+	}
+
+	ambiguous, _, err := cli.AcquireLock(AcquireLockOptions{Key: "/locks/fenced", Ttl: 60})
+	if err != nil {
+		t.Errorf("Error occured acquiring lock: %s", err.Error())
+		return
+	}
+
+	kv := cli.Client.KV
+	cli.Client.KV = &ambiguousKV{KV: kv, failures: 1, key: "/locks/fenced"}
+	err = cli.ReleaseHeldLock(ambiguous)
+	cli.Client.KV = kv
+	if err == nil || errors.Is(err, ErrLockNotHeld) || errors.Is(context.Cause(ambiguous.Context()), ErrLockNotHeld) {
+		t.Errorf("Expected an ambiguous error on the release to be returned without retrying it and got: %v", err)
+	}
+}
+
+func TestQueuedLock(t *testing.T) {
+	tearDown, launchErr := testutils.LaunchTestEtcdCluster("../test", testutils.EtcdTestClusterOpts{})
+	if launchErr != nil {
+		t.Errorf("Error occured launching test etcd cluster: %s", launchErr.Error())
+		return
+	}
+
+	defer func() {
+		errs := tearDown()
+		if len(errs) > 0 {
+			t.Errorf("Errors occured tearing down etcd cluster: %s", errs[0].Error())
+		}
+	}()
+
+	retryInterval, _ := time.ParseDuration("1s")
+	timeouts, _ := time.ParseDuration("10s")
+	retries := uint64(10)
+	cli := setupTestEnv(t, timeouts, retryInterval, retries)
+
+	holder, _, err := cli.AcquireLock(AcquireLockOptions{Key: "/locks/queued", Ttl: 60})
+	if err != nil {
+		t.Errorf("Error occured acquiring lock: %s", err.Error())
+		return
+	}
+
+	_, timeout, err := cli.AcquireLock(AcquireLockOptions{Key: "/locks/queued", Timeout: 500 * time.Millisecond, Queued: true})
+	if !timeout || !errors.Is(err, ErrLockTimeout) {
+		t.Errorf("Expected queued acquisition of a held lock to time out and got: %v", err)
+	}
+
+	queue, _ := cli.GetPrefix(lockQueuePrefix("/locks/queued"))
+	if len(queue.Keys) != 0 {
+		t.Errorf("Expected the queue entry of a timed out acquisition to be removed and got: %v", queue.Keys)
+	}
+
+	acquired := make(chan int, 3)
+	for idx := 0; idx < 3; idx++ {
+		go func(idx int) {
+			lock, _, err := cli.AcquireLock(AcquireLockOptions{Key: "/locks/queued", Ttl: 60, Timeout: 20 * time.Second, Queued: true})
+			if err != nil {
+				t.Errorf("Error occured acquiring queued lock: %s", err.Error())
+				acquired <- -1
+				return
+			}
+
+			acquired <- idx
+			time.Sleep(200 * time.Millisecond)
+			err = cli.ReleaseHeldLock(lock)
+			if err != nil {
+				t.Errorf("Error occured releasing queued lock: %s", err.Error())
+			}
+		}(idx)
+
+		//Waits for the acquirer to be in the queue before starting the next one
+		for {
+			queue, _ := cli.GetPrefix(lockQueuePrefix("/locks/queued"))
+			if len(queue.Keys) == idx + 1 {
+				break
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+
+	released := time.Now()
+	cli.ReleaseHeldLock(holder)
+	for idx := 0; idx < 3; idx++ {
+		select {
+		case order := <-acquired:
+			if order != idx {
+				t.Errorf("Expected queued acquirers to get the lock in their order of arrival and acquirer %d got it in position %d", order, idx)
+			}
+		case <-time.After(10 * time.Second):
+			t.Errorf("Expected queued acquirers to get the lock and they didn't")
+			return
+		}
+	}
+
+	//Each acquirer holds the lock for 200ms, so the handovers themselves should be quick
+	if time.Since(released) > 2 * time.Second {
+		t.Errorf("Expected the lock to be handed over promptly and it took %s for 3 acquirers", time.Since(released))
+	}
+
+	go func() {
+		time.Sleep(500 * time.Millisecond)
+		cli.PutKey("/locks/condition", "ok")
+	}()
+	lock, _, err := cli.AcquireLock(AcquireLockOptions{
+		Key:             "/locks/queued",
+		Timeout:         10 * time.Second,
+		RetryInterval:   100 * time.Millisecond,
+		ExtraConditions: []clientv3.Cmp{KeyValueIsCmp("/locks/condition", "=", "ok")},
+		Queued:          true,
+	})
+	if err != nil || lock == nil {
+		t.Errorf("Expected queued acquisition to succeed once the extra conditions are fulfilled and got: %v", err)
+	}
+}
+
+func TestHeldLocksMetrics(t *testing.T) {
+	tearDown, launchErr := testutils.LaunchTestEtcdCluster("../test", testutils.EtcdTestClusterOpts{})
+	if launchErr != nil {
+		t.Errorf("Error occured launching test etcd cluster: %s", launchErr.Error())
+		return
+	}
+
+	defer func() {
+		errs := tearDown()
+		if len(errs) > 0 {
+			t.Errorf("Errors occured tearing down etcd cluster: %s", errs[0].Error())
+		}
+	}()
+
+	retryInterval, _ := time.ParseDuration("1s")
+	timeouts, _ := time.ParseDuration("10s")
+	retries := uint64(10)
+	metrics := &testMetrics{}
+	cli := setupTestEnv(t, timeouts, retryInterval, retries).SetMetrics(metrics)
+
+	otherMetrics := &testMetrics{}
+	other, err := Connect(context.Background(), cli.connOpts)
+	if err != nil {
+		t.Errorf("Error occured connecting other client: %s", err.Error())
+		return
+	}
+	defer other.Close()
+	other = other.SetMetrics(otherMetrics)
+
+	waitHeldLocks := func(expected int64) bool {
+		for i := 0; i < 50 && metrics.heldLocks.Load() != expected; i++ {
+			time.Sleep(100 * time.Millisecond)
+		}
+		return metrics.heldLocks.Load() == expected
+	}
+
+	released, _, err := cli.AcquireLock(AcquireLockOptions{Key: "/locks/released", Ttl: 10})
+	if err != nil {
+		t.Errorf("Error occured acquiring lock: %s", err.Error())
+		return
+	}
+	if metrics.heldLocks.Load() != 1 {
+		t.Errorf("Expected an acquired lock to be counted as held and got %d held locks", metrics.heldLocks.Load())
+	}
+
+	err = cli.ReleaseHeldLock(released)
+	if err != nil {
+		t.Errorf("Error occured releasing lock: %s", err.Error())
+	}
+	if !waitHeldLocks(0) {
+		t.Errorf("Expected a released lock to stop being counted as held and got %d held locks", metrics.heldLocks.Load())
+	}
+
+	expired, _, err := cli.AcquireLock(AcquireLockOptions{Key: "/locks/expired", Ttl: 1})
+	if err != nil {
+		t.Errorf("Error occured acquiring lock: %s", err.Error())
+		return
+	}
+	if !waitHeldLocks(0) {
+		t.Errorf("Expected an expired lock to stop being counted as held and got %d held locks", metrics.heldLocks.Load())
+	}
+
+	err = cli.ReleaseHeldLock(expired)
+	if !errors.Is(err, ErrLockNotHeld) && err != nil {
+		t.Errorf("Error occured releasing expired lock: %s", err.Error())
+	}
+	if metrics.heldLocks.Load() != 0 {
+		t.Errorf("Expected releasing an expired lock not to be counted again and got %d held locks", metrics.heldLocks.Load())
+	}
+
+	_, _, err = cli.AcquireLock(AcquireLockOptions{Key: "/locks/foreign", Ttl: 10, KeepAlive: true})
+	if err != nil {
+		t.Errorf("Error occured acquiring lock: %s", err.Error())
+		return
+	}
+
+	err = other.ReleaseLock("/locks/foreign")
+	if err != nil {
+		t.Errorf("Error occured releasing lock from another client: %s", err.Error())
+	}
+	if otherMetrics.heldLocks.Load() != 0 {
+		t.Errorf("Expected releasing the lock of another client not to change the held locks of the releaser and got %d held locks", otherMetrics.heldLocks.Load())
+	}
+	if !waitHeldLocks(0) {
+		t.Errorf("Expected a lock released by another client to stop being counted as held by its acquirer and got %d held locks", metrics.heldLocks.Load())
+	}
+}
+
+func TestQueuedLockAmbiguousError(t *testing.T) {
+	tearDown, launchErr := testutils.LaunchTestEtcdCluster("../test", testutils.EtcdTestClusterOpts{})
+	if launchErr != nil {
+		t.Errorf("Error occured launching test etcd cluster: %s", launchErr.Error())
+		return
+	}
+
+	defer func() {
+		errs := tearDown()
+		if len(errs) > 0 {
+			t.Errorf("Errors occured tearing down etcd cluster: %s", errs[0].Error())
+		}
+	}()
+
+	retryInterval, _ := time.ParseDuration("1s")
+	timeouts, _ := time.ParseDuration("10s")
+	retries := uint64(10)
+	cli := setupTestEnv(t, timeouts, retryInterval, retries)
+
+	kv := cli.Client.KV
+	defer func() { cli.Client.KV = kv }()
+
+	//The first write of a queued acquisition is its queue entry
+	cli.Client.KV = &ambiguousKV{KV: kv, failures: 1}
+	_, timeout, err := cli.AcquireLock(AcquireLockOptions{Key: "/locks/ambiguous", Ttl: 60, Timeout: 5 * time.Second, Queued: true})
+	if err == nil || timeout {
+		t.Errorf("Expected an ambiguous error on the queue entry to be returned without retrying it and got: %v", err)
+	}
+
+	cli.Client.KV = &ambiguousKV{KV: kv, failures: 1, key: "/locks/ambiguous"}
+	_, timeout, err = cli.AcquireLock(AcquireLockOptions{Key: "/locks/ambiguous", Ttl: 60, Timeout: 5 * time.Second, Queued: true})
+	if err == nil || timeout {
+		t.Errorf("Expected an ambiguous error on the acquisition to be returned without retrying it and got: %v", err)
+	}
+	cli.Client.KV = kv
+
+	queue, _ := cli.GetPrefix(lockQueuePrefix("/locks/ambiguous"))
+	info, _ := cli.GetKey("/locks/ambiguous", GetKeyOptions{})
+	if len(queue.Keys) != 0 || info.Found() {
+		t.Errorf("Expected failed acquisitions to leave neither a queue entry nor a lock behind and got: %v, %v", queue.Keys, info)
+	}
+
+	lock, _, err := cli.AcquireLock(AcquireLockOptions{Key: "/locks/ambiguous", Ttl: 60, Timeout: time.Second, Queued: true})
+	if err != nil {
+		t.Errorf("Expected the lock to be acquired after failed acquisitions and got: %v", err)
+		return
+	}
+	cli.ReleaseHeldLock(lock)
+}
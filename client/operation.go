@@ -0,0 +1,169 @@
+package client
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+/*
+Collector of metrics on the operations of the client.
+See the metrics package for an implementation exposing the metrics to prometheus.
+*/
+type MetricsCollector interface {
+	//Called when a client method returns, with the time it took and the error it returned if any
+	ObserveOperation(operation string, duration time.Duration, err error)
+	//Called when a request to the etcd cluster failed and is retried
+	ObserveRetry(operation string, err error)
+	//Called when a client method returns, with the number of bytes of keys and values it read from and wrote to the etcd cluster
+	ObserveBytes(operation string, read int64, written int64)
+	//Called with 1 when a watch is started and with -1 when it stops
+	AddWatches(delta int64)
+	//Called with 1 when a lock is acquired by the client and with -1 when it is released, expires or is lost
+	AddHeldLocks(delta int64)
+}
+
+/*
+Returns a copy of the EtcdClient instance with a different metrics collector.
+Note that the underlying client connection to the etcd cluster is reused.
+*/
+func (cli *EtcdClient) SetMetrics(metrics MetricsCollector) *EtcdClient {
+	copy := *cli
+	copy.Metrics = metrics
+	return &copy
+}
+
+type operationCtxKey struct{}
+
+/*
+Call of a client method, tracked from the start to the end of the method.
+*/
+type operation struct {
+	//Name and key the requests of the call are reported under, which are those of the outermost call for nested calls
+	Name    string
+	Key     string
+	cli     *EtcdClient
+	start   time.Time
+	span    trace.Span
+	//Set when the method was called by another client method, in which case it is reported as part of the calling method
+	parent  *operation
+	read    atomic.Int64
+	written atomic.Int64
+}
+
+/*
+Starts tracking a call of a client method.
+The returned context should be used for the requests of the method so that they are reported under its name.
+If the context already tracks a call of a calling method, the returned operation is nested and its requests are reported under the calling method.
+If the client has a tracer provider, a span is started for the call, as a child of the span in the context if any.
+*/
+func (cli *EtcdClient) startOperation(ctx context.Context, name string, key string) (context.Context, *operation) {
+	op := &operation{Name: name, Key: key, cli: cli, start: time.Now(), span: noop.Span{}}
+	if parent, ok := ctx.Value(operationCtxKey{}).(*operation); ok {
+		op.Name = parent.Name
+		op.Key = parent.Key
+		op.parent = parent
+	}
+
+	if cli.TracerProvider != nil {
+		attrs := []attribute.KeyValue{}
+		if key != "" {
+			attrs = append(attrs, attribute.String("etcd.key", key))
+		}
+		ctx, op.span = cli.tracer().Start(ctx, "EtcdClient."+name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	}
+
+	return context.WithValue(ctx, operationCtxKey{}, op), op
+}
+
+/*
+Returns the call of a client method tracked by the context.
+The returned operation is empty, but safe to use, if the context does not track any.
+*/
+func getOperation(ctx context.Context) *operation {
+	if op, ok := ctx.Value(operationCtxKey{}).(*operation); ok {
+		return op
+	}
+
+	return &operation{span: noop.Span{}}
+}
+
+/*
+Adds to the bytes of keys and values read from and written to the etcd cluster by the operation.
+*/
+func (op *operation) addBytes(read int64, written int64) {
+	if op.parent != nil {
+		op.parent.addBytes(read, written)
+		return
+	}
+
+	op.read.Add(read)
+	op.written.Add(written)
+}
+
+/*
+Records the store revision the operation read from or wrote at.
+*/
+func (op *operation) setRevision(revision int64) {
+	op.span.SetAttributes(attribute.Int64("etcd.revision", revision))
+}
+
+/*
+Records the outcome of a transaction made by the operation.
+*/
+func (op *operation) setTxnSucceeded(succeeded bool) {
+	op.span.SetAttributes(attribute.Bool("etcd.txn.succeeded", succeeded))
+}
+
+/*
+Starts a span for a step of a multi-step operation, as a child of the operation's span.
+The returned span does nothing if the client has no tracer provider.
+*/
+func (op *operation) startStep(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if op.cli == nil || op.cli.TracerProvider == nil {
+		return ctx, noop.Span{}
+	}
+
+	return op.cli.tracer().Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+/*
+Ends the tracking of a call of a client method, ending its span and reporting it to the metrics collector of the client.
+*/
+func (op *operation) end(err error) {
+	endSpan(op.span, err)
+
+	if op.parent != nil || op.cli == nil || op.cli.Metrics == nil {
+		return
+	}
+
+	op.cli.Metrics.ObserveBytes(op.Name, op.read.Load(), op.written.Load())
+	op.cli.Metrics.ObserveOperation(op.Name, time.Since(op.start), err)
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+/*
+Returns a copy of the EtcdClient instance with a different tracer provider.
+Note that the underlying client connection to the etcd cluster is reused.
+*/
+func (cli *EtcdClient) SetTracerProvider(provider trace.TracerProvider) *EtcdClient {
+	copy := *cli
+	copy.TracerProvider = provider
+	return &copy
+}
+
+func (cli *EtcdClient) tracer() trace.Tracer {
+	return cli.TracerProvider.Tracer("github.com/Ferlab-Ste-Justine/etcd-sdk/client")
+}
@@ -0,0 +1,126 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type testMetrics struct {
+	lock       sync.Mutex
+	operations []string
+	errors     []error
+	read       int64
+	written    int64
+	heldLocks  atomic.Int64
+}
+
+func (m *testMetrics) ObserveOperation(operation string, duration time.Duration, err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.operations = append(m.operations, operation)
+	m.errors = append(m.errors, err)
+}
+
+func (m *testMetrics) ObserveRetry(operation string, err error) {}
+
+func (m *testMetrics) ObserveBytes(operation string, read int64, written int64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.read += read
+	m.written += written
+}
+
+func (m *testMetrics) AddWatches(delta int64) {}
+
+func (m *testMetrics) AddHeldLocks(delta int64) {
+	m.heldLocks.Add(delta)
+}
+
+func TestOperationMetrics(t *testing.T) {
+	metrics := &testMetrics{}
+	cli := (&EtcdClient{}).SetMetrics(metrics)
+
+	ctx, op := cli.startOperation(context.Background(), "PutChunkedKey", "test")
+	nestedCtx, nested := cli.startOperation(ctx, "PutKey", "test/chunks/v1/0")
+	getOperation(nestedCtx).addBytes(0, 10)
+	nested.end(nil)
+	op.addBytes(5, 0)
+
+	if len(metrics.operations) != 0 {
+		t.Errorf("Expected nested operations not to be reported on their own and got: %v", metrics.operations)
+	}
+
+	opErr := errors.New("Failure")
+	op.end(opErr)
+
+	if len(metrics.operations) != 1 || metrics.operations[0] != "PutChunkedKey" || metrics.errors[0] != opErr {
+		t.Errorf("Expected the outermost operation to be reported with its error and got: %v", metrics.operations)
+	}
+	if metrics.read != 5 || metrics.written != 10 {
+		t.Errorf("Expected the bytes of nested operations to be reported with the outermost operation and got %d read and %d written", metrics.read, metrics.written)
+	}
+}
+
+func TestOperationSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	cli := (&EtcdClient{Retries: 3, RetryInterval: time.Millisecond}).SetTracerProvider(provider)
+
+	ctx, op := cli.startOperation(context.Background(), "PutChunkedKey", "test")
+	nestedCtx, nested := cli.startOperation(ctx, "PutKey", "test/chunks/v1/0")
+	attempts := 0
+	cli.withRetries(nestedCtx, func() error {
+		attempts += 1
+		if attempts < 2 {
+			return status.Error(grpccodes.Unavailable, "unavailable")
+		}
+		return nil
+	})
+	nested.setRevision(10)
+	nested.end(nil)
+
+	_, step := op.startStep(ctx, "etcd.Txn")
+	endSpan(step, nil)
+	op.setTxnSucceeded(false)
+	op.end(ErrTransactionFailed)
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Errorf("Expected 3 spans and got %d", len(spans))
+		return
+	}
+
+	putKey, txn, putChunkedKey := spans[0], spans[1], spans[2]
+	if putKey.Name() != "EtcdClient.PutKey" || putChunkedKey.Name() != "EtcdClient.PutChunkedKey" || txn.Name() != "etcd.Txn" {
+		t.Errorf("Expected spans to be named after the operations and got %s, %s and %s", putKey.Name(), txn.Name(), putChunkedKey.Name())
+	}
+	if putKey.Parent().SpanID() != putChunkedKey.SpanContext().SpanID() || txn.Parent().SpanID() != putChunkedKey.SpanContext().SpanID() {
+		t.Errorf("Expected nested operations and steps to be children of the outermost operation's span and they weren't")
+	}
+
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, attr := range putKey.Attributes() {
+		attrs[attr.Key] = attr.Value
+	}
+	if attrs["etcd.key"].AsString() != "test/chunks/v1/0" || attrs["etcd.revision"].AsInt64() != 10 || attrs["etcd.attempts"].AsInt64() != 2 {
+		t.Errorf("Expected key, revision and attempts attributes on the span and got: %v", attrs)
+	}
+	if len(putKey.Events()) != 1 || putKey.Events()[0].Name != "retry" {
+		t.Errorf("Expected a retry event on the span and got: %v", putKey.Events())
+	}
+
+	if putChunkedKey.Status().Code != codes.Error {
+		t.Errorf("Expected the failed operation's span to have an error status and it didn't")
+	}
+}
@@ -0,0 +1,148 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+/*
+Read-only view of the etcd store at a given revision, for consistent reads across several calls.
+It should be instanciated with the ReadView or ReadViewAt methods of the client.
+Once etcd is compacted past the view's revision, its methods return an error matching ErrRevisionCompacted.
+*/
+type ReadView struct {
+	cli      *EtcdClient
+	//Revision of the etcd store the view reads from
+	Revision int64
+}
+
+/*
+Returns a view of the etcd store at its current revision.
+*/
+func (cli *EtcdClient) ReadView() (*ReadView, error) {
+	return cli.ReadViewCtx(cli.Context)
+}
+
+/*
+Variant of ReadView that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) ReadViewCtx(ctx context.Context) (_ *ReadView, err error) {
+	ctx, op := cli.startOperation(ctx, "ReadView", "")
+	defer func() { op.end(err) }()
+
+	//Any key can be counted to get the revision in the header of the response
+	revision, err := cli.getCurrentRevision(ctx, "\x00")
+	if err != nil {
+		return nil, err
+	}
+
+	op.setRevision(revision)
+	return cli.ReadViewAt(revision), nil
+}
+
+/*
+Returns a view of the etcd store at a given revision.
+*/
+func (cli *EtcdClient) ReadViewAt(revision int64) *ReadView {
+	return &ReadView{cli: cli, Revision: revision}
+}
+
+func (view *ReadView) wrapErr(err error) error {
+	if errors.Is(err, rpctypes.ErrCompacted) {
+		return fmt.Errorf("%w: Read view at revision %d is no longer available: %w", ErrRevisionCompacted, view.Revision, err)
+	}
+
+	return err
+}
+
+/*
+Get information on the given key, at the revision of the view.
+*/
+func (view *ReadView) GetKey(key string) (KeyInfo, error) {
+	return view.GetKeyCtx(view.cli.Context, key)
+}
+
+/*
+Variant of GetKey that takes a context to cancel the operation or set a deadline on it.
+*/
+func (view *ReadView) GetKeyCtx(ctx context.Context, key string) (KeyInfo, error) {
+	info, err := view.cli.GetKeyCtx(ctx, key, GetKeyOptions{Revision: view.Revision})
+	return info, view.wrapErr(err)
+}
+
+/*
+Variant of GetKey for binary values.
+*/
+func (view *ReadView) GetKeyBytes(key string) (KeyBytesInfo, error) {
+	return view.GetKeyBytesCtx(view.cli.Context, key)
+}
+
+/*
+Variant of GetKeyBytes that takes a context to cancel the operation or set a deadline on it.
+*/
+func (view *ReadView) GetKeyBytesCtx(ctx context.Context, key string) (KeyBytesInfo, error) {
+	info, err := view.cli.GetKeyBytesCtx(ctx, key, GetKeyOptions{Revision: view.Revision})
+	return info, view.wrapErr(err)
+}
+
+/*
+Get all the keys within a certain range of values, at the revision of the view.
+*/
+func (view *ReadView) GetKeyRange(key string, rangeEnd string) (KeyRangeInfo, error) {
+	return view.GetKeyRangeCtx(view.cli.Context, key, rangeEnd)
+}
+
+/*
+Variant of GetKeyRange that takes a context to cancel the operation or set a deadline on it.
+*/
+func (view *ReadView) GetKeyRangeCtx(ctx context.Context, key string, rangeEnd string) (_ KeyRangeInfo, err error) {
+	ctx, op := view.cli.startOperation(ctx, "GetKeyRange", key)
+	defer func() { op.end(err) }()
+
+	info, err := view.cli.getKeyRangeBytes(ctx, op, key, rangeEnd, view.Revision)
+	return info.ToKeyRangeInfo(), view.wrapErr(err)
+}
+
+/*
+Get all the keys that are prefixed by a given value, at the revision of the view.
+*/
+func (view *ReadView) GetPrefix(prefix string) (KeyRangeInfo, error) {
+	return view.GetPrefixCtx(view.cli.Context, prefix)
+}
+
+/*
+Variant of GetPrefix that takes a context to cancel the operation or set a deadline on it.
+*/
+func (view *ReadView) GetPrefixCtx(ctx context.Context, prefix string) (_ KeyRangeInfo, err error) {
+	ctx, op := view.cli.startOperation(ctx, "GetPrefix", prefix)
+	defer func() { op.end(err) }()
+
+	info, err := view.cli.getKeyRangeBytes(ctx, op, prefix, clientv3.GetPrefixRangeEnd(prefix), view.Revision)
+	return info.ToKeyRangeInfo(), view.wrapErr(err)
+}
+
+/*
+Returns a KeyDiff structure containing all the operations that would need to be applied on the destination prefix to make it like the source prefix, at the revision of the view.
+*/
+func (view *ReadView) DiffBetweenPrefixes(srcPrefix string, dstPrefix string) (KeyDiff, error) {
+	return view.DiffBetweenPrefixesCtx(view.cli.Context, srcPrefix, dstPrefix)
+}
+
+/*
+Variant of DiffBetweenPrefixes that takes a context to cancel the operation or set a deadline on it.
+*/
+func (view *ReadView) DiffBetweenPrefixesCtx(ctx context.Context, srcPrefix string, dstPrefix string) (_ KeyDiff, err error) {
+	ctx, op := view.cli.startOperation(ctx, "DiffBetweenPrefixes", srcPrefix)
+	defer func() { op.end(err) }()
+
+	diff, err := view.cli.diffBetweenPrefixes(ctx, op, srcPrefix, dstPrefix, view.Revision)
+	if err != nil {
+		return KeyDiff{}, view.wrapErr(err)
+	}
+
+	return diff.ToStrings(), nil
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Ferlab-Ste-Justine/etcd-sdk/testutils"
+)
+
+func TestReadView(t *testing.T) {
+	tearDown, launchErr := testutils.LaunchTestEtcdCluster("../test", testutils.EtcdTestClusterOpts{})
+	if launchErr != nil {
+		t.Errorf("Error occured launching test etcd cluster: %s", launchErr.Error())
+		return
+	}
+
+	defer func() {
+		errs := tearDown()
+		if len(errs) > 0 {
+			t.Errorf("Errors occured tearing down etcd cluster: %s", errs[0].Error())
+		}
+	}()
+
+	retryInterval, _ := time.ParseDuration("1s")
+	timeouts, _ := time.ParseDuration("10s")
+	retries := uint64(10)
+	cli := setupTestEnv(t, timeouts, retryInterval, retries)
+
+	cli.PutKey("/view/src/a", "a")
+	cli.PutKey("/view/src/b", "b")
+	cli.PutKey("/view/dst/a", "a")
+	rev, _ := cli.PutKey("/view/key", "v1")
+
+	view, err := cli.ReadView()
+	if err != nil {
+		t.Errorf("Error occured creating read view: %s", err.Error())
+		return
+	}
+	if view.Revision != rev {
+		t.Errorf("Expected read view to be at the current revision %d and got %d", rev, view.Revision)
+	}
+
+	cli.PutKey("/view/key", "v2")
+	cli.PutKey("/view/src/c", "c")
+	cli.ApplyDiffToPrefix("/view/dst/", KeyDiff{Inserts: map[string]string{"b": "b"}})
+
+	info, err := view.GetKey("/view/key")
+	if err != nil || info.Value != "v1" {
+		t.Errorf("Expected read view to return the value of the key at its revision and got: %s", info.Value)
+	}
+
+	src, err := view.GetPrefix("/view/src/")
+	if err != nil || len(src.Keys) != 2 || src.Revision != rev {
+		t.Errorf("Expected read view to return the keys of the prefix at its revision and got %d keys at revision %d", len(src.Keys), src.Revision)
+	}
+
+	keys, err := view.GetKeyRange("/view/dst/", "/view/dst/z")
+	if err != nil || len(keys.Keys) != 1 {
+		t.Errorf("Expected read view to return the keys of the range at its revision and got %d keys", len(keys.Keys))
+	}
+
+	diff, err := view.DiffBetweenPrefixes("/view/src/", "/view/dst/")
+	if err != nil || len(diff.Inserts) != 1 || diff.Inserts["b"] != "b" || len(diff.Updates) != 0 || len(diff.Deletions) != 0 {
+		t.Errorf("Expected read view to diff the prefixes at its revision and got: %v", diff)
+	}
+
+	current, _ := cli.GetKey("/view/key", GetKeyOptions{})
+	_, err = cli.Client.Compact(cli.Context, current.ModRevision)
+	if err != nil {
+		t.Errorf("Error occured compacting etcd: %s", err.Error())
+		return
+	}
+
+	_, err = view.GetKey("/view/key")
+	if !errors.Is(err, ErrRevisionCompacted) {
+		t.Errorf("Expected read view to return a compaction error after its revision was compacted and got: %v", err)
+	}
+
+	_, err = view.GetPrefix("/view/src/")
+	if !errors.Is(err, ErrRevisionCompacted) {
+		t.Errorf("Expected read view to return a compaction error after its revision was compacted and got: %v", err)
+	}
+}
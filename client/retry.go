@@ -0,0 +1,199 @@
+package client
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+/*
+Policy determining whether and when failed requests to the etcd cluster are retried.
+*/
+type RetryPolicy interface {
+	//Returns whether an error is transient and the request that caused it should be retried
+	IsRetryable(err error) bool
+	//Returns how long to wait before the next attempt, given the number of attempts made so far and the time elapsed since the first one.
+	//The second return value is false if no further attempt should be made.
+	NextInterval(attempts uint64, elapsed time.Duration) (time.Duration, bool)
+}
+
+/*
+Retry policy that waits a fixed interval between attempts.
+It is the policy used by default with the Retries and RetryInterval values of the client.
+*/
+type FixedRetryPolicy struct {
+	//Number of retries to attempt before giving up
+	Retries   uint64
+	//Interval of time to wait between attempts
+	Interval  time.Duration
+	//Classifier for retryable errors. If nil, the ErrorIsRetryable function is used.
+	Retryable func(err error) bool
+}
+
+func (p FixedRetryPolicy) IsRetryable(err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+
+	return ErrorIsRetryable(err)
+}
+
+func (p FixedRetryPolicy) NextInterval(attempts uint64, elapsed time.Duration) (time.Duration, bool) {
+	if attempts > p.Retries {
+		return 0, false
+	}
+
+	return p.Interval, true
+}
+
+/*
+Retry policy that increases the wait between attempts exponentially, with optional random jitter.
+The jitter spreads out the retries of clients that failed at the same time, during leader elections for example.
+*/
+type ExponentialRetryPolicy struct {
+	//Number of retries to attempt before giving up
+	Retries         uint64
+	//Interval of time to wait before the first retry
+	InitialInterval time.Duration
+	//Upper bound on the interval of time to wait between attempts. Ignored if 0.
+	MaxInterval     time.Duration
+	//Factor by which the interval is multiplied after each attempt. Defaults to 2 if lower or equal to 1.
+	Multiplier      float64
+	//Fraction of the interval, between 0 and 1, by which the interval is randomly shortened
+	Jitter          float64
+	//Time after the first attempt past which no more retries will be attempted. Ignored if 0.
+	MaxElapsedTime  time.Duration
+	//Classifier for retryable errors. If nil, the ErrorIsRetryable function is used.
+	Retryable       func(err error) bool
+}
+
+func (p ExponentialRetryPolicy) IsRetryable(err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+
+	return ErrorIsRetryable(err)
+}
+
+func (p ExponentialRetryPolicy) NextInterval(attempts uint64, elapsed time.Duration) (time.Duration, bool) {
+	if attempts > p.Retries {
+		return 0, false
+	}
+
+	multiplier := p.Multiplier
+	if multiplier <= 1 {
+		multiplier = 2
+	}
+
+	interval := float64(p.InitialInterval)
+	for idx := uint64(1); idx < attempts; idx++ {
+		interval = interval * multiplier
+		if p.MaxInterval > 0 && interval >= float64(p.MaxInterval) {
+			interval = float64(p.MaxInterval)
+			break
+		}
+	}
+
+	if p.Jitter > 0 {
+		jitter := p.Jitter
+		if jitter > 1 {
+			jitter = 1
+		}
+		interval = interval * (1 - jitter*rand.Float64())
+	}
+
+	if p.MaxElapsedTime > 0 && elapsed+time.Duration(interval) > p.MaxElapsedTime {
+		return 0, false
+	}
+
+	return time.Duration(interval), true
+}
+
+/*
+Returns a copy of the EtcdClient instance with a different retry policy.
+Useful to override the retry policy of the client for specific calls.
+Note that the underlying client connection to the etcd cluster is reused.
+*/
+func (cli *EtcdClient) SetRetryPolicy(policy RetryPolicy) *EtcdClient {
+	copy := *cli
+	copy.RetryPolicy = policy
+	return &copy
+}
+
+func (cli *EtcdClient) getRetryPolicy() RetryPolicy {
+	if cli.RetryPolicy != nil {
+		return cli.RetryPolicy
+	}
+
+	return FixedRetryPolicy{
+		Retries:  cli.Retries,
+		Interval: cli.RetryInterval,
+	}
+}
+
+/*
+Runs an operation against the etcd cluster, retrying it according to the client's retry policy.
+The waits between attempts are interrupted if the context is cancelled, in which case the context's error is returned.
+Each attempt is logged and reported to the client's hooks under the operation tracked by the context. Retries are also recorded as events of the operation's span.
+*/
+func (cli *EtcdClient) withRetries(ctx context.Context, fn func() error) error {
+	policy := cli.getRetryPolicy()
+	op := getOperation(ctx)
+	start := time.Now()
+	attempts := uint64(0)
+	for {
+		attempts += 1
+		cli.onAttempt(ctx, AttemptInfo{Operation: op.Name, Key: op.Key, Attempt: attempts, Elapsed: time.Since(start)})
+
+		err := fn()
+		op.span.SetAttributes(attribute.Int64("etcd.attempts", int64(attempts)))
+		if err == nil {
+			cli.onSuccess(ctx, AttemptInfo{Operation: op.Name, Key: op.Key, Attempt: attempts, Elapsed: time.Since(start)})
+			return nil
+		}
+
+		info := AttemptInfo{Operation: op.Name, Key: op.Key, Attempt: attempts, Error: err, Elapsed: time.Since(start)}
+		if !policy.IsRetryable(err) {
+			cli.onError(ctx, info)
+			return err
+		}
+
+		interval, retry := policy.NextInterval(attempts, info.Elapsed)
+		if !retry {
+			cli.onError(ctx, info)
+			return err
+		}
+
+		info.Interval = interval
+		cli.onRetry(ctx, info)
+		op.span.AddEvent("retry", trace.WithAttributes(
+			attribute.Int64("etcd.attempt", int64(attempts)),
+			attribute.String("etcd.retry.interval", interval.String()),
+			attribute.String("error", err.Error()),
+		))
+
+		waitErr := sleepCtx(ctx, interval)
+		if waitErr != nil {
+			cli.onError(ctx, AttemptInfo{Operation: op.Name, Key: op.Key, Attempt: attempts, Error: waitErr, Elapsed: time.Since(start)})
+			return waitErr
+		}
+	}
+}
+
+/*
+Waits for the given duration, returning early with the context's error if the context is cancelled first.
+*/
+func sleepCtx(ctx context.Context, duration time.Duration) error {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,150 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestFixedRetryPolicy(t *testing.T) {
+	policy := FixedRetryPolicy{Retries: 3, Interval: time.Second}
+
+	for attempts := uint64(1); attempts <= 3; attempts++ {
+		interval, retry := policy.NextInterval(attempts, 0)
+		if !retry {
+			t.Errorf("Expected fixed retry policy to retry after attempt %d and it didn't", attempts)
+		}
+		if interval != time.Second {
+			t.Errorf("Expected fixed retry policy to wait 1s after attempt %d and got: %s", attempts, interval)
+		}
+	}
+
+	_, retry := policy.NextInterval(4, 0)
+	if retry {
+		t.Errorf("Expected fixed retry policy to stop after its retries were exhausted and it didn't")
+	}
+}
+
+func TestExponentialRetryPolicy(t *testing.T) {
+	policy := ExponentialRetryPolicy{
+		Retries:         10,
+		InitialInterval: 100 * time.Millisecond,
+		MaxInterval:     time.Second,
+		Multiplier:      2,
+	}
+
+	expected := []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+		time.Second,
+	}
+	for idx, expectedInterval := range expected {
+		interval, retry := policy.NextInterval(uint64(idx+1), 0)
+		if !retry {
+			t.Errorf("Expected exponential retry policy to retry after attempt %d and it didn't", idx+1)
+		}
+		if interval != expectedInterval {
+			t.Errorf("Expected exponential retry policy to wait %s after attempt %d and got: %s", expectedInterval, idx+1, interval)
+		}
+	}
+
+	_, retry := policy.NextInterval(11, 0)
+	if retry {
+		t.Errorf("Expected exponential retry policy to stop after its retries were exhausted and it didn't")
+	}
+
+	policy.MaxElapsedTime = 2 * time.Second
+	_, retry = policy.NextInterval(5, 1500*time.Millisecond)
+	if retry {
+		t.Errorf("Expected exponential retry policy to stop when the next attempt would exceed the max elapsed time and it didn't")
+	}
+
+	policy.MaxElapsedTime = 0
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		interval, _ := policy.NextInterval(2, 0)
+		if interval < 100*time.Millisecond || interval > 200*time.Millisecond {
+			t.Errorf("Expected jittered interval to be between 100ms and 200ms and got: %s", interval)
+		}
+	}
+}
+
+func TestWithRetries(t *testing.T) {
+	cli := &EtcdClient{Retries: 3, RetryInterval: time.Millisecond}
+
+	attempts := 0
+	err := cli.withRetries(context.Background(), func() error {
+		attempts += 1
+		return status.Error(codes.Unavailable, "unavailable")
+	})
+	if err == nil {
+		t.Errorf("Expected the last error to be returned when retries are exhausted and got none")
+	}
+	if attempts != 4 {
+		t.Errorf("Expected 4 attempts with 3 retries and got %d", attempts)
+	}
+
+	attempts = 0
+	err = cli.withRetries(context.Background(), func() error {
+		attempts += 1
+		return errors.New("Not retryable")
+	})
+	if err == nil || attempts != 1 {
+		t.Errorf("Expected a non-retryable error to be returned after a single attempt and got %d attempts", attempts)
+	}
+
+	attempts = 0
+	err = cli.SetRetryPolicy(FixedRetryPolicy{
+		Retries:   1,
+		Interval:  time.Millisecond,
+		Retryable: func(err error) bool { return true },
+	}).withRetries(context.Background(), func() error {
+		attempts += 1
+		return errors.New("Retryable with custom classifier")
+	})
+	if err == nil || attempts != 2 {
+		t.Errorf("Expected the overriden retry policy to make 2 attempts and got %d", attempts)
+	}
+
+	attempts = 0
+	err = cli.withRetries(context.Background(), func() error {
+		attempts += 1
+		if attempts < 3 {
+			return status.Error(codes.Unavailable, "unavailable")
+		}
+		return nil
+	})
+	if err != nil || attempts != 3 {
+		t.Errorf("Expected the operation to succeed on the third attempt and got %d attempts", attempts)
+	}
+}
+
+func TestWithRetriesCancellation(t *testing.T) {
+	cli := &EtcdClient{Retries: 10, RetryInterval: time.Hour}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	attempts := 0
+	start := time.Now()
+	err := cli.withRetries(ctx, func() error {
+		attempts += 1
+		return status.Error(codes.Unavailable, "unavailable")
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the context error to be returned when the context expires during a retry wait and got: %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("Expected a single attempt before the context expired and got %d", attempts)
+	}
+	if time.Since(start) > 10*time.Second {
+		t.Errorf("Expected the retry wait to be interrupted when the context expired and it wasn't")
+	}
+}
@@ -2,9 +2,7 @@ package client
 
 import (
 	"context"
-	"errors"
 	"fmt"
-	"time"
 
 	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	clientv3 "go.etcd.io/etcd/client/v3"
@@ -41,107 +39,129 @@ func permissionEnumToPerm(perm clientv3.PermissionType) string {
 	}
 }
 
-func (cli *EtcdClient) listRolesWithRetries(retries uint64) ([]string, error) {
-	ctx, cancel := context.WithTimeout(cli.Context, cli.RequestTimeout)
-	defer cancel()
-
-	res, err := cli.Client.RoleList(ctx)
-	if err != nil {
-		if !shouldRetry(err, retries) {
-			return []string{}, err
-		}
-
-		time.Sleep(cli.RetryInterval)
-		return cli.listRolesWithRetries(retries - 1)
-	}
-
-	return res.Roles, nil
-}
-
 func (cli *EtcdClient) ListRoles() ([]string, error) {
-	return cli.listRolesWithRetries(cli.Retries)
+	return cli.ListRolesCtx(cli.Context)
 }
 
-func (cli *EtcdClient) insertEmptyRoleWithRetries(name string, retries uint64) error {
-	ctx, cancel := context.WithTimeout(cli.Context, cli.RequestTimeout)
-	defer cancel()
+/*
+Variant of ListRoles that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) ListRolesCtx(ctx context.Context) (_ []string, err error) {
+	ctx, op := cli.startOperation(ctx, "ListRoles", "")
+	defer func() { op.end(err) }()
 
-	_, err := cli.Client.RoleAdd(ctx, name)
-	if err != nil {
-		if !shouldRetry(err, retries) {
+	roles := []string{}
+	err = cli.withRetries(ctx, func() error {
+		ctx, cancel := context.WithTimeout(ctx, cli.RequestTimeout)
+		defer cancel()
+
+		res, err := cli.Client.RoleList(ctx)
+		if err != nil {
 			return err
 		}
 
-		time.Sleep(cli.RetryInterval)
-		return cli.insertEmptyRoleWithRetries(name, retries-1)
-	}
+		roles = res.Roles
+		return nil
+	})
 
-	return nil
+	return roles, err
 }
 
 func (cli *EtcdClient) InsertEmptyRole(name string) error {
-	return cli.insertEmptyRoleWithRetries(name, cli.Retries)
+	return cli.InsertEmptyRoleCtx(cli.Context, name)
 }
 
-func (cli *EtcdClient) grantRolePermissionWithRetries(name string, permission EtcdRolePermission, retries uint64) error {
-	ctx, cancel := context.WithTimeout(cli.Context, cli.RequestTimeout)
-	defer cancel()
+/*
+Variant of InsertEmptyRole that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) InsertEmptyRoleCtx(ctx context.Context, name string) (err error) {
+	ctx, op := cli.startOperation(ctx, "InsertEmptyRole", "")
+	defer func() { op.end(err) }()
 
-	_, err := cli.Client.RoleGrantPermission(ctx, name, permission.Key, permission.RangeEnd, permissionToEnum(permission.Permission))
-	if err != nil {
-		if !shouldRetry(err, retries) {
-			return err
-		}
-
-		time.Sleep(cli.RetryInterval)
-		return cli.grantRolePermissionWithRetries(name, permission, retries-1)
-	}
+	return cli.withRetries(ctx, func() error {
+		ctx, cancel := context.WithTimeout(ctx, cli.RequestTimeout)
+		defer cancel()
 
-	return nil
+		_, err := cli.Client.RoleAdd(ctx, name)
+		return err
+	})
 }
 
 func (cli *EtcdClient) GrantRolePermission(name string, permission EtcdRolePermission) error {
-	return cli.grantRolePermissionWithRetries(name, permission, cli.Retries)
+	return cli.GrantRolePermissionCtx(cli.Context, name, permission)
 }
 
-func (cli *EtcdClient) revokeRolePermissionWithRetries(name string, key string, rangeEnd string, retries uint64) error {
-	ctx, cancel := context.WithTimeout(cli.Context, cli.RequestTimeout)
-	defer cancel()
-
-	_, err := cli.Client.RoleRevokePermission(ctx, name, key, rangeEnd)
-	if err != nil {
-		if !shouldRetry(err, retries) {
-			return err
-		}
+/*
+Variant of GrantRolePermission that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) GrantRolePermissionCtx(ctx context.Context, name string, permission EtcdRolePermission) (err error) {
+	ctx, op := cli.startOperation(ctx, "GrantRolePermission", "")
+	defer func() { op.end(err) }()
 
-		time.Sleep(cli.RetryInterval)
-		return cli.revokeRolePermissionWithRetries(name, key, rangeEnd, retries-1)
-	}
+	return cli.withRetries(ctx, func() error {
+		ctx, cancel := context.WithTimeout(ctx, cli.RequestTimeout)
+		defer cancel()
 
-	return nil
+		_, err := cli.Client.RoleGrantPermission(ctx, name, permission.Key, permission.RangeEnd, permissionToEnum(permission.Permission))
+		return err
+	})
 }
 
 func (cli *EtcdClient) RevokeRolePermission(name string, key string, rangeEnd string) error {
-	return cli.revokeRolePermissionWithRetries(name, key, rangeEnd, cli.Retries)
+	return cli.RevokeRolePermissionCtx(cli.Context, name, key, rangeEnd)
 }
 
-func (cli *EtcdClient) getRolePermissionsWithRetries(name string, retries uint64) ([]EtcdRolePermission, bool, error) {
-	ctx, cancel := context.WithTimeout(cli.Context, cli.RequestTimeout)
-	defer cancel()
+/*
+Variant of RevokeRolePermission that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) RevokeRolePermissionCtx(ctx context.Context, name string, key string, rangeEnd string) (err error) {
+	ctx, op := cli.startOperation(ctx, "RevokeRolePermission", key)
+	defer func() { op.end(err) }()
 
-	res, err := cli.Client.RoleGet(ctx, name)
-	if err != nil {
-		etcdErr, ok := err.(rpctypes.EtcdError)
-		if ok && etcdErr.Error() == rpctypes.ErrorDesc(rpctypes.ErrGRPCRoleNotFound) {
-			return []EtcdRolePermission{}, false, nil
-		}
+	return cli.withRetries(ctx, func() error {
+		ctx, cancel := context.WithTimeout(ctx, cli.RequestTimeout)
+		defer cancel()
+
+		_, err := cli.Client.RoleRevokePermission(ctx, name, key, rangeEnd)
+		return err
+	})
+}
 
-		if !shouldRetry(err, retries) {
-			return []EtcdRolePermission{}, false, err
+func (cli *EtcdClient) GetRolePermissions(name string) ([]EtcdRolePermission, bool, error) {
+	return cli.GetRolePermissionsCtx(cli.Context, name)
+}
+
+/*
+Variant of GetRolePermissions that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) GetRolePermissionsCtx(ctx context.Context, name string) (_ []EtcdRolePermission, _ bool, err error) {
+	ctx, op := cli.startOperation(ctx, "GetRolePermissions", "")
+	defer func() { op.end(err) }()
+
+	var res *clientv3.AuthRoleGetResponse
+	err = cli.withRetries(ctx, func() error {
+		ctx, cancel := context.WithTimeout(ctx, cli.RequestTimeout)
+		defer cancel()
+
+		var err error
+		res, err = cli.Client.RoleGet(ctx, name)
+		if err != nil {
+			etcdErr, ok := err.(rpctypes.EtcdError)
+			if ok && etcdErr.Error() == rpctypes.ErrorDesc(rpctypes.ErrGRPCRoleNotFound) {
+				res = nil
+				return nil
+			}
 		}
 
-		time.Sleep(cli.RetryInterval)
-		return cli.getRolePermissionsWithRetries(name, retries-1)
+		return err
+	})
+
+	if err != nil {
+		return []EtcdRolePermission{}, false, err
+	}
+
+	if res == nil {
+		return []EtcdRolePermission{}, false, nil
 	}
 
 	result := make([]EtcdRolePermission, len(res.Perm))
@@ -157,41 +177,46 @@ func (cli *EtcdClient) getRolePermissionsWithRetries(name string, retries uint64
 	return result, true, nil
 }
 
-func (cli *EtcdClient) GetRolePermissions(name string) ([]EtcdRolePermission, bool, error) {
-	return cli.getRolePermissionsWithRetries(name, cli.Retries)
+func (cli *EtcdClient) DeleteRole(name string) error {
+	return cli.DeleteRoleCtx(cli.Context, name)
 }
 
-func (cli *EtcdClient) deleteRoleWithRetries(name string, retries uint64) error {
-	ctx, cancel := context.WithTimeout(cli.Context, cli.RequestTimeout)
-	defer cancel()
+/*
+Variant of DeleteRole that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) DeleteRoleCtx(ctx context.Context, name string) (err error) {
+	ctx, op := cli.startOperation(ctx, "DeleteRole", "")
+	defer func() { op.end(err) }()
 
-	_, err := cli.Client.RoleDelete(ctx, name)
-	if err != nil {
-		if !shouldRetry(err, retries) {
-			return err
-		}
-
-		time.Sleep(cli.RetryInterval)
-		return cli.deleteRoleWithRetries(name, retries-1)
-	}
+	return cli.withRetries(ctx, func() error {
+		ctx, cancel := context.WithTimeout(ctx, cli.RequestTimeout)
+		defer cancel()
 
-	return nil
+		_, err := cli.Client.RoleDelete(ctx, name)
+		return err
+	})
 }
 
-func (cli *EtcdClient) DeleteRole(name string) error {
-	return cli.deleteRoleWithRetries(name, cli.Retries)
+func (cli *EtcdClient) InsertRole(role EtcdRole) error {
+	return cli.InsertRoleCtx(cli.Context, role)
 }
 
-func (cli *EtcdClient) InsertRole(role EtcdRole) error {
-	err := cli.InsertEmptyRole(role.Name)
+/*
+Variant of InsertRole that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) InsertRoleCtx(ctx context.Context, role EtcdRole) (err error) {
+	ctx, op := cli.startOperation(ctx, "InsertRole", "")
+	defer func() { op.end(err) }()
+
+	err = cli.InsertEmptyRoleCtx(ctx, role.Name)
 	if err != nil {
-		return errors.New(fmt.Sprintf("Error creating new role '%s': %s", role.Name, err.Error()))
+		return fmt.Errorf("Error creating new role '%s': %w", role.Name, err)
 	}
 
 	for _, permission := range role.Permissions {
-		err := cli.GrantRolePermission(role.Name, permission)
+		err := cli.GrantRolePermissionCtx(ctx, role.Name, permission)
 		if err != nil {
-			return errors.New(fmt.Sprintf("Error adding role permission (key='%s', range_end='%s', permission='%s') for role '%s': %s", permission.Key, permission.RangeEnd, permission.Permission, role.Name, err.Error()))
+			return fmt.Errorf("Error adding role permission (key='%s', range_end='%s', permission='%s') for role '%s': %w", permission.Key, permission.RangeEnd, permission.Permission, role.Name, err)
 		}
 	}
 
@@ -199,9 +224,19 @@ func (cli *EtcdClient) InsertRole(role EtcdRole) error {
 }
 
 func (cli *EtcdClient) UpdateRole(role EtcdRole) error {
-	resPermissions, _, err := cli.GetRolePermissions(role.Name)
+	return cli.UpdateRoleCtx(cli.Context, role)
+}
+
+/*
+Variant of UpdateRole that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) UpdateRoleCtx(ctx context.Context, role EtcdRole) (err error) {
+	ctx, op := cli.startOperation(ctx, "UpdateRole", "")
+	defer func() { op.end(err) }()
+
+	resPermissions, _, err := cli.GetRolePermissionsCtx(ctx, role.Name)
 	if err != nil {
-		return errors.New(fmt.Sprintf("Error retrieving existing role '%s' for update: %s", role.Name, err.Error()))
+		return fmt.Errorf("Error retrieving existing role '%s' for update: %w", role.Name, err)
 	}
 
 	for _, resPermission := range resPermissions {
@@ -212,9 +247,9 @@ func (cli *EtcdClient) UpdateRole(role EtcdRole) error {
 			}
 		}
 		if remove {
-			err := cli.RevokeRolePermission(role.Name, resPermission.Key, resPermission.RangeEnd)
+			err := cli.RevokeRolePermissionCtx(ctx, role.Name, resPermission.Key, resPermission.RangeEnd)
 			if err != nil {
-				return errors.New(fmt.Sprintf("Error removing role permission (key='%s', range_end='%s', permission='%s') for role '%s': %s", resPermission.Key, resPermission.RangeEnd, resPermission.Permission, role.Name, err.Error()))
+				return fmt.Errorf("Error removing role permission (key='%s', range_end='%s', permission='%s') for role '%s': %w", resPermission.Key, resPermission.RangeEnd, resPermission.Permission, role.Name, err)
 			}
 		}
 	}
@@ -227,9 +262,9 @@ func (cli *EtcdClient) UpdateRole(role EtcdRole) error {
 			}
 		}
 		if add {
-			err := cli.GrantRolePermission(role.Name, permission)
+			err := cli.GrantRolePermissionCtx(ctx, role.Name, permission)
 			if err != nil {
-				return errors.New(fmt.Sprintf("Error adding role permission (key='%s', range_end='%s', permission='%s') for role '%s': %s", permission.Key, permission.RangeEnd, permission.Permission, role.Name, err.Error()))
+				return fmt.Errorf("Error adding role permission (key='%s', range_end='%s', permission='%s') for role '%s': %w", permission.Key, permission.RangeEnd, permission.Permission, role.Name, err)
 			}
 		}
 	}
@@ -238,14 +273,24 @@ func (cli *EtcdClient) UpdateRole(role EtcdRole) error {
 }
 
 func (cli *EtcdClient) UpsertRole(role EtcdRole) error {
-	roles, err := cli.ListRoles()
+	return cli.UpsertRoleCtx(cli.Context, role)
+}
+
+/*
+Variant of UpsertRole that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) UpsertRoleCtx(ctx context.Context, role EtcdRole) (err error) {
+	ctx, op := cli.startOperation(ctx, "UpsertRole", "")
+	defer func() { op.end(err) }()
+
+	roles, err := cli.ListRolesCtx(ctx)
 	if err != nil {
-		return errors.New(fmt.Sprintf("Error retrieving existing roles list: %s", err.Error()))
+		return fmt.Errorf("Error retrieving existing roles list: %w", err)
 	}
 
 	if isStringInSlice(role.Name, roles) {
-		return cli.UpdateRole(role)
+		return cli.UpdateRoleCtx(ctx, role)
 	}
 
-	return cli.InsertRole(role)
+	return cli.InsertRoleCtx(ctx, role)
 }
@@ -0,0 +1,143 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+/*
+Options to acquire the read or the write side of a reader-writer lock.
+*/
+type AcquireRWLockOptions struct {
+	//Prefix under which the holders and waiters of the lock are registered. It should be dedicated to the lock.
+	Key       string
+	Ttl       int64
+	Timeout   time.Duration
+	//If true, the lease of the lock is kept alive in the background until the lock is released or its context is cancelled.
+	KeepAlive bool
+}
+
+func rwLockReadersPrefix(key string) string {
+	return fmt.Sprintf("%s/readers/", key)
+}
+
+func rwLockWritersPrefix(key string) string {
+	return fmt.Sprintf("%s/writers/", key)
+}
+
+/*
+Waits until all the entries of the reader-writer lock that block ours and were registered before it are removed.
+Readers are blocked by the writers registered before them and writers by all the readers and writers registered before them.
+Since readers wait for the writers queued before them, waiting writers take priority over the readers that arrive after them.
+*/
+func (cli *EtcdClient) waitRWLock(ctx context.Context, opts AcquireRWLockOptions, write bool, leaseRes *clientv3.LeaseGrantResponse) (*Lock, error) {
+	entryPrefix := rwLockReadersPrefix(opts.Key)
+	blockingPrefix := rwLockWritersPrefix(opts.Key)
+	if write {
+		entryPrefix = rwLockWritersPrefix(opts.Key)
+		blockingPrefix = fmt.Sprintf("%s/", opts.Key)
+	}
+
+	lock := Lock{
+		Lease:     leaseRes.ID,
+		Ttl:       opts.Ttl,
+		Timestamp: time.Now(),
+		Revision:  leaseRes.ResponseHeader.Revision,
+		Key:       fmt.Sprintf("%s%016x", entryPrefix, int64(leaseRes.ID)),
+	}
+	output, _ := json.Marshal(lock)
+
+	position, err := cli.enqueue(ctx, lock.Key, string(output), leaseRes.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		blocker, revision, err := cli.getPredecessor(ctx, blockingPrefix, position)
+		if err != nil {
+			return nil, err
+		}
+
+		if blocker == "" {
+			break
+		}
+
+		err = cli.waitKeyChange(ctx, blocker, revision, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = cli.refreshLockLease(ctx, &lock)
+	if err != nil {
+		return nil, err
+	}
+
+	//The entry of the lock is what holds it, so its create revision is the fencing token
+	lock.FencingToken = position
+	getOperation(ctx).setRevision(position)
+	return &lock, nil
+}
+
+func (cli *EtcdClient) acquireRWLock(ctx context.Context, name string, opts AcquireRWLockOptions, write bool) (_ *Lock, _ bool, err error) {
+	//The context of the lock outlives the method, so it is not derived from the operation's context
+	lockCtx := ctx
+	ctx, op := cli.startOperation(ctx, name, opts.Key)
+	defer func() { op.end(err) }()
+
+	if opts.Ttl == 0 {
+		opts.Ttl = 600
+	}
+	if int64(opts.Timeout) == 0 {
+		opts.Timeout = 30 * time.Second
+	}
+
+	deadline := time.Now().Add(opts.Timeout)
+	lock, timeout, err := cli.acquireWithLease(ctx, opts.Key, opts.Ttl, deadline, func(ctx context.Context, leaseRes *clientv3.LeaseGrantResponse) (*Lock, error) {
+		return cli.waitRWLock(ctx, opts, write, leaseRes)
+	})
+	if err != nil {
+		return lock, timeout, err
+	}
+
+	cli.holdLock(lockCtx, lock, opts.KeepAlive)
+
+	return lock, timeout, err
+}
+
+/*
+Acquires the read side of a reader-writer lock, which can be held by several readers at once but not while a writer holds the write side.
+Acquirers wait in a queue and readers do not get the lock ahead of the writers that were waiting before them.
+The returned lock is held by an entry under the lock's key and has the same ttl, keepalive and fencing token semantics as the locks of AcquireLock.
+It should be released with ReleaseHeldLock. If the lock could not be acquired before the timeout, the second return value is true and the error matches ErrLockTimeout.
+*/
+func (cli *EtcdClient) AcquireReadLock(opts AcquireRWLockOptions) (*Lock, bool, error) {
+	return cli.AcquireReadLockCtx(cli.Context, opts)
+}
+
+/*
+Variant of AcquireReadLock that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) AcquireReadLockCtx(ctx context.Context, opts AcquireRWLockOptions) (*Lock, bool, error) {
+	return cli.acquireRWLock(ctx, "AcquireReadLock", opts, false)
+}
+
+/*
+Acquires the write side of a reader-writer lock, which gives exclusive access against both readers and other writers.
+Acquirers wait in a queue and the writer gets the lock once all the readers and writers that arrived before it released it.
+The returned lock should be released with ReleaseHeldLock. If the lock could not be acquired before the timeout, the second return value is true and the error matches ErrLockTimeout.
+*/
+func (cli *EtcdClient) AcquireWriteLock(opts AcquireRWLockOptions) (*Lock, bool, error) {
+	return cli.AcquireWriteLockCtx(cli.Context, opts)
+}
+
+/*
+Variant of AcquireWriteLock that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) AcquireWriteLockCtx(ctx context.Context, opts AcquireRWLockOptions) (*Lock, bool, error) {
+	return cli.acquireRWLock(ctx, "AcquireWriteLock", opts, true)
+}
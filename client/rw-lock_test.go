@@ -0,0 +1,130 @@
+package client
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Ferlab-Ste-Justine/etcd-sdk/testutils"
+)
+
+func TestRWLock(t *testing.T) {
+	tearDown, launchErr := testutils.LaunchTestEtcdCluster("../test", testutils.EtcdTestClusterOpts{})
+	if launchErr != nil {
+		t.Errorf("Error occured launching test etcd cluster: %s", launchErr.Error())
+		return
+	}
+
+	defer func() {
+		errs := tearDown()
+		if len(errs) > 0 {
+			t.Errorf("Errors occured tearing down etcd cluster: %s", errs[0].Error())
+		}
+	}()
+
+	retryInterval, _ := time.ParseDuration("1s")
+	timeouts, _ := time.ParseDuration("10s")
+	retries := uint64(10)
+	cli := setupTestEnv(t, timeouts, retryInterval, retries)
+
+	short := AcquireRWLockOptions{Key: "/rwlock", Ttl: 60, Timeout: 500 * time.Millisecond}
+	long := AcquireRWLockOptions{Key: "/rwlock", Ttl: 60, Timeout: 10 * time.Second}
+
+	reader1, _, err := cli.AcquireReadLock(short)
+	if err != nil {
+		t.Errorf("Error occured acquiring read lock: %s", err.Error())
+		return
+	}
+
+	reader2, _, err := cli.AcquireReadLock(short)
+	if err != nil {
+		t.Errorf("Expected several readers to hold the lock at once and got: %s", err.Error())
+		return
+	}
+
+	_, timeout, err := cli.AcquireWriteLock(short)
+	if !timeout || !errors.Is(err, ErrLockTimeout) {
+		t.Errorf("Expected writer to wait for the readers holding the lock and got: %v", err)
+	}
+
+	writerCh := make(chan *Lock, 1)
+	go func() {
+		writer, _, err := cli.AcquireWriteLock(long)
+		if err != nil {
+			t.Errorf("Error occured acquiring write lock: %s", err.Error())
+		}
+		writerCh <- writer
+	}()
+
+	//Waits for the writer to be queued
+	for {
+		writers, _ := cli.GetPrefix(rwLockWritersPrefix("/rwlock"))
+		if len(writers.Keys) == 1 {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	_, timeout, err = cli.AcquireReadLock(short)
+	if !timeout || !errors.Is(err, ErrLockTimeout) {
+		t.Errorf("Expected reader arriving after a waiting writer to wait for it and got: %v", err)
+	}
+
+	cli.ReleaseHeldLock(reader1)
+	select {
+	case <-writerCh:
+		t.Errorf("Expected writer to wait for all the readers to release the lock and it didn't")
+		return
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	cli.ReleaseHeldLock(reader2)
+	var writer *Lock
+	select {
+	case writer = <-writerCh:
+	case <-time.After(5 * time.Second):
+		t.Errorf("Expected writer to get the lock once the readers released it and it didn't")
+		return
+	}
+	if writer == nil {
+		return
+	}
+
+	if writer.FencingToken <= reader2.FencingToken {
+		t.Errorf("Expected the fencing token of the writer to be greater than the ones of the previous readers and it wasn't")
+	}
+
+	_, timeout, _ = cli.AcquireWriteLock(short)
+	if !timeout {
+		t.Errorf("Expected writer to wait for the writer holding the lock and it didn't")
+	}
+
+	readerCh := make(chan *Lock, 1)
+	go func() {
+		reader, _, err := cli.AcquireReadLock(long)
+		if err != nil {
+			t.Errorf("Error occured acquiring read lock: %s", err.Error())
+		}
+		readerCh <- reader
+	}()
+
+	time.Sleep(200 * time.Millisecond)
+	err = cli.ReleaseHeldLock(writer)
+	if err != nil {
+		t.Errorf("Error occured releasing write lock: %s", err.Error())
+	}
+
+	select {
+	case reader := <-readerCh:
+		if reader != nil {
+			cli.ReleaseHeldLock(reader)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("Expected reader to get the lock once the writer released it and it didn't")
+	}
+
+	entries, _ := cli.GetPrefix("/rwlock/")
+	if len(entries.Keys) != 0 {
+		t.Errorf("Expected no entries to be left once all the locks were released and got: %v", entries.Keys)
+	}
+}
@@ -2,16 +2,29 @@ package client
 
 import (
 	"context"
-	"errors"
 	"time"
 
 	clientv3 "go.etcd.io/etcd/client/v3"
 	"go.etcd.io/etcd/client/v3/snapshot"
-	"go.uber.org/zap"
 )
 
+/*
+Saves a snapshot of the etcd store to the given path, taking it from the leader if onLeader is true or from a follower otherwise.
+Returns ErrNoLeader or ErrMemberNotFound if no member with the requested characteristics can be reached.
+The progress of the snapshot is logged with the client's logger.
+*/
 func (cli *EtcdClient) Snapshot(onLeader bool, path string, snapshotTimeout time.Duration) error {
-	members, membersErr := cli.GetMembers(true)
+	return cli.SnapshotCtx(cli.Context, onLeader, path, snapshotTimeout)
+}
+
+/*
+Variant of Snapshot that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) SnapshotCtx(ctx context.Context, onLeader bool, path string, snapshotTimeout time.Duration) (err error) {
+	ctx, op := cli.startOperation(ctx, "Snapshot", "")
+	defer func() { op.end(err) }()
+
+	members, membersErr := cli.GetMembersCtx(ctx, true)
 	if membersErr != nil {
 		return membersErr
 	}
@@ -31,25 +44,23 @@ func (cli *EtcdClient) Snapshot(onLeader bool, path string, snapshotTimeout time
 	}
 
 	if !memberFound {
-		return errors.New("No member with the requests characteristics was found to get snapshot")
+		if onLeader {
+			return ErrNoLeader
+		}
+
+		return ErrMemberNotFound
 	}
 
-	ctx, cancel := context.WithTimeout(cli.Context, snapshotTimeout)
+	ctx, cancel := context.WithTimeout(ctx, snapshotTimeout)
 	defer cancel()
 
-    tlsConfs, tlsConfsErr := getTlsConfigs(cli.connOpts)
-    if tlsConfsErr != nil {
-        return tlsConfsErr
-    }
-
-	logger := zap.NewExample()
-	defer logger.Sync()
-	return snapshot.Save(ctx, logger, clientv3.Config{
+	return snapshot.Save(ctx, cli.getLogger(), clientv3.Config{
 		Context:     ctx,
 		Username:    cli.connOpts.Username,
 		Password:    cli.connOpts.Password,
 		Endpoints:   []string{selectedMember.ClientUrls[0]},
-		TLS:         tlsConfs,
+		TLS:         cli.tlsConf,
 		DialTimeout: cli.connOpts.ConnectionTimeout,
+		Logger:      cli.Logger,
 	}, path)
 }
\ No newline at end of file
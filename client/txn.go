@@ -0,0 +1,315 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	raftv3 "go.etcd.io/raft/v3"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+/*
+Operation of a transaction, as created by the TxnPut, TxnDelete, TxnGet and TxnIf functions and their variants.
+*/
+type TxnOp struct {
+	op       clientv3.Op
+	//Whether the operation, or one of its nested operations, modifies keys
+	mutating bool
+	written  int64
+}
+
+/*
+Transaction operation that sets the value of a key.
+*/
+func TxnPut(key string, val string) TxnOp {
+	return TxnOp{op: clientv3.OpPut(key, val), mutating: true, written: int64(len(key) + len(val))}
+}
+
+/*
+Variant of TxnPut for binary values.
+*/
+func TxnPutBytes(key string, val []byte) TxnOp {
+	return TxnPut(key, string(val))
+}
+
+/*
+Variant of TxnPut that attaches the key to a lease.
+*/
+func TxnPutWithLease(key string, val string, lease clientv3.LeaseID) TxnOp {
+	return TxnOp{op: clientv3.OpPut(key, val, clientv3.WithLease(lease)), mutating: true, written: int64(len(key) + len(val))}
+}
+
+/*
+Transaction operation that deletes a key.
+*/
+func TxnDelete(key string) TxnOp {
+	return TxnOp{op: clientv3.OpDelete(key), mutating: true}
+}
+
+/*
+Transaction operation that deletes all the keys prefixed by a given value.
+*/
+func TxnDeletePrefix(prefix string) TxnOp {
+	return TxnOp{op: clientv3.OpDelete(prefix, clientv3.WithPrefix()), mutating: true}
+}
+
+/*
+Transaction operation that gets a key. Its result is reported in the Keys map of the operation's result.
+*/
+func TxnGet(key string) TxnOp {
+	return TxnOp{op: clientv3.OpGet(key)}
+}
+
+/*
+Transaction operation that gets all the keys prefixed by a given value. Its result is reported in the Keys map of the operation's result.
+*/
+func TxnGetPrefix(prefix string) TxnOp {
+	return TxnOp{op: clientv3.OpGet(prefix, clientv3.WithPrefix())}
+}
+
+/*
+Transaction operation that is itself a transaction, running the thenOps operations if the conditions are met and the elseOps operations otherwise.
+Its result is reported in the Nested field of the operation's result.
+Note that etcd does not allow a key to be modified more than once in the same transaction, including in nested transactions.
+*/
+func TxnIf(conds []clientv3.Cmp, thenOps []TxnOp, elseOps []TxnOp) TxnOp {
+	mutating := false
+	written := int64(0)
+	for _, txnOp := range append(append([]TxnOp{}, thenOps...), elseOps...) {
+		mutating = mutating || txnOp.mutating
+		written += txnOp.written
+	}
+
+	return TxnOp{
+		op:       clientv3.OpTxn(conds, toClientOps(thenOps), toClientOps(elseOps)),
+		mutating: mutating,
+		written:  written,
+	}
+}
+
+func toClientOps(txnOps []TxnOp) []clientv3.Op {
+	ops := []clientv3.Op{}
+	for _, txnOp := range txnOps {
+		ops = append(ops, txnOp.op)
+	}
+
+	return ops
+}
+
+/*
+Result of an operation of a transaction.
+*/
+type TxnOpResult struct {
+	//Keys returned by a get operation
+	Keys    KeyInfoMap
+	//Number of keys deleted by a delete operation
+	Deleted int64
+	//Result of a nested transaction operation
+	Nested  *TxnResult
+}
+
+/*
+Result of a transaction.
+*/
+type TxnResult struct {
+	//Whether the conditions of the transaction were met, in which case the Then operations were run. Otherwise, the Else operations were run.
+	Succeeded bool
+	//Revision of the etcd store after the transaction was committed
+	Revision  int64
+	//Results of the operations that were run, in order
+	Results   []TxnOpResult
+}
+
+/*
+Returns the key as returned by the get operations of the transaction, including those of nested transactions.
+The Found method of the result can be used to check whether the key was returned.
+*/
+func (res *TxnResult) GetKey(key string) KeyInfo {
+	for _, opRes := range res.Results {
+		if info, ok := opRes.Keys[key]; ok {
+			return info
+		}
+
+		if opRes.Nested != nil {
+			info := opRes.Nested.GetKey(key)
+			if info.Found() {
+				return info
+			}
+		}
+	}
+
+	return KeyInfo{}
+}
+
+func newTxnResult(succeeded bool, revision int64, responses []*etcdserverpb.ResponseOp, op *operation) TxnResult {
+	res := TxnResult{
+		Succeeded: succeeded,
+		Revision:  revision,
+		Results:   []TxnOpResult{},
+	}
+
+	for _, response := range responses {
+		opRes := TxnOpResult{}
+		if rangeRes := response.GetResponseRange(); rangeRes != nil {
+			opRes.Keys = KeyInfoMap(make(map[string]KeyInfo))
+			for _, kv := range rangeRes.Kvs {
+				op.addBytes(int64(len(kv.Key) + len(kv.Value)), 0)
+				info := newKeyBytesInfo(kv)
+				opRes.Keys[info.Key] = info.ToKeyInfo()
+			}
+		}
+
+		if deleteRes := response.GetResponseDeleteRange(); deleteRes != nil {
+			opRes.Deleted = deleteRes.Deleted
+		}
+
+		if txnRes := response.GetResponseTxn(); txnRes != nil {
+			nested := newTxnResult(txnRes.Succeeded, revision, txnRes.Responses, op)
+			opRes.Nested = &nested
+		}
+
+		res.Results = append(res.Results, opRes)
+	}
+
+	return res
+}
+
+/*
+Builder of a transaction, as returned by the Txn method of the client.
+The comparison functions, like KeyExists or KeyModRevisionIsCmp, can be used to build its conditions.
+*/
+type TxnBuilder struct {
+	cli        *EtcdClient
+	conds      []clientv3.Cmp
+	thenOps    []TxnOp
+	elseOps    []TxnOp
+	idempotent bool
+}
+
+/*
+Starts building a transaction that is run with the Commit method.
+A transaction that modifies keys is only retried on errors that guarantee it was not applied, as it would otherwise risk being applied twice.
+Transactions that only get keys, or that are marked as idempotent, are retried like the other methods of the client.
+*/
+func (cli *EtcdClient) Txn() *TxnBuilder {
+	return &TxnBuilder{cli: cli}
+}
+
+/*
+Adds conditions that must all be met for the Then operations to run. Otherwise, the Else operations are run.
+*/
+func (b *TxnBuilder) If(conds ...clientv3.Cmp) *TxnBuilder {
+	b.conds = append(b.conds, conds...)
+	return b
+}
+
+/*
+Adds operations to run if the conditions are met.
+*/
+func (b *TxnBuilder) Then(ops ...TxnOp) *TxnBuilder {
+	b.thenOps = append(b.thenOps, ops...)
+	return b
+}
+
+/*
+Adds operations to run if the conditions are not met.
+*/
+func (b *TxnBuilder) Else(ops ...TxnOp) *TxnBuilder {
+	b.elseOps = append(b.elseOps, ops...)
+	return b
+}
+
+/*
+Marks the transaction as safe to apply more than once, so that it is retried on all retryable errors even if it modifies keys.
+*/
+func (b *TxnBuilder) Idempotent() *TxnBuilder {
+	b.idempotent = true
+	return b
+}
+
+/*
+Commits the transaction and returns its result.
+*/
+func (b *TxnBuilder) Commit() (TxnResult, error) {
+	return b.CommitCtx(b.cli.Context)
+}
+
+/*
+Variant of Commit that takes a context to cancel the operation or set a deadline on it.
+*/
+func (b *TxnBuilder) CommitCtx(ctx context.Context) (_ TxnResult, err error) {
+	cli := b.cli
+	ctx, op := cli.startOperation(ctx, "Txn", "")
+	defer func() { op.end(err) }()
+
+	mutating := false
+	for _, txnOp := range append(append([]TxnOp{}, b.thenOps...), b.elseOps...) {
+		mutating = mutating || txnOp.mutating
+	}
+	if mutating && (!b.idempotent) {
+		cli = cli.SetRetryPolicy(unappliedRetryPolicy{cli.getRetryPolicy()})
+	}
+
+	var resp *clientv3.TxnResponse
+	err = cli.withRetries(ctx, func() error {
+		ctx, cancel := context.WithTimeout(ctx, cli.RequestTimeout)
+		defer cancel()
+
+		var err error
+		resp, err = cli.Client.Txn(ctx).If(b.conds...).Then(toClientOps(b.thenOps)...).Else(toClientOps(b.elseOps)...).Commit()
+		return err
+	})
+	if err != nil {
+		return TxnResult{}, err
+	}
+
+	op.setTxnSucceeded(resp.Succeeded)
+	op.setRevision(resp.Header.Revision)
+
+	ops := b.elseOps
+	if resp.Succeeded {
+		ops = b.thenOps
+	}
+	for _, txnOp := range ops {
+		op.addBytes(0, txnOp.written)
+	}
+
+	return newTxnResult(resp.Succeeded, resp.Header.Revision, resp.Responses, op), nil
+}
+
+/*
+Retry policy that only retries requests on errors that guarantee they were not applied by etcd.
+*/
+type unappliedRetryPolicy struct {
+	RetryPolicy
+}
+
+func (p unappliedRetryPolicy) IsRetryable(err error) bool {
+	return p.RetryPolicy.IsRetryable(err) && errorIsUnapplied(err)
+}
+
+/*
+Returns whether an error guarantees that the request that caused it was not applied by etcd, either because it was not sent or because it was rejected before being proposed.
+*/
+func errorIsUnapplied(err error) bool {
+	if errors.Is(err, rpctypes.ErrNoLeader) || errors.Is(err, rpctypes.ErrNotCapable) {
+		return true
+	}
+
+	desc := rpctypes.ErrorDesc(err)
+	if desc == rpctypes.ErrNoLeader.Error() || desc == rpctypes.ErrNotCapable.Error() || desc == raftv3.ErrProposalDropped.Error() {
+		return true
+	}
+
+	stat, ok := status.FromError(err)
+	if !ok || stat.Code() != codes.Unavailable {
+		return false
+	}
+
+	return stat.Message() == "there is no address available" || stat.Message() == "there is no connection available" || strings.HasPrefix(stat.Message(), "connection error")
+}
@@ -0,0 +1,109 @@
+package client
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Ferlab-Ste-Justine/etcd-sdk/testutils"
+)
+
+func TestErrorIsUnapplied(t *testing.T) {
+	if !errorIsUnapplied(rpctypes.ErrNoLeader) || !errorIsUnapplied(rpctypes.ErrGRPCNoLeader) {
+		t.Errorf("Expected no leader errors to be reported as unapplied and they weren't")
+	}
+
+	if !errorIsUnapplied(status.Error(codes.Unavailable, "there is no address available")) {
+		t.Errorf("Expected unavailable errors for unsent requests to be reported as unapplied and they weren't")
+	}
+
+	if errorIsUnapplied(rpctypes.ErrTimeout) || errorIsUnapplied(rpctypes.ErrLeaderChanged) {
+		t.Errorf("Expected errors for requests that may have been applied not to be reported as unapplied and they were")
+	}
+
+	if errorIsUnapplied(errors.New("Other error")) {
+		t.Errorf("Expected unknown errors not to be reported as unapplied and they were")
+	}
+}
+
+func TestTxn(t *testing.T) {
+	tearDown, launchErr := testutils.LaunchTestEtcdCluster("../test", testutils.EtcdTestClusterOpts{})
+	if launchErr != nil {
+		t.Errorf("Error occured launching test etcd cluster: %s", launchErr.Error())
+		return
+	}
+
+	defer func() {
+		errs := tearDown()
+		if len(errs) > 0 {
+			t.Errorf("Errors occured tearing down etcd cluster: %s", errs[0].Error())
+		}
+	}()
+
+	retryInterval, _ := time.ParseDuration("1s")
+	timeouts, _ := time.ParseDuration("10s")
+	retries := uint64(10)
+	cli := setupTestEnv(t, timeouts, retryInterval, retries)
+
+	res, err := cli.Txn().If(KeyMissing("txn/a")).Then(TxnPut("txn/a", "a"), TxnPut("txn/b", "b")).Else(TxnGet("txn/a")).Commit()
+	if err != nil {
+		t.Errorf("Error occured committing transaction: %s", err.Error())
+		return
+	}
+	if !res.Succeeded || len(res.Results) != 2 {
+		t.Errorf("Expected transaction on an absent key to run its then operations and got: %v", res)
+	}
+
+	res, err = cli.Txn().If(KeyMissing("txn/a")).Then(TxnPut("txn/a", "other")).Else(TxnGet("txn/a")).Commit()
+	if err != nil {
+		t.Errorf("Error occured committing transaction: %s", err.Error())
+		return
+	}
+	info := res.GetKey("txn/a")
+	if res.Succeeded || info.Value != "a" {
+		t.Errorf("Expected transaction on a present key to run its else operations and got: %v", res)
+	}
+
+	//KeyIsPresent keeps its inverted result for compatibility
+	res, err = cli.Txn().If(KeyIsPresent("txn/a", false), KeyExists("txn/a")).Commit()
+	if err != nil || !res.Succeeded {
+		t.Errorf("Expected KeyIsPresent with a presence of false to be true for a present key and got: %v", res)
+	}
+
+	res, err = cli.Txn().If(
+		KeyExists("txn/a"),
+		KeyModRevisionIsCmp("txn/a", "=", info.ModRevision),
+		KeyLeaseIsCmp("txn/a", "=", clientv3.NoLease),
+	).Then(
+		TxnDelete("txn/a"),
+		TxnIf(
+			[]clientv3.Cmp{KeyValueIsCmp("txn/b", "=", "b")},
+			[]TxnOp{TxnPut("txn/c", "c"), TxnGetPrefix("txn/b")},
+			[]TxnOp{},
+		),
+	).Commit()
+	if err != nil {
+		t.Errorf("Error occured committing transaction: %s", err.Error())
+		return
+	}
+	if !res.Succeeded || res.Results[0].Deleted != 1 || res.Results[1].Nested == nil || !res.Results[1].Nested.Succeeded {
+		t.Errorf("Expected transaction with a nested transaction to run the operations of both and got: %v", res)
+	}
+	if res.GetKey("txn/b").Value != "b" {
+		t.Errorf("Expected keys read by a nested transaction to be returned and they weren't")
+	}
+
+	prefix, _ := cli.GetPrefix("txn/")
+	values := prefix.Keys.ToValueMap("txn/")
+	if len(values) != 2 || values["b"] != "b" || values["c"] != "c" {
+		t.Errorf("Expected transaction changes to be applied and got: %v", values)
+	}
+	if res.Revision != prefix.Revision {
+		t.Errorf("Expected transaction to return the revision it was committed at and got %d instead of %d", res.Revision, prefix.Revision)
+	}
+}
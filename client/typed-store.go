@@ -0,0 +1,281 @@
+package client
+
+import (
+	"context"
+	"strings"
+)
+
+/*
+Key-value store of values of a given type, that are encoded with a codec before being stored in etcd.
+It should be instanciated with the NewTypedStore function.
+*/
+type TypedStore[T any] struct {
+	//Client used to access etcd
+	Client *EtcdClient
+	//Codec used to encode and decode the values
+	Codec  Codec
+}
+
+/*
+Returns a typed store that accesses etcd with the given client and encodes the values with the given codec.
+*/
+func NewTypedStore[T any](cli *EtcdClient, codec Codec) *TypedStore[T] {
+	return &TypedStore[T]{
+		Client: cli,
+		Codec:  codec,
+	}
+}
+
+/*
+Typed variant of the KeyInfo structure, with the decoded value.
+*/
+type TypedKeyInfo[T any] struct {
+	//Key
+	Key            string
+	//Decoded value stored at the key
+	Value          T
+	//Etcd version of the key, which is incremented when a key changes and reset to 0 when it is deleted
+	Version        int64
+	//Revision of the etcd store when the key was created
+	CreateRevision int64
+	//Revision of the etcd store when the key was last modified
+	ModRevision    int64
+	//Id of the lease that created the key if the key was created with a lease
+	Lease          int64
+}
+
+/*
+Returns whether the TypedKeyInfo structure stores a key that was found.
+*/
+func (info *TypedKeyInfo[T]) Found() bool {
+	return info.CreateRevision > 0
+}
+
+/*
+A map of TypedKeyInfo values with each key in the map being the TypedKeyInfo's key.
+*/
+type TypedKeyInfoMap[T any] map[string]TypedKeyInfo[T]
+
+/*
+Flatten a TypedKeyInfoMap structure to a simple map of key/value pairs.
+The method accepts a prefix argument that will be trimmed from the beginning of the keys.
+*/
+func (info *TypedKeyInfoMap[T]) ToValueMap(prefixTrim string) map[string]T {
+	res := make(map[string]T)
+
+	for key, val := range *info {
+		key := strings.TrimPrefix(key, prefixTrim)
+		res[key] = val.Value
+	}
+
+	return res
+}
+
+/*
+Result from a typed key range or prefix query.
+Keys whose value could not be decoded are not in the Keys map, but in the Errors map instead.
+*/
+type TypedRangeInfo[T any] struct {
+	//Keys whose value was decoded
+	Keys     TypedKeyInfoMap[T]
+	//Errors matching ErrDecodeFailed for the keys whose value could not be decoded, by key
+	Errors   map[string]error
+	//Revision of the etcd store at the moment the results were returned
+	Revision int64
+}
+
+/*
+Reported changes to key(s) of interest by the typed watch function.
+*/
+type TypedWatchInfo[T any] struct {
+	//Inserted or updated keys whose value was decoded. The map keys are the keys that were upserted.
+	Upserts   TypedKeyInfoMap[T]
+	//List of keys that were deleted
+	Deletions []string
+	//Errors matching ErrDecodeFailed for the upserted keys whose value could not be decoded, by key
+	Errors    map[string]error
+}
+
+/*
+Events returned by the typed watch function.
+It can report either a change or an error.
+*/
+type TypedWatchNotification[T any] struct {
+	//Changes that are reported if it is not an error
+	Changes TypedWatchInfo[T]
+	//Error that is reported if it is an error
+	Error   error
+}
+
+func (store *TypedStore[T]) decode(key string, data []byte) (T, error) {
+	var val T
+	err := store.Codec.Unmarshal(data, &val)
+	if err != nil {
+		return val, &KeyError{Key: key, Kind: ErrDecodeFailed, Cause: err}
+	}
+
+	return val, nil
+}
+
+/*
+Encodes a value with the codec and stores it at the given key.
+Returns the revision of the etcd store after the operation and an error matching ErrEncodeFailed if the value could not be encoded.
+*/
+func (store *TypedStore[T]) Put(key string, val T) (int64, error) {
+	return store.PutCtx(store.Client.Context, key, val)
+}
+
+/*
+Variant of Put that takes a context to cancel the operation or set a deadline on it.
+*/
+func (store *TypedStore[T]) PutCtx(ctx context.Context, key string, val T) (int64, error) {
+	data, err := store.Codec.Marshal(val)
+	if err != nil {
+		return -1, &KeyError{Key: key, Kind: ErrEncodeFailed, Cause: err}
+	}
+
+	return store.Client.PutKeyBytesCtx(ctx, key, data)
+}
+
+/*
+Retrieves the value of a given key and decodes it with the codec.
+The Found method of the result can be used to check whether the key exists.
+Returns an error matching ErrDecodeFailed if the value could not be decoded.
+*/
+func (store *TypedStore[T]) Get(key string, opts GetKeyOptions) (TypedKeyInfo[T], error) {
+	return store.GetCtx(store.Client.Context, key, opts)
+}
+
+/*
+Variant of Get that takes a context to cancel the operation or set a deadline on it.
+*/
+func (store *TypedStore[T]) GetCtx(ctx context.Context, key string, opts GetKeyOptions) (TypedKeyInfo[T], error) {
+	info, err := store.Client.GetKeyBytesCtx(ctx, key, opts)
+	if err != nil || (!info.Found()) {
+		return TypedKeyInfo[T]{}, err
+	}
+
+	val, err := store.decode(key, info.Value)
+	if err != nil {
+		return TypedKeyInfo[T]{}, err
+	}
+
+	return TypedKeyInfo[T]{
+		Key:            info.Key,
+		Value:          val,
+		Version:        info.Version,
+		CreateRevision: info.CreateRevision,
+		ModRevision:    info.ModRevision,
+		Lease:          info.Lease,
+	}, nil
+}
+
+/*
+Deletes a given key.
+*/
+func (store *TypedStore[T]) Delete(key string) error {
+	return store.DeleteCtx(store.Client.Context, key)
+}
+
+/*
+Variant of Delete that takes a context to cancel the operation or set a deadline on it.
+*/
+func (store *TypedStore[T]) DeleteCtx(ctx context.Context, key string) error {
+	return store.Client.DeleteKeyCtx(ctx, key)
+}
+
+/*
+Retrieves all the keys prefixed by a given value and decodes their values with the codec.
+Values that cannot be decoded are reported in the Errors map of the result instead of failing the whole query.
+*/
+func (store *TypedStore[T]) GetPrefix(prefix string) (TypedRangeInfo[T], error) {
+	return store.GetPrefixCtx(store.Client.Context, prefix)
+}
+
+/*
+Variant of GetPrefix that takes a context to cancel the operation or set a deadline on it.
+*/
+func (store *TypedStore[T]) GetPrefixCtx(ctx context.Context, prefix string) (TypedRangeInfo[T], error) {
+	res := TypedRangeInfo[T]{
+		Keys:     TypedKeyInfoMap[T](make(map[string]TypedKeyInfo[T])),
+		Errors:   make(map[string]error),
+		Revision: -1,
+	}
+
+	info, err := store.Client.GetPrefixBytesCtx(ctx, prefix)
+	if err != nil {
+		return res, err
+	}
+
+	for key, kv := range info.Keys {
+		val, err := store.decode(key, kv.Value)
+		if err != nil {
+			res.Errors[key] = err
+			continue
+		}
+
+		res.Keys[key] = TypedKeyInfo[T]{
+			Key:            key,
+			Value:          val,
+			Version:        kv.Version,
+			CreateRevision: kv.CreateRevision,
+			ModRevision:    kv.ModRevision,
+			Lease:          kv.Lease,
+		}
+	}
+
+	res.Revision = info.Revision
+	return res, nil
+}
+
+/*
+Watch the keys of a given prefix for changes and returns a channel that notifies of any changes, with the upserted values decoded with the codec.
+Upserted values that cannot be decoded are reported in the Errors map of the changes. Other errors are reported like for the Watch method of the client.
+*/
+func (store *TypedStore[T]) Watch(wKey string, opts WatchOptions) <-chan TypedWatchNotification[T] {
+	return store.WatchCtx(store.Client.Context, wKey, opts)
+}
+
+/*
+Variant of Watch that takes a context to cancel the operation or set a deadline on it.
+*/
+func (store *TypedStore[T]) WatchCtx(ctx context.Context, wKey string, opts WatchOptions) <-chan TypedWatchNotification[T] {
+	outChan := make(chan TypedWatchNotification[T])
+
+	go func() {
+		defer close(outChan)
+		store.Client.watch(ctx, "Watch", wKey, opts, func(notif WatchBytesNotification) {
+			if notif.Error != nil {
+				outChan <- TypedWatchNotification[T]{Error: notif.Error}
+				return
+			}
+
+			changes := TypedWatchInfo[T]{
+				Upserts:   TypedKeyInfoMap[T](make(map[string]TypedKeyInfo[T])),
+				Deletions: notif.Changes.Deletions,
+				Errors:    make(map[string]error),
+			}
+
+			for key, kv := range notif.Changes.Upserts {
+				val, err := store.decode(key, kv.Value)
+				if err != nil {
+					changes.Errors[key] = err
+					continue
+				}
+
+				changes.Upserts[key] = TypedKeyInfo[T]{
+					Key:            key,
+					Value:          val,
+					Version:        kv.Version,
+					CreateRevision: kv.CreateRevision,
+					ModRevision:    kv.ModRevision,
+					Lease:          kv.Lease,
+				}
+			}
+
+			outChan <- TypedWatchNotification[T]{Changes: changes}
+		})
+	}()
+
+	return outChan
+}
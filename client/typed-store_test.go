@@ -0,0 +1,130 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/Ferlab-Ste-Justine/etcd-sdk/testutils"
+)
+
+type typedTestValue struct {
+	Name  string `json:"name" yaml:"name"`
+	Count int    `json:"count" yaml:"count"`
+}
+
+func TestCodecs(t *testing.T) {
+	for name, codec := range map[string]Codec{"json": JsonCodec{}, "yaml": YamlCodec{}} {
+		data, err := codec.Marshal(typedTestValue{Name: "test", Count: 2})
+		if err != nil {
+			t.Errorf("Error occured encoding value with %s codec: %s", name, err.Error())
+			continue
+		}
+
+		var val typedTestValue
+		err = codec.Unmarshal(data, &val)
+		if err != nil || val.Name != "test" || val.Count != 2 {
+			t.Errorf("Expected value to be decoded identically with %s codec and got: %v", name, val)
+		}
+	}
+
+	data, err := ProtoCodec{}.Marshal(wrapperspb.String("test"))
+	if err != nil {
+		t.Errorf("Error occured encoding value with protobuf codec: %s", err.Error())
+		return
+	}
+
+	var msg *wrapperspb.StringValue
+	err = ProtoCodec{}.Unmarshal(data, &msg)
+	if err != nil || msg.GetValue() != "test" {
+		t.Errorf("Expected protobuf message pointer to be allocated and decoded and got: %v", msg)
+	}
+
+	_, err = ProtoCodec{}.Marshal(typedTestValue{})
+	if err == nil {
+		t.Errorf("Expected an error encoding a value that is not a protobuf message with the protobuf codec and got none")
+	}
+}
+
+func TestTypedStore(t *testing.T) {
+	tearDown, launchErr := testutils.LaunchTestEtcdCluster("../test", testutils.EtcdTestClusterOpts{})
+	if launchErr != nil {
+		t.Errorf("Error occured launching test etcd cluster: %s", launchErr.Error())
+		return
+	}
+
+	defer func() {
+		errs := tearDown()
+		if len(errs) > 0 {
+			t.Errorf("Errors occured tearing down etcd cluster: %s", errs[0].Error())
+		}
+	}()
+
+	retryInterval, _ := time.ParseDuration("1s")
+	timeouts, _ := time.ParseDuration("10s")
+	retries := uint64(10)
+	cli := setupTestEnv(t, timeouts, retryInterval, retries)
+
+	store := NewTypedStore[typedTestValue](cli, JsonCodec{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watch := store.WatchCtx(ctx, "/typed/", WatchOptions{IsPrefix: true, TrimPrefix: true})
+
+	rev, err := store.Put("/typed/a", typedTestValue{Name: "a", Count: 1})
+	if err != nil {
+		t.Errorf("Error occured putting typed value: %s", err.Error())
+		return
+	}
+
+	info, err := store.Get("/typed/a", GetKeyOptions{})
+	if err != nil {
+		t.Errorf("Error occured getting typed value: %s", err.Error())
+		return
+	}
+	if !info.Found() || info.Value.Name != "a" || info.Value.Count != 1 || info.ModRevision != rev {
+		t.Errorf("Expected typed value to be read back identically and got: %v", info)
+	}
+
+	notif := <-watch
+	if notif.Error != nil || notif.Changes.Upserts["a"].Value.Name != "a" {
+		t.Errorf("Expected typed value to be reported by the watch and got: %v", notif)
+	}
+
+	cli.PutKey("/typed/invalid", "{not json")
+	_, err = store.Get("/typed/invalid", GetKeyOptions{})
+	if !errors.Is(err, ErrDecodeFailed) {
+		t.Errorf("Expected getting an invalid typed value to return a decode error and got: %v", err)
+	}
+
+	notif = <-watch
+	if notif.Error != nil || len(notif.Changes.Upserts) != 0 || !errors.Is(notif.Changes.Errors["invalid"], ErrDecodeFailed) {
+		t.Errorf("Expected invalid typed value to be reported as a decode error by the watch and got: %v", notif)
+	}
+
+	res, err := store.GetPrefix("/typed/")
+	if err != nil {
+		t.Errorf("Error occured getting typed prefix: %s", err.Error())
+		return
+	}
+	values := res.Keys.ToValueMap("/typed/")
+	if len(values) != 1 || values["a"].Count != 1 || len(res.Errors) != 1 || !errors.Is(res.Errors["/typed/invalid"], ErrDecodeFailed) {
+		t.Errorf("Expected invalid typed value in prefix to be reported separately from the valid one and got: %v, %v", values, res.Errors)
+	}
+	if res.Revision < rev {
+		t.Errorf("Expected typed prefix revision to be at least the revision of the put and got: %d", res.Revision)
+	}
+
+	err = store.Delete("/typed/a")
+	if err != nil {
+		t.Errorf("Error occured deleting typed value: %s", err.Error())
+	}
+
+	notif = <-watch
+	if notif.Error != nil || len(notif.Changes.Deletions) != 1 || notif.Changes.Deletions[0] != "a" {
+		t.Errorf("Expected typed value deletion to be reported by the watch and got: %v", notif)
+	}
+}
@@ -2,9 +2,7 @@ package client
 
 import (
 	"context"
-	"errors"
 	"fmt"
-	"time"
 
 	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 )
@@ -15,168 +13,193 @@ type EtcdUser struct {
 	Roles    []string
 }
 
-func (cli *EtcdClient) listUsersWithRetries(retries uint64) ([]string, error) {
-	ctx, cancel := context.WithTimeout(cli.Context, cli.RequestTimeout)
-	defer cancel()
-
-	res, err := cli.Client.UserList(ctx)
-	if err != nil {
-		if !shouldRetry(err, retries) {
-			return []string{}, err
-		}
-
-		time.Sleep(cli.RetryInterval)
-		return cli.listUsersWithRetries(retries - 1)
-	}
-
-	return res.Users, nil
-}
-
 func (cli *EtcdClient) ListUsers() ([]string, error) {
-	return cli.listUsersWithRetries(cli.Retries)
+	return cli.ListUsersCtx(cli.Context)
 }
 
-func (cli *EtcdClient) insertEmptyUserWithRetries(username string, password string, retries uint64) error {
-	ctx, cancel := context.WithTimeout(cli.Context, cli.RequestTimeout)
-	defer cancel()
+/*
+Variant of ListUsers that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) ListUsersCtx(ctx context.Context) (_ []string, err error) {
+	ctx, op := cli.startOperation(ctx, "ListUsers", "")
+	defer func() { op.end(err) }()
 
-	_, err := cli.Client.UserAdd(ctx, username, password)
-	if err != nil {
-		if !shouldRetry(err, retries) {
+	users := []string{}
+	err = cli.withRetries(ctx, func() error {
+		ctx, cancel := context.WithTimeout(ctx, cli.RequestTimeout)
+		defer cancel()
+
+		res, err := cli.Client.UserList(ctx)
+		if err != nil {
 			return err
 		}
 
-		time.Sleep(cli.RetryInterval)
-		return cli.insertEmptyUserWithRetries(username, password, retries-1)
-	}
+		users = res.Users
+		return nil
+	})
 
-	return nil
+	return users, err
 }
 
 func (cli *EtcdClient) InsertEmptyUser(username string, password string) error {
-	return cli.insertEmptyUserWithRetries(username, password, cli.Retries)
+	return cli.InsertEmptyUserCtx(cli.Context, username, password)
 }
 
-func (cli *EtcdClient) getUserRolesWithRetries(username string, retries uint64) ([]string, bool, error) {
-	ctx, cancel := context.WithTimeout(cli.Context, cli.RequestTimeout)
-	defer cancel()
+/*
+Variant of InsertEmptyUser that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) InsertEmptyUserCtx(ctx context.Context, username string, password string) (err error) {
+	ctx, op := cli.startOperation(ctx, "InsertEmptyUser", "")
+	defer func() { op.end(err) }()
 
-	res, err := cli.Client.UserGet(ctx, username)
-	if err != nil {
-		etcdErr, ok := err.(rpctypes.EtcdError)
-		if ok && etcdErr.Error() == rpctypes.ErrorDesc(rpctypes.ErrGRPCUserNotFound) {
-			return []string{}, false, nil
-		}
+	return cli.withRetries(ctx, func() error {
+		ctx, cancel := context.WithTimeout(ctx, cli.RequestTimeout)
+		defer cancel()
 
-		if !shouldRetry(err, retries) {
-			return []string{}, false, err
-		}
-
-		time.Sleep(cli.RetryInterval)
-		return cli.getUserRolesWithRetries(username, retries-1)
-	}
-
-	return res.Roles, true, nil
+		_, err := cli.Client.UserAdd(ctx, username, password)
+		return err
+	})
 }
 
 func (cli *EtcdClient) GetUserRoles(username string) ([]string, bool, error) {
-	return cli.getUserRolesWithRetries(username, cli.Retries)
+	return cli.GetUserRolesCtx(cli.Context, username)
 }
 
-func (cli *EtcdClient) changeUserPasswordWithRetries(username string, password string, retries uint64) error {
-	ctx, cancel := context.WithTimeout(cli.Context, cli.RequestTimeout)
-	defer cancel()
+/*
+Variant of GetUserRoles that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) GetUserRolesCtx(ctx context.Context, username string) (_ []string, _ bool, err error) {
+	ctx, op := cli.startOperation(ctx, "GetUserRoles", "")
+	defer func() { op.end(err) }()
+
+	roles := []string{}
+	found := false
+	err = cli.withRetries(ctx, func() error {
+		ctx, cancel := context.WithTimeout(ctx, cli.RequestTimeout)
+		defer cancel()
+
+		res, err := cli.Client.UserGet(ctx, username)
+		if err != nil {
+			etcdErr, ok := err.(rpctypes.EtcdError)
+			if ok && etcdErr.Error() == rpctypes.ErrorDesc(rpctypes.ErrGRPCUserNotFound) {
+				return nil
+			}
 
-	_, err := cli.Client.UserChangePassword(ctx, username, password)
-	if err != nil {
-		if !shouldRetry(err, retries) {
 			return err
 		}
 
-		time.Sleep(cli.RetryInterval)
-		return cli.changeUserPasswordWithRetries(username, password, retries-1)
+		roles = res.Roles
+		found = true
+		return nil
+	})
+
+	if err != nil {
+		return []string{}, false, err
 	}
 
-	return nil
+	return roles, found, nil
 }
 
 func (cli *EtcdClient) ChangeUserPassword(username string, password string) error {
-	return cli.changeUserPasswordWithRetries(username, password, cli.Retries)
+	return cli.ChangeUserPasswordCtx(cli.Context, username, password)
 }
 
-func (cli *EtcdClient) grantUserRoleWithRetries(username string, role string, retries uint64) error {
-	ctx, cancel := context.WithTimeout(cli.Context, cli.RequestTimeout)
-	defer cancel()
-
-	_, err := cli.Client.UserGrantRole(ctx, username, role)
-	if err != nil {
-		if !shouldRetry(err, retries) {
-			return err
-		}
+/*
+Variant of ChangeUserPassword that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) ChangeUserPasswordCtx(ctx context.Context, username string, password string) (err error) {
+	ctx, op := cli.startOperation(ctx, "ChangeUserPassword", "")
+	defer func() { op.end(err) }()
 
-		time.Sleep(cli.RetryInterval)
-		return cli.grantUserRoleWithRetries(username, role, retries-1)
-	}
+	return cli.withRetries(ctx, func() error {
+		ctx, cancel := context.WithTimeout(ctx, cli.RequestTimeout)
+		defer cancel()
 
-	return nil
+		_, err := cli.Client.UserChangePassword(ctx, username, password)
+		return err
+	})
 }
 
 func (cli *EtcdClient) GrantUserRole(username string, role string) error {
-	return cli.grantUserRoleWithRetries(username, role, cli.Retries)
+	return cli.GrantUserRoleCtx(cli.Context, username, role)
 }
 
-func (cli *EtcdClient) revokeUserRoleWithRetries(username string, role string, retries uint64) error {
-	ctx, cancel := context.WithTimeout(cli.Context, cli.RequestTimeout)
-	defer cancel()
+/*
+Variant of GrantUserRole that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) GrantUserRoleCtx(ctx context.Context, username string, role string) (err error) {
+	ctx, op := cli.startOperation(ctx, "GrantUserRole", "")
+	defer func() { op.end(err) }()
 
-	_, err := cli.Client.UserRevokeRole(ctx, username, role)
-	if err != nil {
-		if !shouldRetry(err, retries) {
-			return err
-		}
+	return cli.withRetries(ctx, func() error {
+		ctx, cancel := context.WithTimeout(ctx, cli.RequestTimeout)
+		defer cancel()
 
-		time.Sleep(cli.RetryInterval)
-		return cli.revokeUserRoleWithRetries(username, role, retries-1)
-	}
-
-	return nil
+		_, err := cli.Client.UserGrantRole(ctx, username, role)
+		return err
+	})
 }
 
 func (cli *EtcdClient) RevokeUserRole(username string, role string) error {
-	return cli.revokeUserRoleWithRetries(username, role, cli.Retries)
+	return cli.RevokeUserRoleCtx(cli.Context, username, role)
 }
 
-func (cli *EtcdClient) deleteUserWithRetries(username string, retries uint64) error {
-	ctx, cancel := context.WithTimeout(cli.Context, cli.RequestTimeout)
-	defer cancel()
+/*
+Variant of RevokeUserRole that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) RevokeUserRoleCtx(ctx context.Context, username string, role string) (err error) {
+	ctx, op := cli.startOperation(ctx, "RevokeUserRole", "")
+	defer func() { op.end(err) }()
 
-	_, err := cli.Client.UserDelete(ctx, username)
-	if err != nil {
-		if !shouldRetry(err, retries) {
-			return err
-		}
-
-		time.Sleep(cli.RetryInterval)
-		return cli.deleteUserWithRetries(username, retries-1)
-	}
+	return cli.withRetries(ctx, func() error {
+		ctx, cancel := context.WithTimeout(ctx, cli.RequestTimeout)
+		defer cancel()
 
-	return nil
+		_, err := cli.Client.UserRevokeRole(ctx, username, role)
+		return err
+	})
 }
 
 func (cli *EtcdClient) DeleteUser(username string) error {
-	return cli.deleteUserWithRetries(username, cli.Retries)
+	return cli.DeleteUserCtx(cli.Context, username)
+}
+
+/*
+Variant of DeleteUser that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) DeleteUserCtx(ctx context.Context, username string) (err error) {
+	ctx, op := cli.startOperation(ctx, "DeleteUser", "")
+	defer func() { op.end(err) }()
+
+	return cli.withRetries(ctx, func() error {
+		ctx, cancel := context.WithTimeout(ctx, cli.RequestTimeout)
+		defer cancel()
+
+		_, err := cli.Client.UserDelete(ctx, username)
+		return err
+	})
 }
 
 func (cli *EtcdClient) InsertUser(user EtcdUser) error {
-	err := cli.InsertEmptyUser(user.Username, user.Password)
+	return cli.InsertUserCtx(cli.Context, user)
+}
+
+/*
+Variant of InsertUser that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) InsertUserCtx(ctx context.Context, user EtcdUser) (err error) {
+	ctx, op := cli.startOperation(ctx, "InsertUser", "")
+	defer func() { op.end(err) }()
+
+	err = cli.InsertEmptyUserCtx(ctx, user.Username, user.Password)
 	if err != nil {
-		return errors.New(fmt.Sprintf("Error creating new user '%s': %s", user.Username, err.Error()))
+		return fmt.Errorf("Error creating new user '%s': %w", user.Username, err)
 	}
 
 	for _, role := range user.Roles {
-		err := cli.GrantUserRole(user.Username, role)
+		err := cli.GrantUserRoleCtx(ctx, user.Username, role)
 		if err != nil {
-			return errors.New(fmt.Sprintf("Error adding role '%s' to user '%s': %s", role, user.Username, err.Error()))
+			return fmt.Errorf("Error adding role '%s' to user '%s': %w", role, user.Username, err)
 		}
 	}
 
@@ -184,14 +207,24 @@ func (cli *EtcdClient) InsertUser(user EtcdUser) error {
 }
 
 func (cli *EtcdClient) UpdateUser(user EtcdUser) error {
-	resRoles, _, userRolesErr := cli.GetUserRoles(user.Username)
+	return cli.UpdateUserCtx(cli.Context, user)
+}
+
+/*
+Variant of UpdateUser that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) UpdateUserCtx(ctx context.Context, user EtcdUser) (err error) {
+	ctx, op := cli.startOperation(ctx, "UpdateUser", "")
+	defer func() { op.end(err) }()
+
+	resRoles, _, userRolesErr := cli.GetUserRolesCtx(ctx, user.Username)
 	if userRolesErr != nil {
-		return errors.New(fmt.Sprintf("Error retrieving existing user '%s' for update: %s", user.Username, userRolesErr.Error()))
+		return fmt.Errorf("Error retrieving existing user '%s' for update: %w", user.Username, userRolesErr)
 	}
 
-	passErr := cli.ChangeUserPassword(user.Username, user.Password)
+	passErr := cli.ChangeUserPasswordCtx(ctx, user.Username, user.Password)
 	if passErr != nil {
-		return errors.New(fmt.Sprintf("Error updating password of user '%s': %s", user.Username, passErr.Error()))
+		return fmt.Errorf("Error updating password of user '%s': %w", user.Username, passErr)
 	}
 
 	for _, role := range user.Roles {
@@ -203,9 +236,9 @@ func (cli *EtcdClient) UpdateUser(user EtcdUser) error {
 		}
 
 		if add {
-			err := cli.GrantUserRole(user.Username, role)
+			err := cli.GrantUserRoleCtx(ctx, user.Username, role)
 			if err != nil {
-				return errors.New(fmt.Sprintf("Error adding role '%s' to user '%s': %s", role, user.Username, err.Error()))
+				return fmt.Errorf("Error adding role '%s' to user '%s': %w", role, user.Username, err)
 			}
 		}
 	}
@@ -219,9 +252,9 @@ func (cli *EtcdClient) UpdateUser(user EtcdUser) error {
 		}
 
 		if remove {
-			err := cli.RevokeUserRole(user.Username, resRole)
+			err := cli.RevokeUserRoleCtx(ctx, user.Username, resRole)
 			if err != nil {
-				return errors.New(fmt.Sprintf("Error removing role '%s' from user '%s': %s", resRole, user.Username, err.Error()))
+				return fmt.Errorf("Error removing role '%s' from user '%s': %w", resRole, user.Username, err)
 			}
 		}
 	}
@@ -230,14 +263,24 @@ func (cli *EtcdClient) UpdateUser(user EtcdUser) error {
 }
 
 func (cli *EtcdClient) UpsertUser(user EtcdUser) error {
-	users, err := cli.ListUsers()
+	return cli.UpsertUserCtx(cli.Context, user)
+}
+
+/*
+Variant of UpsertUser that takes a context to cancel the operation or set a deadline on it.
+*/
+func (cli *EtcdClient) UpsertUserCtx(ctx context.Context, user EtcdUser) (err error) {
+	ctx, op := cli.startOperation(ctx, "UpsertUser", "")
+	defer func() { op.end(err) }()
+
+	users, err := cli.ListUsersCtx(ctx)
 	if err != nil {
-		return errors.New(fmt.Sprintf("Error retrieving existing users list: %s", err.Error()))
+		return fmt.Errorf("Error retrieving existing users list: %w", err)
 	}
 
 	if isStringInSlice(user.Username, users) {
-		return cli.UpdateUser(user)
+		return cli.UpdateUserCtx(ctx, user)
 	}
 
-	return cli.InsertUser(user)
+	return cli.InsertUserCtx(ctx, user)
 }
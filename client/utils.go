@@ -1,5 +1,14 @@
 package client
 
+/*
+Adapter to use a read function as an io.Reader.
+*/
+type readerFunc func(p []byte) (int, error)
+
+func (f readerFunc) Read(p []byte) (int, error) {
+	return f(p)
+}
+
 func isStringInSlice(val string, slice []string) bool {
 	for _, elem := range slice {
 		if elem == val {
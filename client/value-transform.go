@@ -0,0 +1,228 @@
+package client
+
+import (
+	"context"
+
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+/*
+Transformation applied to values before they are stored in etcd and reverted when they are read back, like encryption or compression.
+*/
+type valueTransform interface {
+	encode(key []byte, val []byte) ([]byte, error)
+	decode(key []byte, val []byte) ([]byte, error)
+}
+
+func decodeKvs(transform valueTransform, kvs ...*mvccpb.KeyValue) error {
+	for _, kv := range kvs {
+		if kv == nil || len(kv.Value) == 0 {
+			continue
+		}
+
+		val, err := transform.decode(kv.Key, kv.Value)
+		if err != nil {
+			return err
+		}
+		kv.Value = val
+	}
+
+	return nil
+}
+
+func encodeOp(transform valueTransform, op clientv3.Op) (clientv3.Op, error) {
+	if op.IsTxn() {
+		cmps, thenOps, elseOps := op.Txn()
+		encThenOps, err := encodeOps(transform, thenOps)
+		if err != nil {
+			return op, err
+		}
+
+		encElseOps, err := encodeOps(transform, elseOps)
+		if err != nil {
+			return op, err
+		}
+
+		return clientv3.OpTxn(cmps, encThenOps, encElseOps), nil
+	}
+
+	if !op.IsPut() {
+		return op, nil
+	}
+
+	val, err := transform.encode(op.KeyBytes(), op.ValueBytes())
+	if err != nil {
+		return op, err
+	}
+	op.WithValueBytes(val)
+
+	return op, nil
+}
+
+func encodeOps(transform valueTransform, ops []clientv3.Op) ([]clientv3.Op, error) {
+	encOps := []clientv3.Op{}
+	for _, op := range ops {
+		encOp, err := encodeOp(transform, op)
+		if err != nil {
+			return nil, err
+		}
+		encOps = append(encOps, encOp)
+	}
+
+	return encOps, nil
+}
+
+func decodeResponseOps(transform valueTransform, responses []*etcdserverpb.ResponseOp) error {
+	for _, response := range responses {
+		var err error
+		if rangeRes := response.GetResponseRange(); rangeRes != nil {
+			err = decodeKvs(transform, rangeRes.Kvs...)
+		} else if putRes := response.GetResponsePut(); putRes != nil {
+			err = decodeKvs(transform, putRes.PrevKv)
+		} else if deleteRes := response.GetResponseDeleteRange(); deleteRes != nil {
+			err = decodeKvs(transform, deleteRes.PrevKvs...)
+		} else if txnRes := response.GetResponseTxn(); txnRes != nil {
+			err = decodeResponseOps(transform, txnRes.Responses)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/*
+Implementation of the etcd KV interface that transforms values transparently, in the manner of etcd's namespace package.
+Transforms are stacked by wrapping a transformed KV in another: values are encoded by the outermost transform first and decoded by it last.
+*/
+type transformedKV struct {
+	clientv3.KV
+	transform valueTransform
+}
+
+func (kv *transformedKV) Put(ctx context.Context, key string, val string, opts ...clientv3.OpOption) (*clientv3.PutResponse, error) {
+	res, err := kv.Do(ctx, clientv3.OpPut(key, val, opts...))
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Put(), nil
+}
+
+func (kv *transformedKV) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	res, err := kv.Do(ctx, clientv3.OpGet(key, opts...))
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Get(), nil
+}
+
+func (kv *transformedKV) Delete(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.DeleteResponse, error) {
+	res, err := kv.Do(ctx, clientv3.OpDelete(key, opts...))
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Del(), nil
+}
+
+func (kv *transformedKV) Do(ctx context.Context, op clientv3.Op) (clientv3.OpResponse, error) {
+	encOp, err := encodeOp(kv.transform, op)
+	if err != nil {
+		return clientv3.OpResponse{}, err
+	}
+
+	res, err := kv.KV.Do(ctx, encOp)
+	if err != nil {
+		return res, err
+	}
+
+	if get := res.Get(); get != nil {
+		err = decodeKvs(kv.transform, get.Kvs...)
+	} else if put := res.Put(); put != nil {
+		err = decodeKvs(kv.transform, put.PrevKv)
+	} else if del := res.Del(); del != nil {
+		err = decodeKvs(kv.transform, del.PrevKvs...)
+	} else if txn := res.Txn(); txn != nil {
+		err = decodeResponseOps(kv.transform, txn.Responses)
+	}
+
+	return res, err
+}
+
+func (kv *transformedKV) Txn(ctx context.Context) clientv3.Txn {
+	return &transformedTxn{Txn: kv.KV.Txn(ctx), transform: kv.transform}
+}
+
+type transformedTxn struct {
+	clientv3.Txn
+	transform valueTransform
+	err       error
+}
+
+func (txn *transformedTxn) If(cmps ...clientv3.Cmp) clientv3.Txn {
+	txn.Txn = txn.Txn.If(cmps...)
+	return txn
+}
+
+func (txn *transformedTxn) Then(ops ...clientv3.Op) clientv3.Txn {
+	encOps, err := encodeOps(txn.transform, ops)
+	if err != nil {
+		txn.err = err
+		return txn
+	}
+
+	txn.Txn = txn.Txn.Then(encOps...)
+	return txn
+}
+
+func (txn *transformedTxn) Else(ops ...clientv3.Op) clientv3.Txn {
+	encOps, err := encodeOps(txn.transform, ops)
+	if err != nil {
+		txn.err = err
+		return txn
+	}
+
+	txn.Txn = txn.Txn.Else(encOps...)
+	return txn
+}
+
+func (txn *transformedTxn) Commit() (*clientv3.TxnResponse, error) {
+	if txn.err != nil {
+		return nil, txn.err
+	}
+
+	res, err := txn.Txn.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return res, decodeResponseOps(txn.transform, res.Responses)
+}
+
+/*
+Decodes the value of a key reported by a watch, which does not go through the KV interface of the etcd client.
+*/
+func (cli *EtcdClient) decodeEvent(ev *clientv3.Event) error {
+	if ev.Type != mvccpb.PUT {
+		return nil
+	}
+
+	if cli.encryption != nil {
+		err := decodeKvs(cli.encryption, ev.Kv)
+		if err != nil {
+			return err
+		}
+	}
+
+	if cli.compression != nil {
+		return decodeKvs(cli.compression, ev.Kv)
+	}
+
+	return nil
+}
@@ -22,16 +22,39 @@ type KeyDiffResult struct {
 	Error error
 }
 
+type KeyBytesDiffResult struct {
+	KeyDiff client.KeyBytesDiff
+	Error error
+}
+
 func ProcessSendKeyDiffRequests(reqCh <-chan *SendKeyDiffRequest) <-chan KeyDiffResult {
+	resCh := make(chan KeyDiffResult)
+
+	go func() {
+		defer close(resCh)
+		for res := range ProcessSendKeyBytesDiffRequests(reqCh) {
+			if res.Error != nil {
+				resCh <- KeyDiffResult{Error: res.Error}
+				continue
+			}
+
+			resCh <- KeyDiffResult{KeyDiff: res.KeyDiff.ToStrings()}
+		}
+	}()
+
+	return resCh
+}
+
+func ProcessSendKeyBytesDiffRequests(reqCh <-chan *SendKeyDiffRequest) <-chan KeyBytesDiffResult {
 	inserts := int64(0)
 	updates := int64(0)
 	deletions := int64(0)
-	diff := client.KeyDiff{
-		Inserts: map[string]string{},
-		Updates: map[string]string{},
+	diff := client.KeyBytesDiff{
+		Inserts: map[string][]byte{},
+		Updates: map[string][]byte{},
 		Deletions: []string{},
 	}
-	resCh := make(chan KeyDiffResult)
+	resCh := make(chan KeyBytesDiffResult)
 
 	go func() {
 		defer close(resCh)
@@ -39,7 +62,7 @@ func ProcessSendKeyDiffRequests(reqCh <-chan *SendKeyDiffRequest) <-chan KeyDiff
 
 		overview, ok := req.KeyDiff.Content.(*KeyDiff_Overview)
 		if !ok {
-			resCh <- KeyDiffResult{Error: ErrKeyDiffRequestNotOverview}
+			resCh <- KeyBytesDiffResult{Error: ErrKeyDiffRequestNotOverview}
 			return
 		}
 
@@ -50,31 +73,31 @@ func ProcessSendKeyDiffRequests(reqCh <-chan *SendKeyDiffRequest) <-chan KeyDiff
 		for req := range reqCh {
 			changes, ok := req.KeyDiff.Content.(*KeyDiff_Changes)
 			if !ok {
-				resCh <- KeyDiffResult{Error: ErrKeyDiffRequestNotChanges}
+				resCh <- KeyBytesDiffResult{Error: ErrKeyDiffRequestNotChanges}
 				return
 			}
 
 			for _, change := range changes.Changes.Changes {
 				switch change.Type {
 				case KeyDiffChangeType_INSERT:
-					diff.Inserts[change.Key] = string(change.Value)
+					diff.Inserts[change.Key] = change.Value
 				case KeyDiffChangeType_UPDATE:
-					diff.Updates[change.Key] = string(change.Value)
+					diff.Updates[change.Key] = change.Value
 				case KeyDiffChangeType_DELETION:
 					diff.Deletions = append(diff.Deletions, change.Key)
 				default:
-					resCh <- KeyDiffResult{Error: ErrInvalidChangeType}
+					resCh <- KeyBytesDiffResult{Error: ErrInvalidChangeType}
 					return
 				}
 			}
 		}
 
 		if int64(len(diff.Inserts)) != inserts || int64(len(diff.Updates)) != updates || int64(len(diff.Deletions)) != deletions {
-			resCh <- KeyDiffResult{Error: ErrInvalidChangeType}
+			resCh <- KeyBytesDiffResult{Error: ErrInvalidChangeType}
 			return
 		}
 
-		resCh <- KeyDiffResult{KeyDiff: diff}
+		resCh <- KeyBytesDiffResult{KeyDiff: diff}
 	}()
 
 	return resCh
@@ -93,6 +116,10 @@ func getNewSendKeyDiffRequestCh() *SendKeyDiffRequest {
 }
 
 func GenSendKeyDiffRequests(diff client.KeyDiff, maxChunkSize uint64, done <-chan struct{}) <-chan *SendKeyDiffRequest {
+	return GenSendKeyBytesDiffRequests(diff.ToBytes(), maxChunkSize, done)
+}
+
+func GenSendKeyBytesDiffRequests(diff client.KeyBytesDiff, maxChunkSize uint64, done <-chan struct{}) <-chan *SendKeyDiffRequest {
 	send := make(chan *SendKeyDiffRequest)
 
 	go func() {
@@ -131,7 +158,7 @@ func GenSendKeyDiffRequests(diff client.KeyDiff, maxChunkSize uint64, done <-cha
 	
 			req.KeyDiff.Content.(*KeyDiff_Changes).Changes.Changes = append(req.KeyDiff.Content.(*KeyDiff_Changes).Changes.Changes, &KeyDiffChange{
 				Key: key,
-				Value: []byte(val),
+				Value: val,
 				Type: KeyDiffChangeType_INSERT,
 			})
 			sendSize = sendSize + uint64(len(key)) + uint64(len(val))
@@ -153,7 +180,7 @@ func GenSendKeyDiffRequests(diff client.KeyDiff, maxChunkSize uint64, done <-cha
 	
 			req.KeyDiff.Content.(*KeyDiff_Changes).Changes.Changes = append(req.KeyDiff.Content.(*KeyDiff_Changes).Changes.Changes, &KeyDiffChange{
 				Key: key,
-				Value: []byte(val),
+				Value: val,
 				Type: KeyDiffChangeType_UPDATE,
 			})
 			sendSize = sendSize + uint64(len(key)) + uint64(len(val))
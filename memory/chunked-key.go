@@ -0,0 +1,177 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/Ferlab-Ste-Justine/etcd-sdk/client"
+)
+
+const chunkSize = int64(1024 * 1024)
+
+func (c *Client) getChunkedKeyInfo(ctx context.Context, key string) (*client.ChunkedKeyInfo, int64, error) {
+	info, err := c.GetKeyCtx(ctx, fmt.Sprintf("%s/info", key), client.GetKeyOptions{})
+	if err != nil || (!info.Found()) {
+		return nil, 0, err
+	}
+
+	cKeyInfo := client.ChunkedKeyInfo{}
+	unmarshalErr := json.Unmarshal([]byte(info.Value), &cKeyInfo)
+	if unmarshalErr != nil {
+		return nil, info.ModRevision, unmarshalErr
+	}
+
+	return &cKeyInfo, info.ModRevision, nil
+}
+
+/*
+Stores the payload in chunks, using the same keys layout as the etcd client.
+*/
+func (c *Client) PutChunkedKey(key *client.ChunkedKeyPayload) error {
+	return c.PutChunkedKeyCtx(c.Context, key)
+}
+
+func (c *Client) PutChunkedKeyCtx(ctx context.Context, key *client.ChunkedKeyPayload) error {
+	keyInfo, _, infoErr := c.getChunkedKeyInfo(ctx, key.Key)
+	if infoErr != nil {
+		return infoErr
+	}
+
+	version := int64(0)
+	if keyInfo != nil {
+		version = keyInfo.Version
+	}
+
+	clearErr := c.DeletePrefixCtx(ctx, fmt.Sprintf("%s/chunks/v%d/", key.Key, version+1))
+	if clearErr != nil {
+		return clearErr
+	}
+
+	chunks := key.Size / chunkSize
+	if (key.Size % chunkSize) > 0 {
+		chunks += 1
+	}
+
+	for idx := int64(0); idx < chunks; idx++ {
+		size := chunkSize
+		if idx == chunks-1 && (key.Size%chunkSize) > 0 {
+			size = key.Size % chunkSize
+		}
+
+		buf := make([]byte, size)
+		_, readErr := io.ReadFull(key.Value, buf)
+		if readErr != nil {
+			return readErr
+		}
+
+		_, putErr := c.PutKeyCtx(ctx, fmt.Sprintf("%s/chunks/v%d/%d", key.Key, version+1, idx), string(buf))
+		if putErr != nil {
+			return putErr
+		}
+	}
+
+	output, _ := json.Marshal(client.ChunkedKeyInfo{
+		Size:    key.Size,
+		Count:   chunks,
+		Version: version + 1,
+	})
+	previousChunks := fmt.Sprintf("%s/chunks/v%d/", key.Key, version)
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.apply([]op{
+		op{Key: fmt.Sprintf("%s/info", key.Key), Value: string(output)},
+		op{Key: previousChunks, RangeEnd: clientv3.GetPrefixRangeEnd(previousChunks), Delete: true},
+	})
+	return nil
+}
+
+type chunksReader struct {
+	client *Client
+	ctx    context.Context
+	key    string
+	index  int64
+	info   client.ChunkedKeyInfo
+	buffer bytes.Buffer
+}
+
+func (r *chunksReader) Close() error {
+	return nil
+}
+
+func (r *chunksReader) Read(p []byte) (n int, err error) {
+	if r.buffer.Len() > 0 {
+		return r.buffer.Read(p)
+	}
+
+	if r.index == r.info.Count {
+		return 0, io.EOF
+	}
+
+	chunkKey := fmt.Sprintf("%s/chunks/v%d/%d", r.key, r.info.Version, r.index)
+	kInfo, kErr := r.client.GetKeyCtx(r.ctx, chunkKey, client.GetKeyOptions{})
+	if kErr != nil {
+		return 0, kErr
+	}
+	if !kInfo.Found() {
+		return 0, &client.KeyError{Key: chunkKey, Kind: client.ErrChunkMissing}
+	}
+
+	r.index += 1
+	r.buffer.WriteString(kInfo.Value)
+	return r.buffer.Read(p)
+}
+
+/*
+Returns a payload to read the value of a chunked key. The payload will be nil if the key does not exist.
+*/
+func (c *Client) GetChunkedKey(key string) (*client.ChunkedKeyPayload, error) {
+	return c.GetChunkedKeyCtx(c.Context, key)
+}
+
+func (c *Client) GetChunkedKeyCtx(ctx context.Context, key string) (*client.ChunkedKeyPayload, error) {
+	keyInfo, _, infoErr := c.getChunkedKeyInfo(ctx, key)
+	if infoErr != nil || keyInfo == nil {
+		return nil, infoErr
+	}
+
+	return &client.ChunkedKeyPayload{
+		Key:   key,
+		Value: &chunksReader{client: c, ctx: ctx, key: key, info: *keyInfo},
+		Size:  keyInfo.Size,
+	}, nil
+}
+
+/*
+Deletes the chunked key information and all of its chunks at a single revision.
+*/
+func (c *Client) DeleteChunkedKey(key string) error {
+	return c.DeleteChunkedKeyCtx(c.Context, key)
+}
+
+func (c *Client) DeleteChunkedKeyCtx(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	chunksPrefix := fmt.Sprintf("%s/chunks/", key)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.apply([]op{
+		op{Key: fmt.Sprintf("%s/info", key), Delete: true},
+		op{Key: chunksPrefix, RangeEnd: clientv3.GetPrefixRangeEnd(chunksPrefix), Delete: true},
+	})
+	return nil
+}
@@ -0,0 +1,266 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"sync"
+
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/Ferlab-Ste-Justine/etcd-sdk/client"
+)
+
+/*
+State of a key at a given revision.
+A deleted key is recorded as a tombstone at the revision of the deletion.
+*/
+type keyVersion struct {
+	Value          string
+	Version        int64
+	CreateRevision int64
+	ModRevision    int64
+	Lease          int64
+	Deleted        bool
+}
+
+type event struct {
+	Key string
+	Kv  keyVersion
+}
+
+/*
+Changes committed together at a given revision.
+*/
+type revisionEvents struct {
+	Revision int64
+	Events   []event
+}
+
+/*
+Operation to apply on the store. It is a deletion of the key or range of keys if Delete is true and an upsert of the key otherwise.
+*/
+type op struct {
+	Key      string
+	RangeEnd string
+	Value    string
+	Lease    int64
+	Delete   bool
+}
+
+/*
+In-memory implementation of the key-value operations of the client, meant to unit test code depending on the client.KeyValueStore interface without an etcd cluster.
+It keeps the full history of all keys to emulate etcd's revisions, which makes it unsuitable outside of tests.
+It should be instanciated with the NewClient function.
+*/
+type Client struct {
+	//Context used by the methods that don't take a context as argument
+	Context  context.Context
+	mutex    sync.Mutex
+	revision int64
+	keys     map[string][]keyVersion
+	log      []revisionEvents
+	leases   map[clientv3.LeaseID]*lease
+	leaseId  int64
+	changed  chan struct{}
+}
+
+var _ client.KeyValueStore = (*Client)(nil)
+
+/*
+Returns a new empty in-memory store.
+*/
+func NewClient() *Client {
+	return &Client{
+		Context:  context.Background(),
+		revision: 1,
+		keys:     make(map[string][]keyVersion),
+		log:      []revisionEvents{},
+		leases:   make(map[clientv3.LeaseID]*lease),
+		changed:  make(chan struct{}),
+	}
+}
+
+/*
+Returns the current revision of the store.
+*/
+func (c *Client) Revision() int64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.revision
+}
+
+//Returns the state of the key at the given revision, or at the latest revision if revision is not positive.
+//Must be called with the mutex held.
+func (c *Client) read(key string, revision int64) (keyVersion, bool) {
+	versions := c.keys[key]
+	for idx := len(versions) - 1; idx >= 0; idx-- {
+		if revision <= 0 || versions[idx].ModRevision <= revision {
+			if versions[idx].Deleted {
+				return keyVersion{}, false
+			}
+			return versions[idx], true
+		}
+	}
+
+	return keyVersion{}, false
+}
+
+//Returns the sorted keys within the range that exist at the given revision.
+//Like with etcd, an empty range end designates a single key and a range end of "\x00" designates all the keys greater or equal to the key.
+//Must be called with the mutex held.
+func (c *Client) rangeKeys(key string, rangeEnd string, revision int64) []string {
+	if rangeEnd == "" {
+		if _, ok := c.read(key, revision); ok {
+			return []string{key}
+		}
+		return []string{}
+	}
+
+	keys := []string{}
+	for candidate, _ := range c.keys {
+		if candidate < key || (rangeEnd != "\x00" && candidate >= rangeEnd) {
+			continue
+		}
+
+		if _, ok := c.read(candidate, revision); ok {
+			keys = append(keys, candidate)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+//Returns an error if the revision cannot be read.
+//Must be called with the mutex held.
+func (c *Client) checkRevision(revision int64) error {
+	if revision > c.revision {
+		return rpctypes.ErrFutureRev
+	}
+
+	return nil
+}
+
+func toKeyInfo(key string, kv keyVersion) client.KeyInfo {
+	return client.KeyInfo{
+		Key:            key,
+		Value:          kv.Value,
+		Version:        kv.Version,
+		CreateRevision: kv.CreateRevision,
+		ModRevision:    kv.ModRevision,
+		Lease:          kv.Lease,
+	}
+}
+
+//Applies the operations atomically at the next revision and returns the revision of the store afterwards.
+//The revision is not incremented if the operations change nothing, as is the case with etcd.
+//Must be called with the mutex held.
+func (c *Client) apply(ops []op) int64 {
+	next := c.revision + 1
+	events := []event{}
+
+	for _, o := range ops {
+		if o.Delete {
+			for _, key := range c.rangeKeys(o.Key, o.RangeEnd, 0) {
+				prev, _ := c.read(key, 0)
+				c.detachLease(key, prev.Lease)
+				tombstone := keyVersion{ModRevision: next, Deleted: true}
+				c.keys[key] = append(c.keys[key], tombstone)
+				events = append(events, event{Key: key, Kv: tombstone})
+			}
+			continue
+		}
+
+		kv := keyVersion{
+			Value:          o.Value,
+			Version:        1,
+			CreateRevision: next,
+			ModRevision:    next,
+			Lease:          o.Lease,
+		}
+		prev, exists := c.read(o.Key, 0)
+		if exists {
+			kv.Version = prev.Version + 1
+			kv.CreateRevision = prev.CreateRevision
+			c.detachLease(o.Key, prev.Lease)
+		}
+		c.attachLease(o.Key, o.Lease)
+		c.keys[o.Key] = append(c.keys[o.Key], kv)
+		events = append(events, event{Key: o.Key, Kv: kv})
+	}
+
+	if len(events) == 0 {
+		return c.revision
+	}
+
+	c.revision = next
+	c.log = append(c.log, revisionEvents{Revision: next, Events: events})
+	close(c.changed)
+	c.changed = make(chan struct{})
+
+	return c.revision
+}
+
+//Evaluates transaction conditions the way etcd would.
+//Must be called with the mutex held.
+func (c *Client) compare(conditions []clientv3.Cmp) bool {
+	for _, cond := range conditions {
+		keys := []string{string(cond.Key)}
+		if len(cond.RangeEnd) > 0 {
+			keys = c.rangeKeys(string(cond.Key), string(cond.RangeEnd), 0)
+		}
+
+		for _, key := range keys {
+			kv, exists := c.read(key, 0)
+			if (!exists) && cond.Target == etcdserverpb.Compare_VALUE {
+				return false
+			}
+
+			result := 0
+			switch union := cond.TargetUnion.(type) {
+			case *etcdserverpb.Compare_Value:
+				result = bytes.Compare([]byte(kv.Value), union.Value)
+			case *etcdserverpb.Compare_Version:
+				result = compareInt(kv.Version, union.Version)
+			case *etcdserverpb.Compare_CreateRevision:
+				result = compareInt(kv.CreateRevision, union.CreateRevision)
+			case *etcdserverpb.Compare_ModRevision:
+				result = compareInt(kv.ModRevision, union.ModRevision)
+			case *etcdserverpb.Compare_Lease:
+				result = compareInt(kv.Lease, union.Lease)
+			}
+
+			ok := false
+			switch cond.Result {
+			case etcdserverpb.Compare_EQUAL:
+				ok = result == 0
+			case etcdserverpb.Compare_NOT_EQUAL:
+				ok = result != 0
+			case etcdserverpb.Compare_GREATER:
+				ok = result > 0
+			case etcdserverpb.Compare_LESS:
+				ok = result < 0
+			}
+
+			if !ok {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func compareInt(first int64, second int64) int {
+	if first < second {
+		return -1
+	} else if first > second {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,210 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/Ferlab-Ste-Justine/etcd-sdk/client"
+)
+
+func TestKeyRevisions(t *testing.T) {
+	cli := NewClient()
+
+	rev1, _ := cli.PutKey("test", "testv1")
+	rev2, _ := cli.PutKey("test", "testv2")
+	if rev2 != rev1+1 {
+		t.Errorf("Expected each put to increment the revision by one and got %d after %d", rev2, rev1)
+	}
+
+	info, _ := cli.GetKey("test", client.GetKeyOptions{Revision: rev1})
+	if info.Value != "testv1" || info.Version != 1 || info.CreateRevision != rev1 {
+		t.Errorf("Expected key at past revision to be testv1 at version 1 and got %s at version %d", info.Value, info.Version)
+	}
+
+	info, _ = cli.GetKey("test", client.GetKeyOptions{})
+	if info.Value != "testv2" || info.Version != 2 || info.CreateRevision != rev1 || info.ModRevision != rev2 {
+		t.Errorf("Expected latest key to be testv2 at version 2 and got %s at version %d", info.Value, info.Version)
+	}
+
+	cli.DeleteKey("test")
+	info, _ = cli.GetKey("test", client.GetKeyOptions{})
+	if info.Found() {
+		t.Errorf("Expected key not to be found after deletion and it was")
+	}
+
+	info, _ = cli.GetKey("test", client.GetKeyOptions{Revision: rev2})
+	if info.Value != "testv2" {
+		t.Errorf("Expected deleted key to still be readable at a past revision and got: %s", info.Value)
+	}
+
+	rev3, _ := cli.PutKey("test", "testv3")
+	info, _ = cli.GetKey("test", client.GetKeyOptions{})
+	if info.Version != 1 || info.CreateRevision != rev3 {
+		t.Errorf("Expected recreated key to restart at version 1 and got version %d", info.Version)
+	}
+
+	_, err := cli.GetKey("test", client.GetKeyOptions{Revision: rev3 + 10})
+	if err == nil {
+		t.Errorf("Expected an error reading a future revision and got none")
+	}
+}
+
+func TestApplyDiffToPrefix(t *testing.T) {
+	cli := NewClient()
+
+	cli.PutKey("/src/a", "a")
+	cli.PutKey("/src/b", "b")
+	cli.PutKey("/dst/b", "old")
+	cli.PutKey("/dst/c", "c")
+
+	diff, _ := cli.DiffBetweenPrefixes("/src/", "/dst/")
+	if len(diff.Inserts) != 1 || len(diff.Updates) != 1 || len(diff.Deletions) != 1 {
+		t.Errorf("Expected one insert, one update and one deletion between the prefixes and got: %v", diff)
+	}
+
+	before := cli.Revision()
+	cli.ApplyDiffToPrefix("/dst/", diff)
+	if cli.Revision() != before+1 {
+		t.Errorf("Expected the diff to be applied at a single revision and the revision went from %d to %d", before, cli.Revision())
+	}
+
+	dst, _ := cli.GetPrefix("/dst/")
+	values := dst.Keys.ToValueMap("/dst/")
+	if len(values) != 2 || values["a"] != "a" || values["b"] != "b" {
+		t.Errorf("Expected the destination prefix to be like the source after applying the diff and got: %v", values)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	cli := NewClient()
+
+	rev, _ := cli.PutKey("/prefix/a", "a")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watch := cli.WatchCtx(ctx, "/prefix/", client.WatchOptions{Revision: rev, IsPrefix: true, TrimPrefix: true})
+
+	res := <-watch
+	if res.Changes.Upserts["a"].Value != "a" {
+		t.Errorf("Expected watch from a past revision to report past changes and got: %v", res.Changes)
+	}
+
+	cli.PutKey("/other", "other")
+	cli.ApplyDiffToPrefix("/prefix/", client.KeyDiff{Inserts: map[string]string{"b": "b"}, Deletions: []string{"a"}})
+
+	res = <-watch
+	if len(res.Changes.Upserts) != 1 || res.Changes.Upserts["b"].Value != "b" || len(res.Changes.Deletions) != 1 || res.Changes.Deletions[0] != "a" {
+		t.Errorf("Expected changes of a single revision to be reported together and got: %v", res.Changes)
+	}
+
+	cancel()
+	for range watch {}
+}
+
+func TestLock(t *testing.T) {
+	cli := NewClient()
+
+	lock, timeout, err := cli.AcquireLock(client.AcquireLockOptions{Key: "lock", Ttl: 1})
+	if err != nil || timeout {
+		t.Errorf("Expected lock to be acquired and it wasn't")
+		return
+	}
+
+	info, _ := cli.GetKey("lock", client.GetKeyOptions{})
+	if info.Lease != int64(lock.Lease) {
+		t.Errorf("Expected lock key to be attached to the lock lease and it wasn't")
+	}
+
+	_, timeout, err = cli.AcquireLock(client.AcquireLockOptions{Key: "lock", Timeout: 100 * time.Millisecond, RetryInterval: 10 * time.Millisecond})
+	if !timeout || !errors.Is(err, client.ErrLockTimeout) {
+		t.Errorf("Expected a held lock acquisition to time out and it didn't")
+	}
+
+	start := time.Now()
+	_, timeout, err = cli.AcquireLock(client.AcquireLockOptions{Key: "lock", Timeout: 5 * time.Second})
+	if err != nil || timeout {
+		t.Errorf("Expected lock to be acquired after the previous lock's ttl expired and it wasn't")
+	}
+	if time.Since(start) > 3*time.Second {
+		t.Errorf("Expected lock to be acquired shortly after the previous lock's ttl expired and it took %s", time.Since(start))
+	}
+
+	held, _, _ := cli.AcquireLock(client.AcquireLockOptions{Key: "fenced"})
+	cli.ReleaseLock("fenced")
+	newer, _, _ := cli.AcquireLock(client.AcquireLockOptions{Key: "fenced"})
+	if newer.FencingToken <= held.FencingToken {
+		t.Errorf("Expected the fencing token to increase with each acquisition and got %d after %d", newer.FencingToken, held.FencingToken)
+	}
+
+	err = cli.ReleaseHeldLock(held)
+	if !errors.Is(err, client.ErrLockNotHeld) {
+		t.Errorf("Expected releasing a lock acquired by another to return a not held error and got: %v", err)
+	}
+
+	err = cli.ReleaseHeldLock(newer)
+	if err != nil {
+		t.Errorf("Error occured releasing the held lock: %s", err.Error())
+	}
+
+	err = cli.ReleaseLock("lock")
+	if err != nil {
+		t.Errorf("Error occured releasing the lock: %s", err.Error())
+	}
+
+	_, err = cli.ReadLock("lock")
+	if !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("Expected reading a released lock to return a not found error and got: %v", err)
+	}
+
+	_, timeout, _ = cli.AcquireLock(client.AcquireLockOptions{
+		Key:             "lock",
+		Timeout:         100 * time.Millisecond,
+		ExtraConditions: []clientv3.Cmp{client.KeyValueIsCmp("condition", "=", "ok")},
+	})
+	if !timeout {
+		t.Errorf("Expected lock acquisition with unfulfilled extra conditions to time out and it didn't")
+	}
+}
+
+func TestChunkedKey(t *testing.T) {
+	cli := NewClient()
+
+	value := bytes.Repeat([]byte("a"), int(chunkSize)+10)
+	err := cli.PutChunkedKey(&client.ChunkedKeyPayload{
+		Key:   "chunked",
+		Value: io.NopCloser(bytes.NewReader(value)),
+		Size:  int64(len(value)),
+	})
+	if err != nil {
+		t.Errorf("Error occured putting chunked key: %s", err.Error())
+		return
+	}
+
+	chunks, _ := cli.GetPrefix("chunked/chunks/")
+	if len(chunks.Keys) != 2 {
+		t.Errorf("Expected the value to be split in 2 chunks and got %d", len(chunks.Keys))
+	}
+
+	payload, err := cli.GetChunkedKey("chunked")
+	if err != nil {
+		t.Errorf("Error occured getting chunked key: %s", err.Error())
+		return
+	}
+
+	read, _ := io.ReadAll(payload)
+	if !bytes.Equal(read, value) {
+		t.Errorf("Expected chunked key value to be read back identically and it wasn't")
+	}
+
+	cli.DeleteChunkedKey("chunked")
+	payload, _ = cli.GetChunkedKey("chunked")
+	if payload != nil {
+		t.Errorf("Expected chunked key not to be found after deletion and it was")
+	}
+}
@@ -0,0 +1,96 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Ferlab-Ste-Justine/etcd-sdk/client"
+)
+
+/*
+Join a group as represented by groupPrefix. A member with id memberId and content memberContent will be added.
+*/
+func (c *Client) JoinGroup(groupPrefix string, memberId string, memberContent string) error {
+	return c.JoinGroupCtx(c.Context, groupPrefix, memberId, memberContent)
+}
+
+func (c *Client) JoinGroupCtx(ctx context.Context, groupPrefix string, memberId string, memberContent string) error {
+	_, err := c.PutKeyCtx(ctx, fmt.Sprintf("%s%s", groupPrefix, memberId), memberContent)
+	return err
+}
+
+/*
+Leave a group as represented by groupPrefix. A member with id memberId will be removed.
+*/
+func (c *Client) LeaveGroup(groupPrefix string, memberId string) error {
+	return c.LeaveGroupCtx(c.Context, groupPrefix, memberId)
+}
+
+func (c *Client) LeaveGroupCtx(ctx context.Context, groupPrefix string, memberId string) error {
+	return c.DeleteKeyCtx(ctx, fmt.Sprintf("%s%s", groupPrefix, memberId))
+}
+
+/*
+Get a list of group members of a group represented by groupPrefix, along with the revision at the time the result was obtained.
+*/
+func (c *Client) GetGroupMembers(groupPrefix string) (map[string]string, int64, error) {
+	return c.GetGroupMembersCtx(c.Context, groupPrefix)
+}
+
+func (c *Client) GetGroupMembersCtx(ctx context.Context, groupPrefix string) (map[string]string, int64, error) {
+	info, err := c.GetPrefixCtx(ctx, groupPrefix)
+	if err != nil {
+		return nil, -1, err
+	}
+
+	return info.Keys.ToValueMap(groupPrefix), info.Revision, nil
+}
+
+/*
+Wait until a group as represented by groupPrefix has reached a threshold number of members.
+The returned channel will receive an error if there is an issue or otherwise will be closed when the wait condition is fulfilled.
+*/
+func (c *Client) WaitGroupCountThreshold(groupPrefix string, threshold int64, doneCh <-chan struct{}) <-chan error {
+	return c.WaitGroupCountThresholdCtx(c.Context, groupPrefix, threshold, doneCh)
+}
+
+func (c *Client) WaitGroupCountThresholdCtx(ctx context.Context, groupPrefix string, threshold int64, doneCh <-chan struct{}) <-chan error {
+	errCh := make(chan error)
+	go func() {
+		defer close(errCh)
+		members, rev, err := c.GetGroupMembersCtx(ctx, groupPrefix)
+		if err != nil {
+			errCh <- err
+			return
+		}
+
+		if int64(len(members)) >= threshold {
+			return
+		}
+
+		wcCh := c.WatchCtx(ctx, groupPrefix, client.WatchOptions{IsPrefix: true, TrimPrefix: true, Revision: rev + 1})
+		for {
+			select {
+			case res, ok := <-wcCh:
+				if !ok {
+					errCh <- errors.New("Watch stopped before reaching threshold")
+					return
+				}
+
+				if res.Error != nil {
+					errCh <- res.Error
+					return
+				}
+
+				res.Changes.ApplyOn(members)
+				if int64(len(members)) >= threshold {
+					return
+				}
+			case <-doneCh:
+				return
+			}
+		}
+	}()
+	return errCh
+}
@@ -0,0 +1,101 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/Ferlab-Ste-Justine/etcd-sdk/client"
+)
+
+//Returns the changes committed at or after the given revision.
+//Must be called with the mutex held.
+func (c *Client) changesFrom(revision int64) []revisionEvents {
+	idx := sort.Search(len(c.log), func(idx int) bool {
+		return c.log[idx].Revision >= revision
+	})
+
+	return c.log[idx:]
+}
+
+/*
+Watch the keys of a given prefix for changes and returns a channel that notifies of any changes.
+Changes committed at the same revision are reported in the same notification.
+*/
+func (c *Client) Watch(wKey string, opts client.WatchOptions) <-chan client.WatchNotification {
+	return c.WatchCtx(c.Context, wKey, opts)
+}
+
+func (c *Client) WatchCtx(ctx context.Context, wKey string, opts client.WatchOptions) <-chan client.WatchNotification {
+	outChan := make(chan client.WatchNotification)
+
+	go func() {
+		defer close(outChan)
+
+		c.mutex.Lock()
+		next := opts.Revision
+		if next <= 0 {
+			next = c.revision + 1
+		}
+		c.mutex.Unlock()
+
+		for {
+			c.mutex.Lock()
+			changes := c.changesFrom(next)
+			changed := c.changed
+			c.mutex.Unlock()
+
+			for _, change := range changes {
+				next = change.Revision + 1
+
+				output := client.WatchNotification{
+					Changes: client.WatchInfo{
+						Upserts:   make(map[string]client.WatchKeyInfo),
+						Deletions: []string{},
+					},
+				}
+
+				for _, ev := range change.Events {
+					if (opts.IsPrefix && (!strings.HasPrefix(ev.Key, wKey))) || ((!opts.IsPrefix) && ev.Key != wKey) {
+						continue
+					}
+
+					key := ev.Key
+					if opts.TrimPrefix {
+						key = strings.TrimPrefix(key, wKey)
+					}
+
+					if ev.Kv.Deleted {
+						output.Changes.Deletions = append(output.Changes.Deletions, key)
+					} else {
+						output.Changes.Upserts[key] = client.WatchKeyInfo{
+							Value:          ev.Kv.Value,
+							Version:        ev.Kv.Version,
+							CreateRevision: ev.Kv.CreateRevision,
+							ModRevision:    ev.Kv.ModRevision,
+							Lease:          ev.Kv.Lease,
+						}
+					}
+				}
+
+				if len(output.Changes.Upserts) == 0 && len(output.Changes.Deletions) == 0 {
+					continue
+				}
+
+				select {
+				case outChan <- output:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case <-changed:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return outChan
+}
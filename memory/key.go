@@ -0,0 +1,206 @@
+package memory
+
+import (
+	"context"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/Ferlab-Ste-Justine/etcd-sdk/client"
+)
+
+/*
+Upsert the given value in the key.
+Returns the revision of the store right after the key was upserted.
+*/
+func (c *Client) PutKey(key string, val string) (int64, error) {
+	return c.PutKeyCtx(c.Context, key, val)
+}
+
+func (c *Client) PutKeyCtx(ctx context.Context, key string, val string) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.apply([]op{op{Key: key, Value: val}}), nil
+}
+
+/*
+Get information on the given key including the value.
+*/
+func (c *Client) GetKey(key string, opts client.GetKeyOptions) (client.KeyInfo, error) {
+	return c.GetKeyCtx(c.Context, key, opts)
+}
+
+func (c *Client) GetKeyCtx(ctx context.Context, key string, opts client.GetKeyOptions) (client.KeyInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return client.KeyInfo{}, err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if err := c.checkRevision(opts.Revision); err != nil {
+		return client.KeyInfo{}, err
+	}
+
+	kv, ok := c.read(key, opts.Revision)
+	if !ok {
+		return client.KeyInfo{}, nil
+	}
+
+	return toKeyInfo(key, kv), nil
+}
+
+/*
+Delete a key.
+*/
+func (c *Client) DeleteKey(key string) error {
+	return c.DeleteKeyCtx(c.Context, key)
+}
+
+func (c *Client) DeleteKeyCtx(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.apply([]op{op{Key: key, Delete: true}})
+	return nil
+}
+
+/*
+Get all the keys within a certain range of values.
+*/
+func (c *Client) GetKeyRange(key string, rangeEnd string) (client.KeyRangeInfo, error) {
+	return c.GetKeyRangeCtx(c.Context, key, rangeEnd)
+}
+
+func (c *Client) GetKeyRangeCtx(ctx context.Context, key string, rangeEnd string) (client.KeyRangeInfo, error) {
+	keys := client.KeyInfoMap(make(map[string]client.KeyInfo))
+	if err := ctx.Err(); err != nil {
+		return client.KeyRangeInfo{Keys: keys, Revision: -1}, err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, rangeKey := range c.rangeKeys(key, rangeEnd, 0) {
+		kv, _ := c.read(rangeKey, 0)
+		keys[rangeKey] = toKeyInfo(rangeKey, kv)
+	}
+
+	return client.KeyRangeInfo{
+		Keys:     keys,
+		Revision: c.revision,
+	}, nil
+}
+
+/*
+Delete all the keys within a certain range of values.
+*/
+func (c *Client) DeleteKeyRange(key string, rangeEnd string) error {
+	return c.DeleteKeyRangeCtx(c.Context, key, rangeEnd)
+}
+
+func (c *Client) DeleteKeyRangeCtx(ctx context.Context, key string, rangeEnd string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.apply([]op{op{Key: key, RangeEnd: rangeEnd, Delete: true}})
+	return nil
+}
+
+/*
+Get all the keys that are prefixed by a given value
+*/
+func (c *Client) GetPrefix(prefix string) (client.KeyRangeInfo, error) {
+	return c.GetPrefixCtx(c.Context, prefix)
+}
+
+func (c *Client) GetPrefixCtx(ctx context.Context, prefix string) (client.KeyRangeInfo, error) {
+	return c.GetKeyRangeCtx(ctx, prefix, clientv3.GetPrefixRangeEnd(prefix))
+}
+
+/*
+Delete all the keys that are prefixed by a given value
+*/
+func (c *Client) DeletePrefix(prefix string) error {
+	return c.DeletePrefixCtx(c.Context, prefix)
+}
+
+func (c *Client) DeletePrefixCtx(ctx context.Context, prefix string) error {
+	return c.DeleteKeyRangeCtx(ctx, prefix, clientv3.GetPrefixRangeEnd(prefix))
+}
+
+/*
+Returns a KeyDiff structure containing all the operations that would need to be applied on the destination prefix to make it like the source prefix.
+Both prefixes are read at the same revision.
+*/
+func (c *Client) DiffBetweenPrefixes(srcPrefix string, dstPrefix string) (client.KeyDiff, error) {
+	return c.DiffBetweenPrefixesCtx(c.Context, srcPrefix, dstPrefix)
+}
+
+func (c *Client) DiffBetweenPrefixesCtx(ctx context.Context, srcPrefix string, dstPrefix string) (client.KeyDiff, error) {
+	if err := ctx.Err(); err != nil {
+		return client.KeyDiff{}, err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	src := make(map[string]string)
+	for _, key := range c.rangeKeys(srcPrefix, clientv3.GetPrefixRangeEnd(srcPrefix), 0) {
+		kv, _ := c.read(key, 0)
+		src[key[len(srcPrefix):]] = kv.Value
+	}
+
+	dst := make(map[string]string)
+	for _, key := range c.rangeKeys(dstPrefix, clientv3.GetPrefixRangeEnd(dstPrefix), 0) {
+		kv, _ := c.read(key, 0)
+		dst[key[len(dstPrefix):]] = kv.Value
+	}
+
+	return client.GetKeyDiff(src, dst), nil
+}
+
+/*
+Applies the operation predicated by KeyDiff argument on all the keys prefixed with a given value.
+All the operations are applied atomically at a single revision.
+*/
+func (c *Client) ApplyDiffToPrefix(prefix string, diff client.KeyDiff) error {
+	return c.ApplyDiffToPrefixCtx(c.Context, prefix, diff)
+}
+
+func (c *Client) ApplyDiffToPrefixCtx(ctx context.Context, prefix string, diff client.KeyDiff) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	ops := []op{}
+	for _, key := range diff.Deletions {
+		ops = append(ops, op{Key: prefix + key, Delete: true})
+	}
+
+	for key, val := range diff.Inserts {
+		ops = append(ops, op{Key: prefix + key, Value: val})
+	}
+
+	for key, val := range diff.Updates {
+		ops = append(ops, op{Key: prefix + key, Value: val})
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.apply(ops)
+	return nil
+}
@@ -0,0 +1,202 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/Ferlab-Ste-Justine/etcd-sdk/client"
+)
+
+type lease struct {
+	Ttl   int64
+	Keys  map[string]bool
+	Timer *time.Timer
+}
+
+//Creates a lease that will be revoked when its ttl expires.
+//Must be called with the mutex held.
+func (c *Client) grant(ttl int64) clientv3.LeaseID {
+	c.leaseId += 1
+	id := clientv3.LeaseID(c.leaseId)
+	c.leases[id] = &lease{
+		Ttl:  ttl,
+		Keys: make(map[string]bool),
+		Timer: time.AfterFunc(time.Duration(ttl)*time.Second, func() {
+			c.mutex.Lock()
+			defer c.mutex.Unlock()
+
+			c.revoke(id)
+		}),
+	}
+
+	return id
+}
+
+//Revokes a lease, deleting all the keys attached to it at a single revision.
+//Must be called with the mutex held.
+func (c *Client) revoke(id clientv3.LeaseID) {
+	l, ok := c.leases[id]
+	if !ok {
+		return
+	}
+
+	l.Timer.Stop()
+	ops := []op{}
+	for key, _ := range l.Keys {
+		ops = append(ops, op{Key: key, Delete: true})
+	}
+	delete(c.leases, id)
+	c.apply(ops)
+}
+
+//Must be called with the mutex held.
+func (c *Client) attachLease(key string, id int64) {
+	if l, ok := c.leases[clientv3.LeaseID(id)]; ok {
+		l.Keys[key] = true
+	}
+}
+
+//Must be called with the mutex held.
+func (c *Client) detachLease(key string, id int64) {
+	if l, ok := c.leases[clientv3.LeaseID(id)]; ok {
+		delete(l.Keys, key)
+	}
+}
+
+/*
+Acquires a lock on the key specified in the options, waiting for it to be released if it is already held.
+Like with the etcd client, the lock is held by a lease that expires after the lock's ttl and the extra conditions must be fulfilled for the lock to be acquired.
+With the KeepAlive option, the lease never expires. Unlike with the etcd client, the context of the returned lock is never cancelled.
+The Queued option has no effect, as changes to the store are always waited on rather than polled.
+*/
+func (c *Client) AcquireLock(opts client.AcquireLockOptions) (*client.Lock, bool, error) {
+	return c.AcquireLockCtx(c.Context, opts)
+}
+
+func (c *Client) AcquireLockCtx(ctx context.Context, opts client.AcquireLockOptions) (*client.Lock, bool, error) {
+	if opts.Ttl == 0 {
+		opts.Ttl = 600
+	}
+	if int64(opts.Timeout) == 0 {
+		opts.Timeout = 30 * time.Second
+	}
+	if int64(opts.RetryInterval) == 0 {
+		opts.RetryInterval = 500 * time.Millisecond
+	}
+
+	deadline := time.Now().Add(opts.Timeout)
+	for {
+		now := time.Now()
+		if now.After(deadline) {
+			return nil, true, &client.KeyError{Key: opts.Key, Kind: client.ErrLockTimeout}
+		}
+
+		if err := ctx.Err(); err != nil {
+			return nil, false, err
+		}
+
+		c.mutex.Lock()
+		_, held := c.read(opts.Key, 0)
+		if (!held) && c.compare(opts.ExtraConditions) {
+			id := c.grant(opts.Ttl)
+			if opts.KeepAlive {
+				c.leases[id].Timer.Stop()
+			}
+			lock := client.Lock{
+				Lease:     id,
+				Ttl:       opts.Ttl,
+				Timestamp: now,
+				Revision:  c.revision,
+				Key:       opts.Key,
+			}
+			output, _ := json.Marshal(lock)
+			c.apply([]op{op{Key: opts.Key, Value: string(output), Lease: int64(id)}})
+			lock.FencingToken = c.revision
+			c.mutex.Unlock()
+
+			return &lock, false, nil
+		}
+		changed := c.changed
+		c.mutex.Unlock()
+
+		//Unlike the etcd client which polls, changes to the store are waited on
+		timer := time.NewTimer(opts.RetryInterval)
+		select {
+		case <-changed:
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+		timer.Stop()
+	}
+}
+
+/*
+Reads the lock stored at the given key.
+Returns an error matching client.ErrNotFound if there is no lock at the key.
+*/
+func (c *Client) ReadLock(key string) (*client.Lock, error) {
+	return c.ReadLockCtx(c.Context, key)
+}
+
+func (c *Client) ReadLockCtx(ctx context.Context, key string) (*client.Lock, error) {
+	info, err := c.GetKeyCtx(ctx, key, client.GetKeyOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if !info.Found() {
+		return nil, &client.KeyError{Key: key, Kind: client.ErrNotFound}
+	}
+
+	lock := client.Lock{Key: key, FencingToken: info.CreateRevision}
+	unmarshalErr := json.Unmarshal([]byte(info.Value), &lock)
+	if unmarshalErr != nil {
+		return nil, unmarshalErr
+	}
+
+	return &lock, nil
+}
+
+/*
+Releases the lock stored at the given key by revoking its lease.
+Returns an error matching client.ErrNotFound if there is no lock at the key.
+*/
+func (c *Client) ReleaseLock(key string) error {
+	return c.ReleaseLockCtx(c.Context, key)
+}
+
+func (c *Client) ReleaseLockCtx(ctx context.Context, key string) error {
+	lock, lockErr := c.ReadLockCtx(ctx, key)
+	if lockErr != nil {
+		return lockErr
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.revoke(lock.Lease)
+	return nil
+}
+
+/*
+Releases a lock acquired with AcquireLock, only if it is still held by it.
+Returns an error matching client.ErrLockNotHeld if the lock expired or is held by another acquirer.
+*/
+func (c *Client) ReleaseHeldLock(lock *client.Lock) error {
+	return c.ReleaseHeldLockCtx(c.Context, lock)
+}
+
+func (c *Client) ReleaseHeldLockCtx(ctx context.Context, lock *client.Lock) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	current, held := c.read(lock.Key, 0)
+	c.revoke(lock.Lease)
+	if (!held) || current.CreateRevision != lock.FencingToken || current.Lease != int64(lock.Lease) {
+		return &client.KeyError{Key: lock.Key, Kind: client.ErrLockNotHeld}
+	}
+
+	return nil
+}
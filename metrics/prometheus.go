@@ -0,0 +1,194 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Ferlab-Ste-Justine/etcd-sdk/client"
+)
+
+/*
+Options for the prometheus metrics collector.
+*/
+type PrometheusOptions struct {
+	//Namespace prefixed to the name of the metrics. Defaults to "etcd_sdk" if empty.
+	Namespace   string
+	//Buckets of the operation latency histogram, in seconds. Defaults to prometheus.DefBuckets if empty.
+	Buckets     []float64
+	//Labels added to all the metrics, useful to distinguish several clients in the same process
+	ConstLabels prometheus.Labels
+}
+
+/*
+Collector of client metrics that exposes them to prometheus.
+It should be instanciated with the NewPrometheusCollector function and passed as the Metrics option of the client.
+*/
+type PrometheusCollector struct {
+	operations *prometheus.CounterVec
+	durations  *prometheus.HistogramVec
+	retries    *prometheus.CounterVec
+	bytes      *prometheus.CounterVec
+	watches    prometheus.Gauge
+	locks      prometheus.Gauge
+}
+
+/*
+Creates a prometheus metrics collector and registers its metrics with the given registerer.
+The following metrics are exposed, prefixed with the namespace:
+  - operations_total: Counter of client method calls, by operation and by code. The code is "OK" for calls that succeeded.
+  - operation_duration_seconds: Histogram of client method call latencies, by operation.
+  - retries_total: Counter of retried requests, by operation.
+  - bytes_total: Counter of bytes of keys and values read from or written to etcd, by operation and by direction ("read" or "write").
+  - active_watches: Gauge of active watches.
+  - held_locks: Gauge of locks acquired by the client that were not yet released, expired or lost.
+*/
+func NewPrometheusCollector(reg prometheus.Registerer, opts PrometheusOptions) (*PrometheusCollector, error) {
+	if opts.Namespace == "" {
+		opts.Namespace = "etcd_sdk"
+	}
+	if len(opts.Buckets) == 0 {
+		opts.Buckets = prometheus.DefBuckets
+	}
+
+	collector := &PrometheusCollector{
+		operations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   opts.Namespace,
+			Name:        "operations_total",
+			Help:        "Number of client method calls by operation and result code.",
+			ConstLabels: opts.ConstLabels,
+		}, []string{"operation", "code"}),
+		durations: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   opts.Namespace,
+			Name:        "operation_duration_seconds",
+			Help:        "Latency of client method calls by operation.",
+			Buckets:     opts.Buckets,
+			ConstLabels: opts.ConstLabels,
+		}, []string{"operation"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   opts.Namespace,
+			Name:        "retries_total",
+			Help:        "Number of retried requests to etcd by operation.",
+			ConstLabels: opts.ConstLabels,
+		}, []string{"operation"}),
+		bytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   opts.Namespace,
+			Name:        "bytes_total",
+			Help:        "Bytes of keys and values read from or written to etcd by operation and direction.",
+			ConstLabels: opts.ConstLabels,
+		}, []string{"operation", "direction"}),
+		watches: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   opts.Namespace,
+			Name:        "active_watches",
+			Help:        "Number of active watches.",
+			ConstLabels: opts.ConstLabels,
+		}),
+		locks: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   opts.Namespace,
+			Name:        "held_locks",
+			Help:        "Number of locks acquired by the client that were not yet released, expired or lost.",
+			ConstLabels: opts.ConstLabels,
+		}),
+	}
+
+	for _, metric := range []prometheus.Collector{
+		collector.operations,
+		collector.durations,
+		collector.retries,
+		collector.bytes,
+		collector.watches,
+		collector.locks,
+	} {
+		err := reg.Register(metric)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return collector, nil
+}
+
+func (c *PrometheusCollector) ObserveOperation(operation string, duration time.Duration, err error) {
+	c.operations.WithLabelValues(operation, ErrorCode(err)).Inc()
+	c.durations.WithLabelValues(operation).Observe(duration.Seconds())
+}
+
+func (c *PrometheusCollector) ObserveRetry(operation string, err error) {
+	c.retries.WithLabelValues(operation).Inc()
+}
+
+func (c *PrometheusCollector) ObserveBytes(operation string, read int64, written int64) {
+	if read > 0 {
+		c.bytes.WithLabelValues(operation, "read").Add(float64(read))
+	}
+	if written > 0 {
+		c.bytes.WithLabelValues(operation, "write").Add(float64(written))
+	}
+}
+
+func (c *PrometheusCollector) AddWatches(delta int64) {
+	c.watches.Add(float64(delta))
+}
+
+func (c *PrometheusCollector) AddHeldLocks(delta int64) {
+	c.locks.Add(float64(delta))
+}
+
+var sdkErrorCodes = []struct {
+	Err  error
+	Code string
+}{
+	{client.ErrNotFound, "NotFound"},
+	{client.ErrLockTimeout, "LockTimeout"},
+	{client.ErrTransactionFailed, "TransactionFailed"},
+	{client.ErrChunkMissing, "ChunkMissing"},
+	{client.ErrConnectionTimeout, "ConnectionTimeout"},
+	{client.ErrWatchFailed, "WatchFailed"},
+	{client.ErrNoLeader, "NoLeader"},
+	{client.ErrNoTransferCandidate, "NoTransferCandidate"},
+	{client.ErrMemberNotFound, "MemberNotFound"},
+	{client.ErrMemberNotResponsive, "MemberNotResponsive"},
+	{client.ErrMemberIsLearner, "MemberIsLearner"},
+	{client.ErrInvalidOptions, "InvalidOptions"},
+	{client.ErrEncodeFailed, "EncodeFailed"},
+	{client.ErrDecodeFailed, "DecodeFailed"},
+	{client.ErrUpdateConflict, "UpdateConflict"},
+	{client.ErrRevisionCompacted, "RevisionCompacted"},
+	{client.ErrDecryptionFailed, "DecryptionFailed"},
+	{client.ErrDecompressionFailed, "DecompressionFailed"},
+	{client.ErrLeaseLost, "LeaseLost"},
+	{client.ErrLockNotHeld, "LockNotHeld"},
+	{context.Canceled, "Canceled"},
+	{context.DeadlineExceeded, "DeadlineExceeded"},
+}
+
+/*
+Returns a code with a low cardinality for an error returned by the client, for use as a metric label.
+The code is "OK" for a nil error, the name of the sdk error for errors of the sdk, the grpc code for errors returned by etcd and "Unknown" otherwise.
+*/
+func ErrorCode(err error) string {
+	if err == nil {
+		return "OK"
+	}
+
+	for _, sdkErr := range sdkErrorCodes {
+		if errors.Is(err, sdkErr.Err) {
+			return sdkErr.Code
+		}
+	}
+
+	var etcdErr rpctypes.EtcdError
+	if errors.As(err, &etcdErr) {
+		return etcdErr.Code().String()
+	}
+
+	if stat, ok := status.FromError(err); ok {
+		return stat.Code().String()
+	}
+
+	return "Unknown"
+}
@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+
+	"github.com/Ferlab-Ste-Justine/etcd-sdk/client"
+)
+
+func TestPrometheusCollector(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	collector, err := NewPrometheusCollector(reg, PrometheusOptions{})
+	if err != nil {
+		t.Errorf("Error occured creating the collector: %s", err.Error())
+		return
+	}
+
+	var _ client.MetricsCollector = collector
+
+	collector.ObserveOperation("PutKey", 10*time.Millisecond, nil)
+	collector.ObserveOperation("PutKey", 10*time.Millisecond, nil)
+	collector.ObserveOperation("AcquireLock", time.Second, &client.KeyError{Key: "lock", Kind: client.ErrLockTimeout})
+	collector.ObserveRetry("PutKey", errors.New("Unavailable"))
+	collector.ObserveBytes("PutKey", 0, 12)
+	collector.AddWatches(1)
+	collector.AddHeldLocks(1)
+	collector.AddHeldLocks(-1)
+
+	if val := testutil.ToFloat64(collector.operations.WithLabelValues("PutKey", "OK")); val != 2 {
+		t.Errorf("Expected 2 successful PutKey operations to be counted and got %f", val)
+	}
+	if val := testutil.ToFloat64(collector.operations.WithLabelValues("AcquireLock", "LockTimeout")); val != 1 {
+		t.Errorf("Expected a lock timeout to be counted and got %f", val)
+	}
+	if val := testutil.ToFloat64(collector.retries.WithLabelValues("PutKey")); val != 1 {
+		t.Errorf("Expected a retry to be counted and got %f", val)
+	}
+	if val := testutil.ToFloat64(collector.bytes.WithLabelValues("PutKey", "write")); val != 12 {
+		t.Errorf("Expected 12 bytes written to be counted and got %f", val)
+	}
+	if testutil.ToFloat64(collector.watches) != 1 || testutil.ToFloat64(collector.locks) != 0 {
+		t.Errorf("Expected 1 active watch and no held locks")
+	}
+	if count := testutil.CollectAndCount(collector.durations); count != 2 {
+		t.Errorf("Expected latency histograms for 2 operations and got %d", count)
+	}
+
+	_, err = NewPrometheusCollector(reg, PrometheusOptions{})
+	if err == nil {
+		t.Errorf("Expected registering the metrics twice with the same registerer to fail and it didn't")
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	codes := map[string]error{
+		"OK":                nil,
+		"NotFound":          fmt.Errorf("Reading lock: %w", &client.KeyError{Key: "lock", Kind: client.ErrNotFound}),
+		"DeadlineExceeded":  context.DeadlineExceeded,
+		"OutOfRange":        rpctypes.ErrCompacted,
+		"Unknown":           errors.New("Other error"),
+	}
+
+	for code, err := range codes {
+		if ErrorCode(err) != code {
+			t.Errorf("Expected error code %s for error %v and got %s", code, err, ErrorCode(err))
+		}
+	}
+}
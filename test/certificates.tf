@@ -14,6 +14,7 @@ resource "tls_cert_request" "request" {
     "127.0.0.2",
     "127.0.0.3"
   ]
+  dns_names       = ["localhost"]
   subject {
     common_name  = "localhost"
     organization = "localhost"